@@ -15,61 +15,52 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/subscriptions": {
+        "/admin/stats/explain": {
             "get": {
-                "description": "Retrieve paginated list of subscriptions with optional sorting",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
                 ],
+                "description": "Run EXPLAIN (ANALYZE, FORMAT JSON) on the summary query (admin only, requires ENABLE_EXPLAIN)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Subscriptions"
+                    "Admin"
                 ],
-                "summary": "List subscriptions with pagination",
+                "summary": "Explain the summary query",
                 "parameters": [
                     {
-                        "maximum": 100,
-                        "minimum": 1,
-                        "type": "integer",
-                        "default": 10,
-                        "description": "Maximum number of items to return",
-                        "name": "limit",
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "User UUID",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by service name, matched case-insensitively, all services when omitted",
+                        "name": "service_name",
                         "in": "query"
                     },
                     {
-                        "minimum": 0,
-                        "type": "integer",
-                        "default": 0,
-                        "description": "Number of items to skip",
-                        "name": "offset",
+                        "type": "boolean",
+                        "description": "Match service_name exactly (case-sensitive)",
+                        "name": "exact_service_name",
                         "in": "query"
                     },
                     {
-                        "enum": [
-                            "id",
-                            "user_id",
-                            "service_name",
-                            "price",
-                            "start_date",
-                            "end_date"
-                        ],
                         "type": "string",
-                        "default": "id",
-                        "description": "Field to sort by",
-                        "name": "sort_by",
+                        "description": "Start date (MM-YYYY), defaults to the start of DEFAULT_STATS_PERIOD before to",
+                        "name": "from",
                         "in": "query"
                     },
                     {
-                        "enum": [
-                            "asc",
-                            "desc"
-                        ],
                         "type": "string",
-                        "default": "desc",
-                        "description": "Sort order",
-                        "name": "order",
+                        "description": "End date (MM-YYYY), defaults to the current month",
+                        "name": "to",
                         "in": "query"
                     }
                 ],
@@ -77,11 +68,23 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.ListSubscriptionsResponse"
+                            "$ref": "#/definitions/models.ExplainResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad Request - Invalid query parameters",
+                        "description": "Bad Request - Invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Missing or invalid admin key",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Admin api is disabled",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -93,39 +96,75 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "post": {
-                "description": "Create a subscription for a user",
-                "consumes": [
-                    "application/json"
+            }
+        },
+        "/admin/stats/inflight": {
+            "get": {
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
                 ],
+                "description": "Number of requests currently being processed and the configured maximum, 0 when unlimited (admin only)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Subscriptions"
+                    "Admin"
                 ],
-                "summary": "Create a new subscription",
-                "parameters": [
-                    {
-                        "description": "Subscription payload",
-                        "name": "subscription",
-                        "in": "body",
-                        "required": true,
+                "summary": "Get in-flight request statistics",
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.CreateSubscriptionRequest"
+                            "$ref": "#/definitions/models.InFlightStatsResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Missing or invalid admin key",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Admin api is disabled",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
+                }
+            }
+        },
+        "/admin/stats/users": {
+            "get": {
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
+                ],
+                "description": "Count distinct users with at least one subscription and with a subscription active now (admin only)",
+                "produces": [
+                    "application/json"
                 ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Get distinct user statistics",
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.SubscriptionResponse"
+                            "$ref": "#/definitions/models.UserStatsResponse"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized - Missing or invalid admin key",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Admin api is disabled",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -139,45 +178,46 @@ const docTemplate = `{
                 }
             }
         },
-        "/subscriptions/summary": {
+        "/admin/subscriptions": {
             "get": {
-                "description": "Calculate subscription statistics including total cost, unique months, and count for a user",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
                 ],
+                "description": "Return a page of subscriptions whose user_id starts with the given prefix (at least 8 hexadecimal characters, admin only)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Subscriptions"
+                    "Admin"
                 ],
-                "summary": "Get user subscription summary",
+                "summary": "Find subscriptions by user ID prefix",
                 "parameters": [
                     {
+                        "maxLength": 36,
+                        "minLength": 8,
                         "type": "string",
-                        "format": "uuid",
-                        "description": "User UUID",
-                        "name": "user_id",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filter by service name",
-                        "name": "service_name",
+                        "description": "User ID prefix",
+                        "name": "user_prefix",
                         "in": "query",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Start date (MM-YYYY)",
-                        "name": "from",
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Maximum number of items to return",
+                        "name": "limit",
                         "in": "query"
                     },
                     {
-                        "type": "string",
-                        "description": "End date (MM-YYYY)",
-                        "name": "to",
+                        "minimum": 0,
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Number of items to skip",
+                        "name": "offset",
                         "in": "query"
                     }
                 ],
@@ -185,11 +225,23 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.UserSubscriptionSummaryResponse"
+                            "$ref": "#/definitions/models.ListSubscriptionsResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad Request - Invalid parameters",
+                        "description": "Bad Request - Invalid prefix or pagination",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Missing or invalid admin key",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Admin api is disabled",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -203,44 +255,36 @@ const docTemplate = `{
                 }
             }
         },
-        "/subscriptions/{id}": {
-            "get": {
-                "description": "Retrieve a subscription using its ID",
-                "consumes": [
-                    "application/json"
+        "/admin/subscriptions/normalize-dates": {
+            "post": {
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
                 ],
+                "description": "Rewrite every start_date and end_date not on the first of its month to it, across all tenants, in batches within one transaction, and return the number of subscriptions rewritten (admin only)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Subscriptions"
-                ],
-                "summary": "Get subscription by ID",
-                "parameters": [
-                    {
-                        "minimum": 1,
-                        "type": "integer",
-                        "description": "Subscription ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
+                    "Admin"
                 ],
+                "summary": "Normalize subscription dates",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.SubscriptionResponse"
+                            "$ref": "#/definitions/models.NormalizeDatesResponse"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request - Invalid subscription ID",
+                    "401": {
+                        "description": "Unauthorized - Missing or invalid admin key",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found - Subscription does not exist",
+                    "403": {
+                        "description": "Forbidden - Admin api is disabled",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -252,99 +296,379 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Partially update subscription fields by ID (only provided fields are modified)",
-                "consumes": [
-                    "application/json"
-                ],
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Report that the service process is alive",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Subscriptions"
+                    "Health"
                 ],
-                "summary": "Update subscription",
-                "parameters": [
-                    {
-                        "minimum": 1,
-                        "type": "integer",
-                        "description": "Subscription ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Update payload (partial update)",
-                        "name": "subscription",
-                        "in": "body",
-                        "required": true,
+                "summary": "Liveness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.UpdateSubscriptionRequest"
+                            "$ref": "#/definitions/models.HealthResponse"
                         }
                     }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Check the database connection and, when enabled, that no migrations are pending",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
                 ],
+                "summary": "Readiness probe",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.SubscriptionResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request - Invalid input or validation failed",
-                        "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
-                        }
-                    },
-                    "404": {
-                        "description": "Not Found - Subscription does not exist",
-                        "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
+                            "$ref": "#/definitions/models.HealthResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "503": {
+                        "description": "Service Unavailable - A dependency check failed",
                         "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
+                            "$ref": "#/definitions/models.HealthResponse"
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Permanently delete a subscription by ID",
+            }
+        },
+        "/subscriptions": {
+            "get": {
+                "description": "Retrieve paginated list of subscriptions with optional sorting.\nWith pagination=cursor, or when after is given, the list is keyset paginated in ascending id order instead: limit defaults to 50 (max 200), the other parameters are ignored, and the response is a models.CursorSubscriptionsResponse with items and next_cursor, empty on the last page.\nWith page or page_size, the response is a models.PaginatedSubscriptions with items, total, page, page_size and total_pages instead, keeping the counts and totals options.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/vnd.api+json"
                 ],
                 "tags": [
                     "Subscriptions"
                 ],
-                "summary": "Delete subscription",
+                "summary": "List subscriptions with pagination",
                 "parameters": [
                     {
+                        "maximum": 200,
                         "minimum": 1,
                         "type": "integer",
-                        "description": "Subscription ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "204": {
-                        "description": "No Content - Subscription successfully deleted"
-                    },
-                    "400": {
-                        "description": "Bad Request - Invalid subscription ID",
-                        "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
-                        }
+                        "default": 10,
+                        "description": "Maximum number of items to return, at most 100, with pagination=cursor default 50 and at most 200",
+                        "name": "limit",
+                        "in": "query"
                     },
-                    "404": {
-                        "description": "Not Found - Subscription does not exist",
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Number of items to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "user_id",
+                            "service_name",
+                            "price",
+                            "start_date",
+                            "end_date"
+                        ],
+                        "type": "string",
+                        "default": "id",
+                        "description": "Field to sort by",
+                        "name": "sort_by",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "default": "desc",
+                        "description": "Sort order",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "active",
+                            "upcoming",
+                            "expired"
+                        ],
+                        "type": "string",
+                        "description": "Filter by status derived from the dates",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include the unfiltered and filtered-out totals in meta",
+                        "name": "include_counts",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Append a totals footer with the row count and price sum of the page and of every filtered row",
+                        "name": "with_totals",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Add the cost of each subscription over the from-to period",
+                        "name": "with_cost",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cost period start (MM-YYYY) with with_cost, defaults to the start of DEFAULT_STATS_PERIOD before to",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cost period end (MM-YYYY) with with_cost, defaults to the current month",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "maxLength": 100,
+                        "type": "string",
+                        "description": "Keep subscriptions whose service name or description contains it, case-insensitively",
+                        "name": "search",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "cursor"
+                        ],
+                        "type": "string",
+                        "description": "cursor selects keyset pagination",
+                        "name": "pagination",
+                        "in": "query"
+                    },
+                    {
+                        "maxLength": 64,
+                        "type": "string",
+                        "description": "Opaque next_cursor of the previous page, implies pagination=cursor",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Page number, replaces offset with (page-1)*page_size, invalid values mean 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Page size, replaces limit and is clamped to 100, invalid values keep limit",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ListSubscriptionsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid query parameters or cost period",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a subscription for a user. With upsert=true, the subscription of the same user and service (in any case) starting the same month is replaced with the payload and returned with 200 and its Content-Location; otherwise one is created and returned with 201 and its Location.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/vnd.api+json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Create a new subscription",
+                "parameters": [
+                    {
+                        "description": "Subscription payload",
+                        "name": "subscription",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateSubscriptionRequest"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Replace the subscription of the same user and service starting the same month",
+                        "name": "upsert",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Existing subscription replaced (upsert)",
+                        "schema": {
+                            "$ref": "#/definitions/models.SubscriptionResponse"
+                        },
+                        "headers": {
+                            "Content-Location": {
+                                "type": "string",
+                                "description": "URL of the replaced subscription"
+                            }
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.SubscriptionResponse"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "URL of the created subscription"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/compare": {
+            "post": {
+                "description": "Compare two or more subscriptions side by side with their annualized cost",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Compare subscriptions",
+                "parameters": [
+                    {
+                        "description": "Subscription IDs to compare",
+                        "name": "ids",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CompareSubscriptionsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CompareSubscriptionsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Fewer than two or invalid IDs",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - Lists the missing subscription IDs",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/periods": {
+            "get": {
+                "description": "Continuous ranges of months a user was subscribed to a service, in chronological order. Overlapping subscriptions and subscriptions starting the month after another ends are merged; to is null for an open-ended range.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Get subscription coverage periods",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID (UUID)",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Service name, matched case-insensitively",
+                        "name": "service_name",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SubscriptionPeriodsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid user ID or service name",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity - Too many subscriptions",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -357,97 +681,1687 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "models.CreateSubscriptionRequest": {
-            "description": "Defines the request body for creating a new subscription.",
+        },
+        "/subscriptions/stats/avg-price": {
+            "get": {
+                "description": "Average, median, min and max monthly price of a service across all users, the service name matched case-insensitively",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Get average price of a service",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Service name",
+                        "name": "service_name",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PriceStatsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid service name",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - Service has no subscriptions",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/stats/compare": {
+            "get": {
+                "description": "Total cost and months of a user over period A and period B, with the absolute and percent change from A to B. delta_percent is null when period A cost nothing.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Compare the spend of two periods",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "User UUID",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Base period start (MM-YYYY)",
+                        "name": "period_a_from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Base period end (MM-YYYY)",
+                        "name": "period_a_to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Compared period start (MM-YYYY)",
+                        "name": "period_b_from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Compared period end (MM-YYYY)",
+                        "name": "period_b_to",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.StatsCompareResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid user ID or period",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity - Too many rows, narrow the query",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/stats/quarterly": {
+            "get": {
+                "description": "Total cost of a user for each of the four quarters of a year, every service included, computed like a summary of each quarter. Quarters without activity cost 0.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Get quarterly costs of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "User UUID",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "maximum": 2100,
+                        "minimum": 1970,
+                        "type": "integer",
+                        "description": "Year",
+                        "name": "year",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.QuarterlyStatsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid user ID or year",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity - Too many rows, narrow the query",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/stats/revenue": {
+            "get": {
+                "description": "Summed price of the subscriptions active in each month of the period, of every subscription or of one user. The period defaults to DEFAULT_STATS_PERIOD up to the current month (the last 12 months when it is all_time) and spans at most 1200 months.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Get revenue by month",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "User UUID, all users when omitted",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start date (MM-YYYY), defaults to the start of DEFAULT_STATS_PERIOD before to",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date (MM-YYYY), defaults to the current month",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.RevenueByMonthResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid user ID or period",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/stats/services": {
+            "get": {
+                "description": "Summarize total cost, months and subscription count of each service of a user, by cost descending",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Get per-service summaries of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "User UUID",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start date (MM-YYYY), defaults to the start of DEFAULT_STATS_PERIOD before to",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date (MM-YYYY), defaults to the current month",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ServiceStatsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity - Too many rows, narrow the query",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/stats/team": {
+            "post": {
+                "description": "Combine the total cost of up to 100 users over a period, with a per-user breakdown",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Get team spend",
+                "parameters": [
+                    {
+                        "description": "User IDs and optional period (MM-YYYY)",
+                        "name": "team",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TeamStatsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TeamStatsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid user IDs or period",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity - Too many rows, narrow the query",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/summary": {
+            "get": {
+                "description": "Calculate subscription statistics including total cost, unique months, and count for a user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Get user subscription summary",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "User UUID",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by service name, matched case-insensitively, all services when omitted",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Match service_name exactly (case-sensitive)",
+                        "name": "exact_service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start date (MM-YYYY), defaults to the start of DEFAULT_STATS_PERIOD before to",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date (MM-YYYY), defaults to the current month",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Roll family plan members up into their parent subscriptions",
+                        "name": "include_members",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "Spend limit the total cost is compared against",
+                        "name": "budget",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "List the subscriptions contributing nothing to the total and why",
+                        "name": "diagnostics",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserSubscriptionSummaryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity - Too many rows, narrow the query",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/validate-batch": {
+            "post": {
+                "description": "Validate and normalize up to 100 create payloads, returning each normalized result with its non-blocking warnings, or its errors. Nothing is written to the database.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Validate subscriptions without saving",
+                "parameters": [
+                    {
+                        "description": "Batch of subscription payloads",
+                        "name": "subscriptions",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ValidateBatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ValidateBatchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Empty or oversized batch",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}": {
+            "get": {
+                "description": "Retrieve a subscription using its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/vnd.api+json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Get subscription by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID, a UUID when UUID_SUBSCRIPTION_IDS is set",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SubscriptionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid subscription ID",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - Subscription does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Partially update subscription fields by ID (only provided fields are modified)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/vnd.api+json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Update subscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID, a UUID when UUID_SUBSCRIPTION_IDS is set",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Update payload (partial update)",
+                        "name": "subscription",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateSubscriptionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SubscriptionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid input or validation failed",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - Subscription does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Permanently delete a subscription by ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Delete subscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID, a UUID when UUID_SUBSCRIPTION_IDS is set",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content - Subscription successfully deleted"
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid subscription ID",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - Subscription does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/cancel": {
+            "post": {
+                "description": "Set the end_date of a subscription to the given month (MM-YYYY), the current month by default. The end_date must not be before the start_date.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/vnd.api+json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Cancel subscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID, a UUID when UUID_SUBSCRIPTION_IDS is set",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Month the subscription ends",
+                        "name": "cancel",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.CancelSubscriptionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SubscriptionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid input or end_date before start_date",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - Subscription does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/cost-per-month": {
+            "get": {
+                "description": "Compute the effective monthly cost of a subscription over its active months in a period",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Get subscription cost per month",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID, a UUID when UUID_SUBSCRIPTION_IDS is set",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start date (MM-YYYY), defaults to the subscription start",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date (MM-YYYY), defaults to the current month",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CostPerMonthResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid subscription ID or period",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - Subscription does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/extend": {
+            "post": {
+                "description": "Push the end_date of a subscription forward by 1 to 120 monthly periods. Open-ended subscriptions can't be extended.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/vnd.api+json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Extend subscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID, a UUID when UUID_SUBSCRIPTION_IDS is set",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Number of periods to extend by",
+                        "name": "extend",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ExtendSubscriptionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SubscriptionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid input or subscription has no end date",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - Subscription does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/members": {
+            "get": {
+                "description": "Retrieve the member subscriptions linked to a primary subscription",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "List family plan members",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID, a UUID when UUID_SUBSCRIPTION_IDS is set",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SubscriptionMembersResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid subscription ID",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - Subscription does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity - Too many rows, narrow the query",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/timeline": {
+            "get": {
+                "description": "Monthly cost contribution of a subscription, one point per month, over its lifespan or the queried period. Open-ended subscriptions run up to the current month; inactive months cost 0.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Get subscription cost timeline",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID, a UUID when UUID_SUBSCRIPTION_IDS is set",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start date (MM-YYYY), defaults to the subscription start",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date (MM-YYYY), defaults to the subscription end or the current month",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SubscriptionTimelineResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid subscription ID or period",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - Subscription does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/export": {
+            "get": {
+                "description": "Download every subscription of a user as a single JSON document",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Export a user's data",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "User UUID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserExportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid user ID",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/subscriptions": {
+            "delete": {
+                "security": [
+                    {
+                        "AdminKey": []
+                    }
+                ],
+                "description": "Delete every subscription of a user in one transaction and return how many were removed (admin only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Erase a user's data",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "User UUID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserEraseResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid user ID",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Missing or invalid admin key",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Admin api is disabled",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "models.BatchValidationResult": {
+            "description": "Defines the validation outcome of a single item of a batch.",
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "subscription": {
+                    "$ref": "#/definitions/models.SubscriptionResponse"
+                },
+                "valid": {
+                    "type": "boolean"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.CancelSubscriptionRequest": {
+            "description": "Defines the request payload to cancel a subscription, end_date defaults to the current month",
+            "type": "object",
+            "properties": {
+                "end_date": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CompareSubscriptionsRequest": {
+            "description": "Defines the request payload for comparing subscriptions side by side",
+            "type": "object",
+            "required": [
+                "ids"
+            ],
+            "properties": {
+                "ids": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "minItems": 2,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.CompareSubscriptionsResponse": {
+            "description": "Defines the API response structure for a subscription comparison",
+            "type": "object",
+            "properties": {
+                "subscriptions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ComparedSubscription"
+                    }
+                }
+            }
+        },
+        "models.ComparedSubscription": {
+            "description": "Defines a subscription of a comparison with its computed annualized cost",
+            "type": "object",
+            "properties": {
+                "annotation": {
+                    "type": "string",
+                    "enum": [
+                        "cheapest",
+                        "most_expensive"
+                    ]
+                },
+                "annual_cost": {
+                    "type": "integer"
+                },
+                "cost": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-07-01T12:00:00Z"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "end_date": {
+                    "type": "string",
+                    "x-nullable": true,
+                    "example": "12-2025"
+                },
+                "parent_id": {
+                    "type": "string",
+                    "x-nullable": true
+                },
+                "price": {
+                    "type": "integer"
+                },
+                "service_id": {
+                    "type": "string",
+                    "example": "42"
+                },
+                "service_name": {
+                    "type": "string"
+                },
+                "start_date": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "active",
+                        "upcoming",
+                        "expired"
+                    ]
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2025-07-01T12:00:00Z"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.CostPerMonthResponse": {
+            "description": "Defines the API response structure for the effective monthly cost of a subscription",
+            "type": "object",
+            "properties": {
+                "active_months": {
+                    "type": "integer"
+                },
+                "cost_per_month": {
+                    "type": "number"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "total_cost": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.CoveragePeriod": {
+            "description": "Defines a continuous range of months covered by subscriptions, to is null while open-ended",
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string",
+                    "x-nullable": true
+                }
+            }
+        },
+        "models.CreateSubscriptionRequest": {
+            "description": "Defines the request body for creating a new subscription.",
+            "type": "object",
+            "required": [
+                "price",
+                "service_name",
+                "start_date",
+                "user_id"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "end_date": {
+                    "type": "string"
+                },
+                "parent_id": {
+                    "type": "string",
+                    "minLength": 1
+                },
+                "price": {
+                    "type": "integer"
+                },
+                "service_name": {
+                    "type": "string",
+                    "maxLength": 15
+                },
+                "start_date": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ErrorResponse": {
+            "description": "Defines the generic error, error is localized from the Accept-Language header while code stays the same in every language",
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "details": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ExcludedSubscription": {
+            "description": "Defines a subscription left out of a summary, reported by the diagnostics mode",
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string",
+                    "enum": [
+                        "starts_after_period",
+                        "ended_before_period",
+                        "months_already_charged"
+                    ]
+                },
+                "service_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ExplainResponse": {
+            "description": "Defines the API response structure for the plan of the summary query.",
+            "type": "object",
+            "properties": {
+                "plan": {
+                    "type": "object"
+                }
+            }
+        },
+        "models.ExtendSubscriptionRequest": {
+            "description": "Defines the request payload to extend a subscription end_date by a number of monthly periods",
+            "type": "object",
+            "required": [
+                "periods"
+            ],
+            "properties": {
+                "periods": {
+                    "type": "integer",
+                    "maximum": 120,
+                    "minimum": 1
+                }
+            }
+        },
+        "models.HealthResponse": {
+            "description": "Defines the API response structure of the health probes.",
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.InFlightStatsResponse": {
+            "description": "Defines the API response structure for the admin in-flight request statistics.",
+            "type": "object",
+            "properties": {
+                "in_flight": {
+                    "type": "integer"
+                },
+                "max_in_flight": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ListSubscriptionsResponse": {
+            "description": "Defines the API response structure for a ListSubscriptionRequest.",
+            "type": "object",
+            "properties": {
+                "meta": {
+                    "$ref": "#/definitions/models.PaginationMeta"
+                },
+                "subscriptions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SubscriptionResponse"
+                    }
+                },
+                "totals": {
+                    "$ref": "#/definitions/models.ListTotals"
+                }
+            }
+        },
+        "models.ListTotals": {
+            "description": "Defines the totals footer of a subscription list, set when with_totals is requested. page_* cover the returned rows, count and price_sum every row matching the filters.",
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "page_count": {
+                    "type": "integer"
+                },
+                "page_price_sum": {
+                    "type": "integer"
+                },
+                "price_sum": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.NormalizeDatesResponse": {
+            "description": "Defines the API response structure for the date normalization backfill, normalized counts the rows rewritten.",
+            "type": "object",
+            "properties": {
+                "normalized": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.PaginationMeta": {
+            "description": "Defines pagination metadata for response for ListSubscriptionResponse",
+            "type": "object",
+            "properties": {
+                "filtered_out": {
+                    "type": "integer"
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "order": {
+                    "type": "string"
+                },
+                "sort_by": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "unfiltered_total": {
+                    "description": "only set when include_counts is requested\nзаполняются только при запросе include_counts",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.PeriodStats": {
+            "description": "Defines the spend of a user over one period",
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "months": {
+                    "type": "integer"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "total_cost": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.PriceStatsResponse": {
+            "description": "Defines the API response structure for the price statistics of a service across all users. average is rounded to 2 decimals, median is the interpolated 50th percentile.",
+            "type": "object",
+            "properties": {
+                "average": {
+                    "type": "number"
+                },
+                "max": {
+                    "type": "integer"
+                },
+                "median": {
+                    "type": "number"
+                },
+                "min": {
+                    "type": "integer"
+                },
+                "service_name": {
+                    "type": "string"
+                },
+                "subscription_count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.QuarterCost": {
+            "description": "Defines the cost of a user over one quarter, computed like a summary of its three months",
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "months": {
+                    "type": "integer"
+                },
+                "quarter": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "to": {
+                    "type": "string"
+                },
+                "total_cost": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.QuarterlyStatsResponse": {
+            "description": "Defines the API response structure for the quarterly costs of a user, always four quarters in order",
+            "type": "object",
+            "properties": {
+                "quarters": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.QuarterCost"
+                    }
+                },
+                "total_cost": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "year": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.RevenueByMonthResponse": {
+            "description": "Defines the API response structure for the revenue per month",
             "type": "object",
-            "required": [
-                "price",
-                "service_name",
-                "start_date",
-                "user_id"
-            ],
             "properties": {
-                "end_date": {
+                "from": {
                     "type": "string"
                 },
-                "price": {
-                    "type": "integer"
+                "period": {
+                    "$ref": "#/definitions/models.StatsPeriod"
                 },
-                "service_name": {
-                    "type": "string",
-                    "maxLength": 15
+                "points": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RevenuePoint"
+                    }
                 },
-                "start_date": {
+                "to": {
                     "type": "string"
                 },
+                "total_revenue": {
+                    "type": "integer"
+                },
                 "user_id": {
                     "type": "string"
                 }
             }
         },
-        "models.ErrorResponse": {
-            "description": "Defines the generic error",
+        "models.RevenuePoint": {
+            "description": "Defines the summed price of the subscriptions active in one month",
             "type": "object",
             "properties": {
-                "details": {
+                "month": {
                     "type": "string"
                 },
-                "error": {
-                    "type": "string"
+                "revenue": {
+                    "type": "integer"
                 }
             }
         },
-        "models.ListSubscriptionsResponse": {
-            "description": "Defines the API response structure for a ListSubscriptionRequest.",
+        "models.ServiceStatsResponse": {
+            "description": "Defines the API response structure for the per-service summaries of a user, by cost descending",
             "type": "object",
             "properties": {
-                "meta": {
-                    "$ref": "#/definitions/models.PaginationMeta"
+                "period": {
+                    "$ref": "#/definitions/models.StatsPeriod"
                 },
-                "subscriptions": {
+                "services": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.SubscriptionResponse"
+                        "$ref": "#/definitions/models.ServiceSummary"
                     }
+                },
+                "user_id": {
+                    "type": "string"
                 }
             }
         },
-        "models.PaginationMeta": {
-            "description": "Defines pagination metadata for response for ListSubscriptionResponse",
+        "models.ServiceSummary": {
+            "description": "Defines the summary of one service of a user",
             "type": "object",
             "properties": {
-                "limit": {
+                "months": {
                     "type": "integer"
                 },
-                "offset": {
+                "service_name": {
+                    "type": "string"
+                },
+                "subscription_count": {
                     "type": "integer"
                 },
-                "order": {
+                "total_cost": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.StatsCompareResponse": {
+            "description": "Defines the API response structure comparing two periods. delta is period B minus period A, delta_percent is null when period A cost nothing.",
+            "type": "object",
+            "properties": {
+                "delta": {
+                    "type": "integer"
+                },
+                "delta_percent": {
+                    "type": "number",
+                    "x-nullable": true
+                },
+                "period_a": {
+                    "$ref": "#/definitions/models.PeriodStats"
+                },
+                "period_b": {
+                    "$ref": "#/definitions/models.PeriodStats"
+                },
+                "user_id": {
                     "type": "string"
+                }
+            }
+        },
+        "models.StatsPeriod": {
+            "description": "Defines the period covered by stats. from is null when the period is unbounded, default names the DEFAULT_STATS_PERIOD applied when from or to was omitted.",
+            "type": "object",
+            "properties": {
+                "default": {
+                    "type": "string",
+                    "example": "last_12_months"
                 },
-                "sort_by": {
+                "from": {
+                    "type": "string",
+                    "x-nullable": true,
+                    "example": "11-2025"
+                },
+                "to": {
+                    "type": "string",
+                    "example": "10-2026"
+                }
+            }
+        },
+        "models.SubscriptionMembersResponse": {
+            "description": "Defines the API response structure for the members of a family/group plan.",
+            "type": "object",
+            "properties": {
+                "members": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SubscriptionResponse"
+                    }
+                },
+                "subscription": {
+                    "$ref": "#/definitions/models.SubscriptionResponse"
+                }
+            }
+        },
+        "models.SubscriptionPeriodsResponse": {
+            "description": "Defines the API response structure for the coverage periods of a user's service, in chronological order. Adjacent and overlapping subscriptions are merged, so consecutive periods are separated by a gap.",
+            "type": "object",
+            "properties": {
+                "periods": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CoveragePeriod"
+                    }
+                },
+                "service_name": {
                     "type": "string"
                 },
-                "total": {
+                "subscription_count": {
                     "type": "integer"
+                },
+                "user_id": {
+                    "type": "string"
                 }
             }
         },
         "models.SubscriptionResponse": {
-            "description": "Defines the API response structure for a subscription.",
+            "description": "Defines the API response structure for a subscription. end_date is always present and is null for open-ended subscriptions. warnings lists non-blocking issues found on create or update. created_at and updated_at are RFC3339 timestamps of the creation and the last modification, omitted for unsaved subscriptions.",
             "type": "object",
             "properties": {
-                "end_date": {
+                "cost": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-07-01T12:00:00Z"
+                },
+                "description": {
                     "type": "string"
                 },
+                "end_date": {
+                    "type": "string",
+                    "x-nullable": true,
+                    "example": "12-2025"
+                },
+                "parent_id": {
+                    "type": "string",
+                    "x-nullable": true
+                },
                 "price": {
                     "type": "integer"
                 },
                 "service_id": {
-                    "type": "integer"
+                    "type": "string",
+                    "example": "42"
                 },
                 "service_name": {
                     "type": "string"
@@ -455,8 +2369,103 @@ const docTemplate = `{
                 "start_date": {
                     "type": "string"
                 },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "active",
+                        "upcoming",
+                        "expired"
+                    ]
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2025-07-01T12:00:00Z"
+                },
                 "user_id": {
                     "type": "string"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.SubscriptionTimelineResponse": {
+            "description": "Defines the API response structure for the monthly cost timeline of a subscription",
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "points": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TimelinePoint"
+                    }
+                },
+                "to": {
+                    "type": "string"
+                },
+                "total_cost": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.TeamStatsRequest": {
+            "description": "Defines the request payload for the combined spend of a team of users",
+            "type": "object",
+            "required": [
+                "user_ids"
+            ],
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "user_ids": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.TeamStatsResponse": {
+            "description": "Defines the API response structure for the combined spend of a team, with a per-user breakdown",
+            "type": "object",
+            "properties": {
+                "period": {
+                    "$ref": "#/definitions/models.StatsPeriod"
+                },
+                "total_cost": {
+                    "type": "integer"
+                },
+                "users": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.UserCostSummary"
+                    }
+                }
+            }
+        },
+        "models.TimelinePoint": {
+            "description": "Defines the cost contribution of a subscription in one month, 0 when it isn't active",
+            "type": "object",
+            "properties": {
+                "cost": {
+                    "type": "integer"
+                },
+                "month": {
+                    "type": "string"
                 }
             }
         },
@@ -464,7 +2473,18 @@ const docTemplate = `{
             "description": "Defines the request body for updating a subscription.",
             "type": "object",
             "properties": {
+                "description": {
+                    "description": "omitted or null leaves it unchanged, \"\" clears it",
+                    "type": "string",
+                    "x-nullable": true
+                },
                 "end_date": {
+                    "description": "omitted or null leaves it unchanged, \"\" clears it",
+                    "type": "string",
+                    "x-nullable": true
+                },
+                "parent_id": {
+                    "description": "0 detaches the subscription from its parent",
                     "type": "string"
                 },
                 "price": {
@@ -479,10 +2499,87 @@ const docTemplate = `{
                 }
             }
         },
+        "models.UserCostSummary": {
+            "description": "Defines the spend of one user of a team",
+            "type": "object",
+            "properties": {
+                "subscription_count": {
+                    "type": "integer"
+                },
+                "total_cost": {
+                    "type": "integer"
+                },
+                "total_months": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.UserEraseResponse": {
+            "description": "Defines the API response structure for a user data erasure",
+            "type": "object",
+            "properties": {
+                "deleted": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.UserExportResponse": {
+            "description": "Defines the export of every record held about a user",
+            "type": "object",
+            "properties": {
+                "exported_at": {
+                    "type": "string"
+                },
+                "subscriptions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SubscriptionResponse"
+                    }
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.UserStatsResponse": {
+            "description": "Defines the API response structure for the admin user statistics.",
+            "type": "object",
+            "properties": {
+                "active_users": {
+                    "type": "integer"
+                },
+                "total_users": {
+                    "type": "integer"
+                }
+            }
+        },
         "models.UserSubscriptionSummaryResponse": {
             "description": "Defines the structure of the API response for the /summary endpoint.",
             "type": "object",
             "properties": {
+                "excluded": {
+                    "description": "only set when diagnostics is requested\nзаполняется только при запросе diagnostics",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ExcludedSubscription"
+                    }
+                },
+                "over_budget": {
+                    "description": "only set when a budget is requested\nзаполняются только при запросе бюджета",
+                    "type": "boolean"
+                },
+                "overage": {
+                    "type": "integer"
+                },
+                "period": {
+                    "$ref": "#/definitions/models.StatsPeriod"
+                },
                 "service_name": {
                     "type": "string"
                 },
@@ -499,6 +2596,48 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "models.ValidateBatchRequest": {
+            "description": "Defines the request body for validating many subscriptions without saving them.",
+            "type": "object",
+            "required": [
+                "subscriptions"
+            ],
+            "properties": {
+                "subscriptions": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/models.CreateSubscriptionRequest"
+                    }
+                }
+            }
+        },
+        "models.ValidateBatchResponse": {
+            "description": "Defines the API response structure for a batch validation request.",
+            "type": "object",
+            "properties": {
+                "invalid": {
+                    "type": "integer"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.BatchValidationResult"
+                    }
+                },
+                "valid": {
+                    "type": "integer"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "AdminKey": {
+            "type": "apiKey",
+            "name": "X-Admin-Key",
+            "in": "header"
         }
     }
 }`
@@ -12,9 +12,13 @@ import (
 	"github.com/cyb3rkh4l1d/subsapi/internal/config"
 	"github.com/cyb3rkh4l1d/subsapi/internal/database"
 	"github.com/cyb3rkh4l1d/subsapi/internal/handlers"
+	"github.com/cyb3rkh4l1d/subsapi/internal/logging"
+	"github.com/cyb3rkh4l1d/subsapi/internal/models"
+	"github.com/cyb3rkh4l1d/subsapi/internal/notification"
 	"github.com/cyb3rkh4l1d/subsapi/internal/repository"
 	"github.com/cyb3rkh4l1d/subsapi/internal/router"
 	"github.com/cyb3rkh4l1d/subsapi/internal/service"
+	"github.com/cyb3rkh4l1d/subsapi/internal/utils"
 	"github.com/cyb3rkh4l1d/subsapi/internal/validations"
 	"github.com/cyb3rkh4l1d/subsapi/migrations"
 	"github.com/sirupsen/logrus"
@@ -73,6 +77,34 @@ func NewApp(ctx context.Context) *App {
 	logger.SetLevel(logLevel)
 	appLogger.Infof("loglevel set to %+v", logLevel)
 
+	//mask the configured fields, such as user_id, in every log line
+	//маскирование настроенных полей, например user_id, в каждой строке журнала
+	if hook := logging.NewRedactionHook(conf.LogRedactFields); hook != nil {
+		logger.AddHook(hook)
+		appLogger.Infof("log fields redacted: %+v", conf.LogRedactFields)
+	}
+
+	//configure the layout of every date emitted by the api, default to MM-YYYY
+	//настройка формата всех дат, возвращаемых API, по умолчанию MM-YYYY
+	if err := utils.SetDateOutputLayout(conf.DateOutputFormat); err != nil {
+		appLogger.WithError(err).Warnf("invalid DATE_OUTPUT_FORMAT: %+v, falling back to %+v", conf.DateOutputFormat, utils.MonthYearLayout)
+	}
+	appLogger.Infof("date output format set to %+v", utils.DateOutputLayout())
+
+	//configure the timezone used to determine the current month, default to UTC
+	//настройка часового пояса для определения текущего месяца, по умолчанию UTC
+	if err := utils.SetTimezone(conf.Timezone); err != nil {
+		appLogger.WithError(err).Warnf("invalid TIMEZONE: %+v, falling back to UTC", conf.Timezone)
+	}
+	appLogger.Infof("timezone set to %+v", utils.Now().Location())
+
+	//expose subscriptions by their UUID public_id instead of their integer id, applied by migration 00009
+	//открывать подписки по их UUID public_id вместо целочисленного id, добавленного миграцией 00009
+	models.SetUUIDSubscriptionIDs(conf.UUIDSubscriptionIDs)
+	if conf.UUIDSubscriptionIDs {
+		appLogger.Info("subscriptions are exposed by UUID")
+	}
+
 	//DATABASE: Initialize and connect to postgres database
 	dbConfig := conf.DbConfig
 
@@ -80,25 +112,56 @@ func NewApp(ctx context.Context) *App {
 
 	//MIGRATION: Run datbase migrations
 	//MIGRATION: Выполнение миграций базы данных
-	migrations.PostgreSQLMigrateSubscriptions(dbLogger)
+	migrations.PostgreSQLMigrateSubscriptions(dbLogger, conf.SkipMigrations)
+
+	//KEEPALIVE: Probe the database in the background when enabled
+	//KEEPALIVE: Фоновая проверка базы данных, если включена
+	var keepAlive *database.KeepAlive
+	if conf.DbKeepAlive && driver.Sql_DB != nil {
+		keepAlive = database.NewKeepAlive(driver.Sql_DB, time.Duration(conf.DbKeepAliveInterval)*time.Second, dbLogger)
+		keepAlive.Start(ctx)
+	}
 
 	//REPOSITORY: Initialize repository with its logger.
 	//REPOSITORY: Инициализируйте репозиторий с его логгером.
-	subRepo := repository.NewSubscriptionRepository(driver.Gorm_DB, repoLogger)
+	subRepo := repository.NewSubscriptionRepository(driver.Gorm_DB, repoLogger, conf.MaxResultRows, conf.DbMaxRetries)
 
 	//SERVICE: Initialize service with its logger.
 	//SERVICE: Инициализируйте службу с её регистратором.
-	subService := service.NewSubscriptionService(subRepo, serviceLogger)
+	subService := service.NewSubscriptionService(subRepo, conf, serviceLogger)
+
+	//SEED: Insert sample subscriptions into an empty development database when enabled
+	//SEED: Добавление примеров подписок в пустую базу данных разработки, если включено
+	if driver.Gorm_DB != nil {
+		if _, err := subService.SeedSampleData(ctx); err != nil {
+			appLogger.WithError(err).Warn("seeding sample subscriptions failed")
+		}
+	}
+
+	//REMINDERS: Send expiry reminders in the background when a webhook is configured
+	//REMINDERS: Фоновая отправка напоминаний об окончании, если настроен вебхук
+	if conf.ReminderWebhookURL != "" && driver.Gorm_DB != nil {
+		reminders := notification.NewReminders(subRepo, notification.NewWebhookNotifier(conf.ReminderWebhookURL),
+			conf.ReminderLeadMonths, time.Duration(conf.ReminderInterval)*time.Minute, serviceLogger.WithField("job", "reminders"))
+		reminders.Start(ctx)
+	}
+
+	//SUMMARY CACHE: Precompute the summary months in the background when enabled
+	//SUMMARY CACHE: Фоновое предварительное вычисление месяцев сводки, если включено
+	if conf.SummaryCacheEnabled && driver.Gorm_DB != nil {
+		subService.StartSummaryCache(ctx, time.Duration(conf.SummaryCacheInterval)*time.Minute)
+	}
 
 	//HANDLER: Initialize handlers with its logger
 	//HANDLER: Инициализируйте обработчики с помощью соответствующего логгера.
 	subHandler := handlers.NewSubscriptionHandlers(ctx, handlerLogger, subService)
+	healthHandler := handlers.NewHealthHandler(handlerLogger, driver.Sql_DB, keepAlive, conf.CheckMigrations)
 
 	//ROUTER: Initialize router with its logger
 	//МАРШРУТИЗАТОР: Инициализация маршрутизатора с его логгером
-	routerInstance := router.NewApiRouter(ctx, conf, routerLogger, subHandler)
+	routerInstance := router.NewApiRouter(ctx, conf, routerLogger, subHandler, healthHandler)
 	//register routes. //регистрация маршрутов
-	routerInstance.RegisterRoutes(router.SubscriptionRoutes, router.SwaggerRoute)
+	routerInstance.RegisterRoutes(router.HealthRoutes, router.SubscriptionRoutes, router.UserRoutes, router.AdminRoutes, router.SwaggerRoute)
 
 	server := &http.Server{Addr: conf.Host, Handler: routerInstance.GinEngine}
 	app := &App{
@@ -125,7 +188,7 @@ func (a *App) Run() error {
 	// Start HTTP server in background goroutine
 	// Запуск HTTP-сервера в фоновом режиме (горутина)
 	go func() {
-		a.Logger.Infof("starting server at :%+v", a.Server.Addr)
+		a.Logger.Infof("starting server at %+v", a.Server.Addr)
 		if err := a.Server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			a.serverErrChan <- err
 		}
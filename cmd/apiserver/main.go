@@ -13,6 +13,9 @@ import (
 // @description REST API for managing user subscriptions
 // @host localhost:8080
 // @BasePath /api/v1
+// @securityDefinitions.apikey AdminKey
+// @in header
+// @name X-Admin-Key
 
 func main() {
 	defer database.ClosePgDriverConnection()
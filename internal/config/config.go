@@ -2,21 +2,76 @@ package config
 
 import (
 	"context"
+	"fmt"
+	"net"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/cyb3rkh4l1d/subsapi/internal/database"
+	"github.com/cyb3rkh4l1d/subsapi/internal/utils"
 	"github.com/cyb3rkh4l1d/subsapi/internal/validations"
 	"github.com/joho/godotenv"
 	"github.com/sirupsen/logrus"
 )
 
+// defaultListenAddr is the server address used when Host is missing or malformed.
+// defaultListenAddr — адрес сервера, используемый, если Host не задан или задан неверно.
+const defaultListenAddr = ":8080"
+
 // Define configuration for the applications
 // Определение конфигурации для приложений
 type Config struct {
-	Host     string
-	LogLevel string
-	GinMode  string
-	DbConfig *database.Config
+	AppEnv               string
+	Host                 string
+	LogLevel             string
+	LogRedactFields      string
+	GinMode              string
+	AdminAPIKey          string
+	DateOutputFormat     string
+	Timezone             string
+	CheckMigrations      bool
+	SkipMigrations       bool
+	SeedData             bool
+	EnableSwagger        bool
+	EnableExplain        bool
+	DefaultStatsPeriod   string
+	DefaultStatsMonths   int
+	StrictDateOrder      bool
+	StrictQueryParams    bool
+	MaxResultRows        int
+	MaxOffset            int
+	MaxPrice             int
+	DbMaxRetries         int
+	DbKeepAlive          bool
+	DbKeepAliveInterval  int
+	ReminderWebhookURL   string
+	TenancyEnabled       bool
+	TenantAPIKeys        string
+	ReminderLeadMonths   int
+	ReminderInterval     int
+	SummaryCacheEnabled  bool
+	SummaryCacheInterval int
+	SummaryCacheTTL      int
+	UUIDSubscriptionIDs  bool
+	Middleware           MiddlewareConfig
+	DbConfig             *database.Config
+}
+
+// MiddlewareConfig controls which global middlewares are enabled and their parameters.
+// The router assembles them in order: recovery, request ID, access log, query length limit,
+// in-flight limit and HTTPS enforcement. Recovery, request IDs and the in-flight gauge are always on.
+// MiddlewareConfig управляет тем, какие глобальные промежуточные обработчики включены, и их параметрами.
+// Маршрутизатор собирает их по порядку: восстановление, ID запроса, журнал доступа, ограничение длины запроса,
+// ограничение одновременных запросов и принудительный HTTPS. Восстановление, ID запросов и счетчик запросов всегда включены.
+type MiddlewareConfig struct {
+	AccessLog      bool
+	MaxQueryLength int
+	MaxInFlight    int
+	HSTSEnabled    bool
+	HSTSMaxAge     int
+	HTTPSRedirect  bool
+	TrustedProxies string
 }
 
 /*.....................................................................
@@ -28,11 +83,105 @@ type Config struct {
 // Функция, загружающая конфигурации приложения из файла .env
 func LoadConfig(ctx context.Context, logger *logrus.Entry) *Config {
 	err := godotenv.Load()
+	appEnv := getEnv("APP_ENV", "dev")
+	statsPeriod, statsMonths := getStatsPeriod(logger)
 	cfg := &Config{
 
-		Host:     getEnv("Host", ":8080"),
+		AppEnv:   appEnv,
+		Host:     listenAddr(logger, getEnv("Host", defaultListenAddr)),
 		LogLevel: getEnv("LOG_LEVEL", "info"),
-		GinMode:  getEnv("GIN_MODE", "debug"),
+		// comma separated log fields masked in every log line, none by default
+		// поля журнала через запятую, маскируемые в каждой строке журнала, по умолчанию нет
+		LogRedactFields: getEnv("LOG_REDACT_FIELDS", ""),
+		GinMode:         getEnv("GIN_MODE", "debug"),
+		// admin routes stay disabled until a key is configured
+		// маршруты администратора отключены, пока ключ не настроен
+		AdminAPIKey: getEnv("ADMIN_API_KEY", ""),
+		// Go time layout used for every date in API responses
+		// Формат времени Go, используемый для всех дат в ответах API
+		DateOutputFormat: getEnv("DATE_OUTPUT_FORMAT", utils.MonthYearLayout),
+		// timezone used to decide which month is the current one
+		// часовой пояс, используемый для определения текущего месяца
+		Timezone: getEnv("TIMEZONE", "UTC"),
+		// disable when migrations are applied out-of-band
+		// отключите, если миграции применяются отдельно
+		CheckMigrations: getEnvBool(logger, "READINESS_CHECK_MIGRATIONS", true),
+		// skip goose on startup when migrations are applied externally
+		// пропустить goose при запуске, если миграции применяются извне
+		SkipMigrations: getEnvBool(logger, "SKIP_MIGRATIONS", false),
+		// insert sample subscriptions into an empty table on startup, only honoured with APP_ENV=dev
+		// добавлять примеры подписок в пустую таблицу при запуске, учитывается только с APP_ENV=dev
+		SeedData: getEnvBool(logger, "SEED_DATA", false),
+		// swagger ui is exposed by default everywhere but in production
+		// swagger ui доступен по умолчанию везде, кроме production
+		EnableSwagger: getEnvBool(logger, "ENABLE_SWAGGER", !IsProduction(appEnv)),
+		// admin endpoint returning query plans, never exposed by default
+		// эндпоинт администратора, возвращающий планы запросов, по умолчанию недоступен
+		EnableExplain: getEnvBool(logger, "ENABLE_EXPLAIN", false),
+		// period covered by the stats endpoints when "from" is omitted, all_time keeps it unbounded
+		// период, охватываемый эндпоинтами статистики, если "from" не указан, all_time — без ограничения
+		DefaultStatsPeriod: statsPeriod,
+		DefaultStatsMonths: statsMonths,
+		// reject date ranges with "from" later than "to" instead of swapping them
+		// отклонять диапазоны дат, где "from" позже "to", вместо перестановки границ
+		StrictDateOrder: getEnvBool(logger, "STRICT_DATE_ORDER", true),
+		// reject query parameters an endpoint doesn't know instead of ignoring them, off by default
+		// отклонять неизвестные эндпоинту параметры запроса вместо их игнорирования, по умолчанию выключено
+		StrictQueryParams: getEnvBool(logger, "STRICT_QUERY_PARAMS", false),
+		// most rows an unpaginated query may load, 0 disables the cap
+		// максимальное количество строк для запросов без пагинации, 0 отключает ограничение
+		MaxResultRows: getEnvInt(logger, "MAX_RESULT_ROWS", 10000, 0),
+		// deepest offset accepted by paginated lists, 0 disables the limit
+		// максимальное смещение для списков с пагинацией, 0 отключает ограничение
+		MaxOffset: getEnvInt(logger, "MAX_OFFSET", 10000, 0),
+		// highest monthly price accepted on create and update, 0 disables the cap
+		// максимальная месячная цена, принимаемая при создании и обновлении, 0 отключает ограничение
+		MaxPrice: getEnvInt(logger, "MAX_PRICE", 1000000, 0),
+		// retries of transactions failing with serialization failures or deadlocks
+		// повторы транзакций, завершившихся ошибкой сериализации или взаимоблокировкой
+		DbMaxRetries: getEnvInt(logger, "DB_MAX_RETRIES", 3, 0),
+		// background SELECT 1 probe feeding the readiness check, interval in seconds
+		// фоновая проба SELECT 1 для проверки готовности, интервал в секундах
+		DbKeepAlive:         getEnvBool(logger, "DB_KEEPALIVE_ENABLED", false),
+		DbKeepAliveInterval: getEnvInt(logger, "DB_KEEPALIVE_INTERVAL_SECONDS", 15, 1),
+		// expiry reminders are posted to the webhook, disabled while it is empty
+		// напоминания об окончании отправляются на вебхук, отключены, пока он пуст
+		ReminderWebhookURL: getEnv("REMINDER_WEBHOOK_URL", ""),
+		ReminderLeadMonths: getEnvInt(logger, "REMINDER_LEAD_MONTHS", 1, 0),
+		ReminderInterval:   getEnvInt(logger, "REMINDER_INTERVAL_MINUTES", 60, 1),
+		// scope subscription and user endpoints to the tenant of each request
+		// ограничить эндпоинты подписок и пользователей арендатором каждого запроса
+		TenancyEnabled: getEnvBool(logger, "TENANCY_ENABLED", false),
+		// comma separated key:tenant pairs, the X-Tenant-ID header is used when empty
+		// пары ключ:арендатор через запятую, при пустом значении используется заголовок X-Tenant-ID
+		TenantAPIKeys: getEnv("TENANT_API_KEYS", ""),
+		// background precomputed summary months serving the summary and stats endpoints, intervals in minutes
+		// фоновые предварительно вычисленные месяцы сводки для эндпоинтов сводки и статистики, интервалы в минутах
+		SummaryCacheEnabled:  getEnvBool(logger, "SUMMARY_CACHE_ENABLED", false),
+		SummaryCacheInterval: getEnvInt(logger, "SUMMARY_CACHE_INTERVAL_MINUTES", 5, 1),
+		SummaryCacheTTL:      getEnvInt(logger, "SUMMARY_CACHE_TTL_MINUTES", 60, 1),
+		// expose and look up subscriptions by their UUID public_id instead of their integer id, off by default
+		// открывать и искать подписки по их UUID public_id вместо целочисленного id, по умолчанию выключено
+		UUIDSubscriptionIDs: getEnvBool(logger, "UUID_SUBSCRIPTION_IDS", false),
+		Middleware: MiddlewareConfig{
+			// one line per request on stdout, on by default
+			// одна строка на запрос в stdout, по умолчанию включено
+			AccessLog: getEnvBool(logger, "ACCESS_LOG_ENABLED", true),
+			// longest raw query string accepted, 0 disables the limit
+			// максимальная длина строки запроса, 0 отключает ограничение
+			MaxQueryLength: getEnvInt(logger, "MAX_QUERY_LENGTH", 2048, 0),
+			// requests processed at once before new ones are rejected with 503, 0 disables the limit
+			// количество одновременно обрабатываемых запросов, сверх которого новые отклоняются с 503, 0 отключает ограничение
+			MaxInFlight: getEnvInt(logger, "MAX_INFLIGHT", 100, 0),
+			// HTTPS enforcement behind a TLS terminating proxy, off by default
+			// принудительный HTTPS за прокси, завершающим TLS, по умолчанию выключен
+			HSTSEnabled:   getEnvBool(logger, "HSTS_ENABLED", false),
+			HSTSMaxAge:    getEnvInt(logger, "HSTS_MAX_AGE_SECONDS", 31536000, 0),
+			HTTPSRedirect: getEnvBool(logger, "HTTPS_REDIRECT", false),
+			// comma separated IPs/CIDRs whose X-Forwarded-* headers are trusted
+			// IP/CIDR через запятую, чьим заголовкам X-Forwarded-* можно доверять
+			TrustedProxies: getEnv("TRUSTED_PROXIES", ""),
+		},
 		DbConfig: &database.Config{
 			Host:     getEnv("DB_HOST", "localhost"),
 			Port:     getEnv("DB_PORT", "5432"),
@@ -40,6 +189,11 @@ func LoadConfig(ctx context.Context, logger *logrus.Entry) *Config {
 			Password: getEnv("DB_PASSWORD", "postgress"),
 			DBName:   getEnv("DB_NAME", "subscriptions_db"),
 			SSLMode:  getEnv("DB_SSLMODE", "disable"),
+			// certificate paths for verify-ca / verify-full and client certificate authentication
+			// пути к сертификатам для verify-ca / verify-full и аутентификации по клиентскому сертификату
+			SSLRootCert: getEnv("DB_SSLROOTCERT", ""),
+			SSLCert:     getEnv("DB_SSLCERT", ""),
+			SSLKey:      getEnv("DB_SSLKEY", ""),
 		},
 	}
 
@@ -52,6 +206,36 @@ func LoadConfig(ctx context.Context, logger *logrus.Entry) *Config {
 	return cfg
 }
 
+// IsProduction reports whether the APP_ENV value designates a production environment.
+// IsProduction сообщает, обозначает ли значение APP_ENV производственную среду.
+func IsProduction(appEnv string) bool {
+	return appEnv == "prod" || appEnv == "production"
+}
+
+// function that validates the server listen address: a bare port such as "8080" is corrected to ":8080",
+// any other malformed value falls back to the default address
+// Функция, проверяющая адрес сервера: порт без двоеточия, например "8080", исправляется на ":8080",
+// любое другое неверное значение заменяется адресом по умолчанию
+func listenAddr(logger *logrus.Entry, addr string) string {
+	if _, port, err := net.SplitHostPort(addr); err == nil && validPort(port) {
+		logger.Infof("listen address set to %+v", addr)
+		return addr
+	}
+	if validPort(addr) {
+		logger.Warnf("%+v: Host=%+v, missing colon, corrected to :%+v", validations.ErrInvalidConfigValue, addr, addr)
+		return ":" + addr
+	}
+	logger.Warnf("%+v: Host=%+v, falling back to %+v", validations.ErrInvalidConfigValue, addr, defaultListenAddr)
+	return defaultListenAddr
+}
+
+// function that reports whether port is a numeric TCP port, 0 lets the system pick one
+// Функция, сообщающая, является ли port числовым TCP-портом, 0 позволяет системе выбрать порт
+func validPort(port string) bool {
+	p, err := strconv.Atoi(port)
+	return err == nil && p >= 0 && p <= 65535
+}
+
 // function that gets enviroment variables
 // Функция, которая получает переменные окружения
 func getEnv(key, fallback string) string {
@@ -60,3 +244,67 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+// function that gets boolean enviroment variables, falling back on malformed values
+// Функция, которая получает логические переменные окружения, используя значение по умолчанию при ошибке
+func getEnvBool(logger *logrus.Entry, key string, fallback bool) bool {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		logger.Warnf("%+v: %+v=%+v, falling back to %+v", validations.ErrInvalidConfigValue, key, v, fallback)
+		return fallback
+	}
+	return b
+}
+
+// StatsPeriodAllTime is the DEFAULT_STATS_PERIOD leaving the stats unbounded when "from" is omitted.
+// StatsPeriodAllTime — значение DEFAULT_STATS_PERIOD, оставляющее статистику без ограничения, если "from" не указан.
+const StatsPeriodAllTime = "all_time"
+
+// function that gets the default stats period, "all_time" or "last_<N>_months", with its length in months, 0 for all_time.
+// The deprecated SUMMARY_DEFAULT_LOOKBACK_MONTHS is still honoured when DEFAULT_STATS_PERIOD is unset.
+// Функция, которая получает период статистики по умолчанию, "all_time" или "last_<N>_months", и его длину в месяцах, 0 для all_time.
+// Устаревшая SUMMARY_DEFAULT_LOOKBACK_MONTHS по-прежнему учитывается, если DEFAULT_STATS_PERIOD не задана.
+func getStatsPeriod(logger *logrus.Entry) (string, int) {
+	fallback := "last_12_months"
+	if months := getEnvInt(logger, "SUMMARY_DEFAULT_LOOKBACK_MONTHS", 0, 0); months > 0 {
+		fallback = fmt.Sprintf("last_%d_months", months)
+	}
+	period := getEnv("DEFAULT_STATS_PERIOD", fallback)
+	if months, ok := parseStatsPeriod(period); ok {
+		return period, months
+	}
+	logger.Warnf("%+v: DEFAULT_STATS_PERIOD=%+v, falling back to %+v", validations.ErrInvalidConfigValue, period, fallback)
+	months, _ := parseStatsPeriod(fallback)
+	return fallback, months
+}
+
+// function that parses a stats period into its length in months, reporting whether it is well-formed
+// Функция, которая разбирает период статистики в его длину в месяцах и сообщает, корректен ли он
+func parseStatsPeriod(period string) (int, bool) {
+	if period == StatsPeriodAllTime {
+		return 0, true
+	}
+	months, hasPrefix := strings.CutPrefix(period, "last_")
+	months, hasSuffix := strings.CutSuffix(months, "_months")
+	n, err := strconv.Atoi(months)
+	return n, hasPrefix && hasSuffix && err == nil && n > 0
+}
+
+// function that gets integer enviroment variables not lower than min, falling back on malformed values
+// Функция, которая получает целочисленные переменные окружения не меньше min, используя значение по умолчанию при ошибке
+func getEnvInt(logger *logrus.Entry, key string, fallback, min int) int {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil || i < min {
+		logger.Warnf("%+v: %+v=%+v, falling back to %+v", validations.ErrInvalidConfigValue, key, v, fallback)
+		return fallback
+	}
+	return i
+}
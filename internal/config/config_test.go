@@ -0,0 +1,64 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestListenAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want string
+	}{
+		{":8080", ":8080"},
+		{"0.0.0.0:9000", "0.0.0.0:9000"},
+		{"[::1]:443", "[::1]:443"},
+		{":0", ":0"},
+		{"8080", ":8080"},
+		{"localhost", defaultListenAddr},
+		{":http", defaultListenAddr},
+		{":70000", defaultListenAddr},
+		{"-1", defaultListenAddr},
+		{"", defaultListenAddr},
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			logger, _ := logtest.NewNullLogger()
+			if got := listenAddr(logrus.NewEntry(logger), tt.addr); got != tt.want {
+				t.Errorf("listenAddr(%q) = %q, want %q", tt.addr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetStatsPeriod(t *testing.T) {
+	tests := []struct {
+		name       string
+		period     string
+		lookback   string
+		wantPeriod string
+		wantMonths int
+	}{
+		{"default", "", "", "last_12_months", 12},
+		{"last months", "last_6_months", "", "last_6_months", 6},
+		{"all time", StatsPeriodAllTime, "", StatsPeriodAllTime, 0},
+		{"malformed", "last_x_months", "", "last_12_months", 12},
+		{"zero months", "last_0_months", "", "last_12_months", 12},
+		{"deprecated lookback", "", "3", "last_3_months", 3},
+		{"period wins over lookback", "all_time", "3", StatsPeriodAllTime, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DEFAULT_STATS_PERIOD", tt.period)
+			t.Setenv("SUMMARY_DEFAULT_LOOKBACK_MONTHS", tt.lookback)
+			logger, _ := logtest.NewNullLogger()
+
+			period, months := getStatsPeriod(logrus.NewEntry(logger))
+			if period != tt.wantPeriod || months != tt.wantMonths {
+				t.Errorf("getStatsPeriod = %q, %d, want %q, %d", period, months, tt.wantPeriod, tt.wantMonths)
+			}
+		})
+	}
+}
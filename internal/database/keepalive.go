@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/validations"
+	"github.com/sirupsen/logrus"
+)
+
+// keepAliveTimeout bounds every keepalive probe.
+// keepAliveTimeout ограничивает время каждой keepalive-пробы.
+const keepAliveTimeout = 2 * time.Second
+
+// KeepAlive periodically probes the database with SELECT 1, keeping pooled connections warm
+// and maintaining a health state that readiness checks can read without pinging per request.
+// KeepAlive периодически проверяет базу данных запросом SELECT 1, поддерживая соединения пула активными
+// и сохраняя состояние работоспособности, которое пробы готовности читают без пинга на каждый запрос.
+type KeepAlive struct {
+	db       *sql.DB
+	interval time.Duration
+	logger   *logrus.Entry
+
+	mu      sync.RWMutex
+	lastErr error
+	checked bool
+}
+
+// NewKeepAlive creates a KeepAlive probing db every interval.
+// NewKeepAlive создает KeepAlive, проверяющий db каждые interval.
+func NewKeepAlive(db *sql.DB, interval time.Duration, logger *logrus.Entry) *KeepAlive {
+	return &KeepAlive{db: db, interval: interval, logger: logger}
+}
+
+// Start runs a first probe synchronously, then keeps probing in the background until ctx is done.
+// Start выполняет первую пробу синхронно, затем продолжает проверки в фоне до завершения ctx.
+func (k *KeepAlive) Start(ctx context.Context) {
+	k.probe(ctx)
+	go func() {
+		ticker := time.NewTicker(k.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				k.probe(ctx)
+			}
+		}
+	}()
+}
+
+// Healthy returns the error of the latest probe, nil when the database answered.
+// Healthy возвращает ошибку последней пробы, nil, если база данных ответила.
+func (k *KeepAlive) Healthy() error {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if !k.checked {
+		return validations.ErrDbPingFailed
+	}
+	return k.lastErr
+}
+
+// probe runs SELECT 1 and logs transitions between healthy and unhealthy.
+// probe выполняет SELECT 1 и логирует переходы между работоспособным и неработоспособным состоянием.
+func (k *KeepAlive) probe(ctx context.Context) {
+	probeCtx, cancel := context.WithTimeout(ctx, keepAliveTimeout)
+	defer cancel()
+	_, err := k.db.ExecContext(probeCtx, "SELECT 1")
+
+	k.mu.Lock()
+	wasHealthy := k.checked && k.lastErr == nil
+	first := !k.checked
+	k.lastErr = err
+	k.checked = true
+	k.mu.Unlock()
+
+	switch {
+	case err != nil && (wasHealthy || first):
+		k.logger.WithError(err).Warn("database keepalive: database became unhealthy")
+	case err == nil && !wasHealthy:
+		k.logger.Info("database keepalive: database is healthy")
+	}
+}
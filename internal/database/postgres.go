@@ -4,6 +4,8 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -22,6 +24,11 @@ type Config struct {
 	Password string
 	DBName   string
 	SSLMode  string
+	// certificate paths for TLS connections, verify-ca and verify-full require SSLRootCert
+	// пути к сертификатам для TLS-соединений, verify-ca и verify-full требуют SSLRootCert
+	SSLRootCert string
+	SSLCert     string
+	SSLKey      string
 }
 
 // Ensures only one instance of PgDriver exists throughout the application lifecycle.
@@ -50,15 +57,10 @@ type PgDriver struct {
 // Функция NewPostgresConnection создает и возвращает новое соединение GORM с PostgreSQL, используя шаблон проектирования Singleton.
 // Он формирует DSN на основе предоставленной конфигурации и проверяет соединение.
 func NewPostgresConnection(config *Config, dbLogger *logrus.Entry) *PgDriver {
-	dsn := fmt.Sprintf(
-		"host=%s user=%s password=%s dbname=%s port=%s sslmode=%s",
-		config.Host,
-		config.User,
-		config.Password,
-		config.DBName,
-		config.Port,
-		config.SSLMode,
-	)
+	if err := config.ValidateSSL(); err != nil {
+		dbLogger.WithError(err).Fatal(validations.ErrDbConnectionFailed)
+	}
+	dsn := config.DSN()
 	once.Do(func() {
 		db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
 		if err != nil {
@@ -85,6 +87,53 @@ func NewPostgresConnection(config *Config, dbLogger *logrus.Entry) *PgDriver {
 	return PgDriverInstance
 }
 
+// DSN builds the PostgreSQL connection string, adding the certificate paths that are configured.
+// DSN формирует строку подключения к PostgreSQL, добавляя заданные пути к сертификатам.
+func (c *Config) DSN() string {
+	dsn := fmt.Sprintf(
+		"host=%s user=%s password=%s dbname=%s port=%s sslmode=%s",
+		c.Host,
+		c.User,
+		c.Password,
+		c.DBName,
+		c.Port,
+		c.SSLMode,
+	)
+	if c.SSLRootCert != "" {
+		dsn += " sslrootcert=" + c.SSLRootCert
+	}
+	if c.SSLCert != "" {
+		dsn += " sslcert=" + c.SSLCert
+	}
+	if c.SSLKey != "" {
+		dsn += " sslkey=" + c.SSLKey
+	}
+	return dsn
+}
+
+// ValidateSSL checks that the certificate files exist: the root certificate is required by the verify-ca and
+// verify-full modes, and the client certificate and key must be set together.
+// ValidateSSL проверяет существование файлов сертификатов: корневой сертификат обязателен для режимов verify-ca
+// и verify-full, а клиентские сертификат и ключ должны задаваться вместе.
+func (c *Config) ValidateSSL() error {
+	mode := strings.ToLower(c.SSLMode)
+	if (mode == "verify-ca" || mode == "verify-full") && c.SSLRootCert == "" {
+		return fmt.Errorf("%w: sslmode=%s requires DB_SSLROOTCERT", validations.ErrDbSSLConfigInvalid, c.SSLMode)
+	}
+	if (c.SSLCert == "") != (c.SSLKey == "") {
+		return fmt.Errorf("%w: DB_SSLCERT and DB_SSLKEY must be set together", validations.ErrDbSSLConfigInvalid)
+	}
+	for _, path := range []string{c.SSLRootCert, c.SSLCert, c.SSLKey} {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("%w: %w", validations.ErrDbSSLConfigInvalid, err)
+		}
+	}
+	return nil
+}
+
 // ClosePgDriverConnection safely closes the singleton PostgreSQL database connection pool.
 // Функция ClosePgDriverConnection безопасно закрывает пул соединений с единственной базой данных PostgreSQL.
 func ClosePgDriverConnection() {
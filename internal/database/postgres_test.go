@@ -0,0 +1,58 @@
+package database
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/validations"
+)
+
+func TestDSNCarriesCertificatePaths(t *testing.T) {
+	config := &Config{Host: "db", Port: "5432", User: "app", Password: "secret", DBName: "subs", SSLMode: "verify-full",
+		SSLRootCert: "/certs/root.crt", SSLCert: "/certs/client.crt", SSLKey: "/certs/client.key"}
+	dsn := config.DSN()
+	for _, param := range []string{"sslmode=verify-full", "sslrootcert=/certs/root.crt", "sslcert=/certs/client.crt", "sslkey=/certs/client.key"} {
+		if !strings.Contains(dsn, param) {
+			t.Errorf("DSN %q lacks %s", dsn, param)
+		}
+	}
+
+	config = &Config{Host: "db", Port: "5432", User: "app", DBName: "subs", SSLMode: "disable"}
+	if dsn := config.DSN(); strings.Contains(dsn, "sslrootcert") || strings.Contains(dsn, "sslcert") || strings.Contains(dsn, "sslkey") {
+		t.Errorf("DSN %q carries certificate paths that are not configured", dsn)
+	}
+}
+
+func TestValidateSSL(t *testing.T) {
+	dir := t.TempDir()
+	cert := filepath.Join(dir, "client.crt")
+	if err := os.WriteFile(cert, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "missing.key")
+
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{"no tls", Config{SSLMode: "disable"}, false},
+		{"require without certificates", Config{SSLMode: "require"}, false},
+		{"verify-full without root certificate", Config{SSLMode: "verify-full"}, true},
+		{"verify-ca with root certificate", Config{SSLMode: "VERIFY-CA", SSLRootCert: cert}, false},
+		{"client certificate without key", Config{SSLMode: "require", SSLCert: cert}, true},
+		{"client certificate and key", Config{SSLMode: "require", SSLCert: cert, SSLKey: cert}, false},
+		{"missing file", Config{SSLMode: "require", SSLCert: cert, SSLKey: missing}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.ValidateSSL()
+			if (err != nil) != tt.wantErr || (err != nil && !errors.Is(err, validations.ErrDbSSLConfigInvalid)) {
+				t.Errorf("ValidateSSL() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
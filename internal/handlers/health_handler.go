@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/database"
+	"github.com/cyb3rkh4l1d/subsapi/internal/models"
+	"github.com/cyb3rkh4l1d/subsapi/internal/validations"
+	"github.com/cyb3rkh4l1d/subsapi/migrations"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// healthCheckTimeout bounds every dependency check of the readiness probe.
+// healthCheckTimeout ограничивает время каждой проверки зависимостей пробы готовности.
+const healthCheckTimeout = 2 * time.Second
+
+// HealthHandler serves the liveness and readiness probes.
+// HealthHandler обслуживает пробы живости и готовности.
+type HealthHandler struct {
+	Logger          *logrus.Entry
+	db              *sql.DB
+	keepAlive       *database.KeepAlive
+	checkMigrations bool
+}
+
+/*.....................................................................
+
+					Functions/Methods Definations
+
+........................................................................*/
+
+// NewHealthHandler creates a HealthHandler checking the given database and, optionally, its migrations.
+// When keepAlive is set, the database check reads its state instead of pinging on every request.
+// NewHealthHandler создает HealthHandler, проверяющий указанную базу данных и, при необходимости, ее миграции.
+// Если задан keepAlive, проверка базы данных читает его состояние вместо пинга на каждый запрос.
+func NewHealthHandler(logger *logrus.Entry, db *sql.DB, keepAlive *database.KeepAlive, checkMigrations bool) *HealthHandler {
+	return &HealthHandler{Logger: logger, db: db, keepAlive: keepAlive, checkMigrations: checkMigrations}
+}
+
+// Healthz reports that the process is alive.
+// Healthz godoc
+// @Summary Liveness probe
+// @Description Report that the service process is alive
+// @Tags Health
+// @Produce json
+// @Success 200 {object} models.HealthResponse
+// @Router /healthz [get]
+func (h *HealthHandler) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, models.HealthResponse{Status: models.HealthStatusOK})
+}
+
+// Readyz reports whether the service can handle traffic: the database answers a ping
+// and, when enabled, no goose migrations are pending.
+// Readyz godoc
+// @Summary Readiness probe
+// @Description Check the database connection and, when enabled, that no migrations are pending
+// @Tags Health
+// @Produce json
+// @Success 200 {object} models.HealthResponse
+// @Failure 503 {object} models.HealthResponse "Service Unavailable - A dependency check failed"
+// @Router /readyz [get]
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), healthCheckTimeout)
+	defer cancel()
+
+	checks := map[string]string{"database": models.HealthStatusOK}
+	ready := true
+
+	// Check the database connection
+	// Проверка соединения с базой данных
+	if h.db == nil {
+		h.Logger.Warn("readiness: database is not initialized")
+		checks["database"] = validations.ErrDbInitializationFailed.Error()
+		ready = false
+	} else if err := h.pingDatabase(ctx); err != nil {
+		h.Logger.WithError(err).Warn("readiness: database ping failed")
+		checks["database"] = err.Error()
+		ready = false
+	}
+
+	// Check that the schema is not behind the binary
+	// Проверка, что схема не отстает от бинарного файла
+	if h.checkMigrations && ready {
+		checks["migrations"] = models.HealthStatusOK
+		pending, err := migrations.PendingMigrations(ctx, h.db)
+		switch {
+		case err != nil:
+			h.Logger.WithError(err).Warn("readiness: migration status check failed")
+			checks["migrations"] = err.Error()
+			ready = false
+		case pending > 0:
+			h.Logger.Warnf("readiness: %+v migrations pending", pending)
+			checks["migrations"] = fmt.Sprintf("%d pending", pending)
+			ready = false
+		}
+	}
+
+	if !ready {
+		c.JSON(http.StatusServiceUnavailable, models.HealthResponse{Status: models.HealthStatusUnavailable, Checks: checks})
+		return
+	}
+	c.JSON(http.StatusOK, models.HealthResponse{Status: models.HealthStatusOK, Checks: checks})
+}
+
+// pingDatabase reports the keepalive state when available, otherwise pings the database.
+// pingDatabase возвращает состояние keepalive, если оно доступно, иначе пингует базу данных.
+func (h *HealthHandler) pingDatabase(ctx context.Context) error {
+	if h.keepAlive != nil {
+		return h.keepAlive.Healthy()
+	}
+	return h.db.PingContext(ctx)
+}
@@ -1,57 +1,230 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"time"
 
+	"github.com/cyb3rkh4l1d/subsapi/internal/i18n"
+	"github.com/cyb3rkh4l1d/subsapi/internal/middleware"
 	"github.com/cyb3rkh4l1d/subsapi/internal/models"
+	"github.com/cyb3rkh4l1d/subsapi/internal/service"
 	"github.com/cyb3rkh4l1d/subsapi/internal/utils"
 	"github.com/cyb3rkh4l1d/subsapi/internal/validations"
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/sirupsen/logrus"
 )
 
 // ToResponse converts a Subscription model to a SubscriptionResponse DTO
 // formatting the StartDate and EndDate in "MM-YYYY" format.
+// Open-ended subscriptions always carry a null end_date so clients can detect them.
 // ToResponse преобразует модель Subscription в DTO SubscriptionResponse
 // форматирование StartDate и EndDate в формате "MM-YYYY".
+// Бессрочные подписки всегда содержат end_date равный null, чтобы клиенты могли их распознать.
 func FormatToSubscriptionResponse(sub *models.Subscription) models.SubscriptionResponse {
-	var end string
+	var end *string
 	if sub.EndDate != nil && !sub.EndDate.IsZero() {
-		end = utils.FormatMonthYear(*sub.EndDate)
+		formatted := utils.FormatMonthYear(*sub.EndDate)
+		end = &formatted
 	}
 	// return response object with formatted dates
 	// Возвращает объект ответа с отформатированными датами
 	return models.SubscriptionResponse{
-		ID:          sub.ID,
+		ID:          sub.ExposedID(),
 		ServiceName: sub.ServiceName,
 		Price:       sub.Price,
 		UserID:      sub.UserID,
 		StartDate:   utils.FormatMonthYear(sub.StartDate),
 		EndDate:     end,
+		Status:      service.SubscriptionStatus(sub, utils.Now()),
+		ParentID:    sub.ExposedParentID(),
+		Description: sub.Description,
+		CreatedAt:   formatTimestamp(sub.CreatedAt),
+		UpdatedAt:   formatTimestamp(sub.UpdatedAt),
 	}
 }
 
+// formatTimestamp formats a record timestamp as RFC3339 in UTC, nil when it is zero as for unsaved subscriptions.
+// formatTimestamp форматирует метку времени записи как RFC3339 в UTC, nil для нулевой, как у несохраненных подписок.
+func formatTimestamp(t time.Time) *string {
+	if t.IsZero() {
+		return nil
+	}
+	formatted := t.UTC().Format(time.RFC3339)
+	return &formatted
+}
+
+// formatSubscriptions converts subscriptions to their API response format, keeping their order.
+// formatSubscriptions преобразует подписки в формат ответа API, сохраняя их порядок.
+func formatSubscriptions(subs []models.Subscription) []models.SubscriptionResponse {
+	formatted := make([]models.SubscriptionResponse, len(subs))
+	for i := range subs {
+		formatted[i] = FormatToSubscriptionResponse(&subs[i])
+	}
+	return formatted
+}
+
+// validationMessages splits a binding error into one message per failing field.
+// validationMessages разбивает ошибку привязки на отдельные сообщения для каждого неверного поля.
+func validationMessages(err error) []string {
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return []string{err.Error()}
+	}
+	messages := make([]string, len(fieldErrs))
+	for i, fieldErr := range fieldErrs {
+		messages[i] = fieldErr.Error()
+	}
+	return messages
+}
+
+// requestLogger returns the handler logger enriched with the request_id and, when known, the tenant_id and user_id of the request.
+// requestLogger возвращает логгер обработчика, дополненный request_id и, если известны, tenant_id и user_id запроса.
+func (h *SubscriptionHandler) requestLogger(c *gin.Context) *logrus.Entry {
+	logger := h.Logger.WithField(middleware.RequestIDKey, c.GetString(middleware.RequestIDKey))
+	if tenantID := c.GetString(middleware.TenantKey); tenantID != "" {
+		logger = logger.WithField(middleware.TenantKey, tenantID)
+	}
+	if userID := c.GetString(middleware.UserIDKey); userID != "" {
+		logger = logger.WithField(middleware.UserIDKey, userID)
+	}
+	return logger
+}
+
+// newPaginatedSubscriptions answers a list requested by page with the items, counts and totals of res.
+// Its limit and offset are the ones page and page_size were translated to, so the offset is a multiple of the limit.
+// newPaginatedSubscriptions формирует ответ списка, запрошенного по странице, с элементами, счетчиками и итогами res.
+// Его limit и offset — те, в которые были преобразованы page и page_size, поэтому offset кратен limit.
+func newPaginatedSubscriptions(res *models.ListSubscriptionsResponse) *models.PaginatedSubscriptions {
+	meta := res.Meta
+	return &models.PaginatedSubscriptions{
+		Items: res.Subscriptions,
+		PageNumberMeta: models.PageNumberMeta{
+			Total:           meta.Total,
+			Page:            meta.Offset/meta.Limit + 1,
+			PageSize:        meta.Limit,
+			TotalPages:      int((meta.Total + int64(meta.Limit) - 1) / int64(meta.Limit)),
+			UnfilteredTotal: meta.UnfilteredTotal,
+			FilteredOut:     meta.FilteredOut,
+		},
+		Totals: res.Totals,
+	}
+}
+
+// RespondError writes the error envelope shared by every endpoint, localized for the request, with optional details.
+// RespondError записывает общий для всех эндпоинтов конверт ошибки, локализованный для запроса, с необязательными подробностями.
+func RespondError(c *gin.Context, status int, err error, details ...string) {
+	c.JSON(status, i18n.ErrorResponse(c.GetHeader(i18n.AcceptLanguageHeader), err, details...))
+}
+
+// RespondPaginated writes a page of subscriptions in its list envelope as plain JSON by default,
+// or as a JSON:API collection document carrying the same items and metadata.
+// RespondPaginated записывает страницу подписок в ее конверте списка: по умолчанию как обычный JSON
+// или как документ-коллекцию JSON:API с теми же элементами и метаданными.
+func RespondPaginated(c *gin.Context, res models.PaginatedResponse) {
+	if !wantsJSONAPI(c) {
+		c.JSON(http.StatusOK, res)
+		return
+	}
+	items := res.PageItems()
+	resources := make([]models.JSONAPIResource, len(items))
+	for i, sub := range items {
+		resources[i] = ToJSONAPIResource(sub)
+	}
+	c.Header("Content-Type", JSONAPIMediaType)
+	c.JSON(http.StatusOK, models.JSONAPIDocument{Data: resources, Meta: res.PageMeta()})
+}
+
+// ParsePagination reads the limit and offset query parameters of the offset paginated lists:
+// limit defaults to 10 and is between 1 and 100, offset defaults to 0 and is not negative.
+// ParsePagination читает параметры запроса limit и offset списков с пагинацией по смещению:
+// limit по умолчанию 10 и находится между 1 и 100, offset по умолчанию 0 и неотрицателен.
+func ParsePagination(c *gin.Context) (models.Pagination, error) {
+	var page models.Pagination
+	err := c.ShouldBindQuery(&page)
+	return page, err
+}
+
+// ParseDateRange reads the optional from and to query parameters of the date range endpoints and checks
+// their MM-YYYY format. Their order and defaults are left to the service, which knows the endpoint.
+// ParseDateRange читает необязательные параметры запроса from и to эндпоинтов с диапазоном дат и проверяет
+// их формат MM-YYYY. Порядок и значения по умолчанию остаются сервису, который знает эндпоинт.
+func ParseDateRange(c *gin.Context) (models.DateRange, error) {
+	period := models.DateRange{From: c.Query("from"), To: c.Query("to")}
+	for _, bound := range []struct{ name, value string }{{"from", period.From}, {"to", period.To}} {
+		if bound.value == "" {
+			continue
+		}
+		if _, err := utils.ParseMonthYear(bound.value); err != nil {
+			return models.DateRange{}, fmt.Errorf("%s: %w", bound.name, validations.ErrInvalidDateFormat)
+		}
+	}
+	return period, nil
+}
+
+// respondInvalidInput answers a request failing its binding rules with 400 and the binding error as details.
+// An empty or whitespace-only JSON body is reported as such rather than as the decoder's EOF.
+// respondInvalidInput отвечает на запрос, не прошедший правила привязки, статусом 400 с ошибкой привязки в подробностях.
+// Пустое тело JSON или тело только из пробелов сообщается как таковое, а не как EOF декодера.
+func (h *SubscriptionHandler) respondInvalidInput(c *gin.Context, err error) {
+	if errors.Is(err, io.EOF) {
+		h.requestLogger(c).Info(validations.ErrEmptyBody)
+		RespondError(c, http.StatusBadRequest, validations.ErrEmptyBody)
+		return
+	}
+	h.requestLogger(c).WithError(err).Info(validations.ErrInvalidRequestInput)
+	RespondError(c, http.StatusBadRequest, validations.ErrInvalidRequestInput, err.Error())
+}
+
 // handleServiceError maps service layer errors to appropriate HTTP responses
 // Функция handleServiceError сопоставляет ошибки уровня сервиса с соответствующими HTTP-ответами.
 func (h *SubscriptionHandler) handleServiceError(c *gin.Context, err error) {
+	logger := h.requestLogger(c)
 	switch err {
 	case validations.ErrInvalidServiceName,
 		validations.ErrEmptyUserID,
 		validations.ErrInvalidPrice,
+		validations.ErrDescriptionTooLong,
 		validations.ErrInvalidDateFormat,
 		validations.ErrInvalidStartDate,
 		validations.ErrInvalidEndDate,
+		validations.ErrInvalidYear,
 		validations.ErrEndDateBeforeStart,
 		validations.ErrInvalidSubscriptionID,
-		validations.ErrInvalidUserID:
-		h.service.Logger.Info(err)
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
-	case validations.ErrSubscriptionNotFound:
-		h.service.Logger.Info(err)
-		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: err.Error()})
+		validations.ErrInvalidUserID,
+		validations.ErrInvalidUserIDPrefix,
+		validations.ErrParentNotFound,
+		validations.ErrParentIsSelf,
+		validations.ErrParentCycle,
+		validations.ErrOpenEndedExtension,
+		validations.ErrTimelineTooLong,
+		validations.ErrPeriodReversed,
+		validations.ErrInvalidCursor:
+		logger.WithError(err).Info("request validation failed")
+		RespondError(c, http.StatusBadRequest, err)
+	case validations.ErrPriceTooHigh:
+		logger.WithError(err).Info("request validation failed")
+		RespondError(c, http.StatusBadRequest, err, fmt.Sprintf("maximum price is %d", h.service.MaxPrice()))
+	case validations.ErrOffsetTooLarge:
+		logger.WithError(err).Info("request validation failed")
+		RespondError(c, http.StatusBadRequest, err, fmt.Sprintf("maximum offset is %d", h.service.MaxOffset()))
+	case validations.ErrSubscriptionNotFound, validations.ErrServiceNotFound:
+		logger.WithError(err).Info("requested resource not found")
+		RespondError(c, http.StatusNotFound, err)
+	case validations.ErrResultTooLarge:
+		logger.WithError(err).Warn("request result is too large")
+		RespondError(c, http.StatusUnprocessableEntity, err)
 	case validations.ErrSubscriptionExists:
-		h.service.Logger.Warn(err)
-		c.JSON(http.StatusConflict, models.ErrorResponse{Error: err.Error()})
+		logger.WithError(err).Warn("request conflicts with existing resource")
+		RespondError(c, http.StatusConflict, err)
+	case validations.ErrDbInitializationFailed:
+		logger.WithError(err).Error("database is not initialized")
+		RespondError(c, http.StatusServiceUnavailable, validations.ErrServiceUnavailable)
 	default:
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Internal server error"})
+		logger.WithError(err).Error("request failed")
+		RespondError(c, http.StatusInternalServerError, validations.ErrInternalServer)
 	}
 }
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/config"
+	"github.com/cyb3rkh4l1d/subsapi/internal/i18n"
+	"github.com/cyb3rkh4l1d/subsapi/internal/middleware"
+	"github.com/cyb3rkh4l1d/subsapi/internal/models"
+	"github.com/cyb3rkh4l1d/subsapi/internal/service"
+	"github.com/cyb3rkh4l1d/subsapi/internal/validations"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+)
+
+// newTestHandler builds a handler without a repository, for requests answered before the service reaches it,
+// and returns the hook capturing its log entries.
+// newTestHandler создает обработчик без репозитория для запросов, на которые отвечают до обращения сервиса к нему,
+// и возвращает хук, перехватывающий его записи журнала.
+func newTestHandler() (*SubscriptionHandler, *logtest.Hook) {
+	gin.SetMode(gin.TestMode)
+	logger, hook := logtest.NewNullLogger()
+	entry := logrus.NewEntry(logger)
+	svc := service.NewSubscriptionService(nil, &config.Config{}, entry)
+	return NewSubscriptionHandlers(context.Background(), entry, svc), hook
+}
+
+func TestRequestLoggerCarriesRequestID(t *testing.T) {
+	h, hook := newTestHandler()
+	router := gin.New()
+	router.Use(middleware.RequestID())
+	router.POST("/subscriptions", h.CreateSubscription)
+
+	req := httptest.NewRequest(http.MethodPost, "/subscriptions", strings.NewReader(`{}`))
+	req.Header.Set(middleware.RequestIDHeader, "req-123")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	entry := hook.LastEntry()
+	if entry == nil {
+		t.Fatal("no log entry written")
+	}
+	if got := entry.Data[middleware.RequestIDKey]; got != "req-123" {
+		t.Errorf("request_id = %v, want req-123", got)
+	}
+}
+
+func TestRequestLoggerAddsTenantAndUser(t *testing.T) {
+	h, hook := newTestHandler()
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Set(middleware.RequestIDKey, "req-1")
+	c.Set(middleware.TenantKey, "acme")
+	c.Set(middleware.UserIDKey, "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11")
+
+	h.requestLogger(c).Info("message")
+
+	want := map[string]string{
+		middleware.RequestIDKey: "req-1",
+		middleware.TenantKey:    "acme",
+		middleware.UserIDKey:    "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11",
+	}
+	for key, value := range want {
+		if got := hook.LastEntry().Data[key]; got != value {
+			t.Errorf("%s = %v, want %s", key, got, value)
+		}
+	}
+}
+
+func TestRequestLoggerOmitsUnknownFields(t *testing.T) {
+	h, hook := newTestHandler()
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Set(middleware.RequestIDKey, "req-2")
+
+	h.requestLogger(c).Info("message")
+
+	data := hook.LastEntry().Data
+	for _, key := range []string{middleware.TenantKey, middleware.UserIDKey} {
+		if _, ok := data[key]; ok {
+			t.Errorf("%s present without being set", key)
+		}
+	}
+}
+
+// newQueryContext builds a test context for a GET request with the given query string.
+// newQueryContext создает тестовый контекст GET-запроса с заданной строкой запроса.
+func newQueryContext(query string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/subscriptions?"+query, nil)
+	return c, w
+}
+
+func TestRespondError(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		details        []string
+		want           models.ErrorResponse
+	}{
+		{"without details", "", nil, models.ErrorResponse{Code: "invalid_price", Error: validations.ErrInvalidPrice.Error()}},
+		{"with details", "", []string{"price: 0", "maximum price is 100"}, models.ErrorResponse{Code: "invalid_price", Error: validations.ErrInvalidPrice.Error(), Details: "price: 0; maximum price is 100"}},
+		{"localized", "ru-RU", nil, models.ErrorResponse{Code: "invalid_price", Error: i18n.Message("ru", "invalid_price", "")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := newQueryContext("")
+			c.Request.Header.Set(i18n.AcceptLanguageHeader, tt.acceptLanguage)
+			RespondError(c, http.StatusBadRequest, validations.ErrInvalidPrice, tt.details...)
+
+			var res models.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+				t.Fatal(err)
+			}
+			if w.Code != http.StatusBadRequest || res != tt.want || res.Error == "" {
+				t.Errorf("got %d %+v, want 400 %+v", w.Code, res, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePagination(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    models.Pagination
+		wantErr bool
+	}{
+		{"", models.Pagination{Limit: 10, Offset: 0}, false},
+		{"limit=1&offset=20", models.Pagination{Limit: 1, Offset: 20}, false},
+		{"limit=100", models.Pagination{Limit: 100, Offset: 0}, false},
+		{"limit=101", models.Pagination{}, true},
+		{"offset=-1", models.Pagination{}, true},
+		{"limit=ten", models.Pagination{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			c, _ := newQueryContext(tt.query)
+			got, err := ParsePagination(c)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want an error: %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("pagination = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDateRange(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    models.DateRange
+		wantErr string
+	}{
+		{"", models.DateRange{}, ""},
+		{"from=01-2025", models.DateRange{From: "01-2025"}, ""},
+		{"from=01-2025&to=12-2025", models.DateRange{From: "01-2025", To: "12-2025"}, ""},
+		{"from=2025-01&to=12-2025", models.DateRange{}, "from"},
+		{"from=01-2025&to=13-2025", models.DateRange{}, "to"},
+		{"from=bad&to=bad", models.DateRange{}, "from"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			c, _ := newQueryContext(tt.query)
+			got, err := ParseDateRange(c)
+			if tt.wantErr == "" {
+				if err != nil || got != tt.want {
+					t.Errorf("ParseDateRange = %+v, %v, want %+v", got, err, tt.want)
+				}
+				return
+			}
+			if !errors.Is(err, validations.ErrInvalidDateFormat) || !strings.HasPrefix(err.Error(), tt.wantErr+":") {
+				t.Errorf("err = %v, want ErrInvalidDateFormat naming %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFormatTimestamps(t *testing.T) {
+	// an unsaved subscription, as answered by validate-batch, has no timestamps to report
+	// у несохраненной подписки, как в ответе validate-batch, нет меток времени
+	unsaved := &models.Subscription{ServiceName: "Netflix", Price: 999, StartDate: time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)}
+	body, err := json.Marshal(FormatToSubscriptionResponse(unsaved))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(body, &fields); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"created_at", "updated_at"} {
+		if _, ok := fields[key]; ok {
+			t.Errorf("unsaved subscription has %s: %s", key, body)
+		}
+	}
+
+	moscow := time.FixedZone("MSK", 3*60*60)
+	saved := *unsaved
+	saved.CreatedAt = time.Date(2025, time.July, 1, 15, 0, 0, 0, moscow)
+	saved.UpdatedAt = time.Date(2025, time.July, 2, 15, 0, 0, 0, moscow)
+	res := FormatToSubscriptionResponse(&saved)
+	if res.CreatedAt == nil || *res.CreatedAt != "2025-07-01T12:00:00Z" {
+		t.Errorf("created_at = %v, want 2025-07-01T12:00:00Z", res.CreatedAt)
+	}
+	if res.UpdatedAt == nil || *res.UpdatedAt != "2025-07-02T12:00:00Z" {
+		t.Errorf("updated_at = %v, want 2025-07-02T12:00:00Z", res.UpdatedAt)
+	}
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/middleware"
+	"github.com/cyb3rkh4l1d/subsapi/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// InFlightHandler reports the in-flight gauge of the request limiter, so operators can
+// watch how close the service runs to MAX_INFLIGHT.
+// InFlightHandler godoc
+// @Summary Get in-flight request statistics
+// @Description Number of requests currently being processed and the configured maximum, 0 when unlimited (admin only)
+// @Tags Admin
+// @Produce json
+// @Security AdminKey
+// @Success 200 {object} models.InFlightStatsResponse
+// @Failure 401 {object} models.ErrorResponse "Unauthorized - Missing or invalid admin key"
+// @Failure 403 {object} models.ErrorResponse "Forbidden - Admin api is disabled"
+// @Router /admin/stats/inflight [get]
+func InFlightHandler(limiter *middleware.InFlightLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, models.InFlightStatsResponse{InFlight: limiter.InFlight(), MaxInFlight: limiter.Limit()})
+	}
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// JSONAPIMediaType is the media type that switches responses to the JSON:API representation.
+	// JSONAPIMediaType — медиатип, переключающий ответы в представление JSON:API.
+	JSONAPIMediaType = "application/vnd.api+json"
+	// subscriptionResourceType is the JSON:API resource type of subscriptions.
+	// subscriptionResourceType — тип ресурса JSON:API для подписок.
+	subscriptionResourceType = "subscriptions"
+)
+
+// wantsJSONAPI reports whether the client asked for the JSON:API representation via the Accept header.
+// wantsJSONAPI сообщает, запросил ли клиент представление JSON:API через заголовок Accept.
+func wantsJSONAPI(c *gin.Context) bool {
+	return strings.Contains(c.GetHeader("Accept"), JSONAPIMediaType)
+}
+
+// ToJSONAPIResource wraps a subscription response into a JSON:API resource object.
+// ToJSONAPIResource оборачивает ответ подписки в объект ресурса JSON:API.
+func ToJSONAPIResource(sub models.SubscriptionResponse) models.JSONAPIResource {
+	return models.JSONAPIResource{
+		Type:       subscriptionResourceType,
+		ID:         string(sub.ID),
+		Attributes: sub,
+	}
+}
+
+// respondSubscription writes a single subscription, as plain JSON by default or as a JSON:API document.
+// respondSubscription записывает одну подписку: по умолчанию как обычный JSON или как документ JSON:API.
+func respondSubscription(c *gin.Context, status int, sub models.SubscriptionResponse) {
+	if !wantsJSONAPI(c) {
+		c.JSON(status, sub)
+		return
+	}
+	c.Header("Content-Type", JSONAPIMediaType)
+	c.JSON(status, models.JSONAPIDocument{Data: ToJSONAPIResource(sub)})
+}
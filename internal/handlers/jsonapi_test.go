@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// newJSONAPIContext builds a test context for a request with the given Accept header.
+// newJSONAPIContext создает тестовый контекст запроса с заданным заголовком Accept.
+func newJSONAPIContext(accept string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/subscriptions", nil)
+	if accept != "" {
+		c.Request.Header.Set("Accept", accept)
+	}
+	return c, w
+}
+
+func TestRespondSubscriptionRepresentations(t *testing.T) {
+	sub := models.SubscriptionResponse{ID: "7", ServiceName: "Netflix", Price: 400, UserID: "60601fee-2bf1-4721-ae6f-7636e79a0cba", StartDate: "07-2025"}
+
+	t.Run("plain json", func(t *testing.T) {
+		c, w := newJSONAPIContext("application/json")
+		respondSubscription(c, http.StatusOK, sub)
+
+		if got := w.Header().Get("Content-Type"); got != "application/json; charset=utf-8" {
+			t.Errorf("Content-Type = %q", got)
+		}
+		var res models.SubscriptionResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Fatal(err)
+		}
+		if res.ID != "7" || res.ServiceName != "Netflix" {
+			t.Errorf("body = %+v, want the bare subscription", res)
+		}
+		// integer IDs stay JSON numbers
+		// целочисленные ID остаются JSON-числами
+		if !strings.Contains(w.Body.String(), `"service_id":7,`) {
+			t.Errorf("body = %s, want service_id as a number", w.Body)
+		}
+	})
+
+	t.Run("json:api", func(t *testing.T) {
+		c, w := newJSONAPIContext(JSONAPIMediaType)
+		respondSubscription(c, http.StatusCreated, sub)
+
+		if w.Code != http.StatusCreated {
+			t.Errorf("status = %d, want %d", w.Code, http.StatusCreated)
+		}
+		if got := w.Header().Get("Content-Type"); got != JSONAPIMediaType {
+			t.Errorf("Content-Type = %q, want %q", got, JSONAPIMediaType)
+		}
+		var doc struct {
+			Data models.JSONAPIResource `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+			t.Fatal(err)
+		}
+		if doc.Data.Type != "subscriptions" || doc.Data.ID != "7" {
+			t.Errorf("resource = %s/%s, want subscriptions/7", doc.Data.Type, doc.Data.ID)
+		}
+		if doc.Data.Attributes.ServiceName != "Netflix" || doc.Data.Attributes.Price != 400 {
+			t.Errorf("attributes = %+v", doc.Data.Attributes)
+		}
+	})
+}
+
+func TestRespondPaginatedRepresentations(t *testing.T) {
+	res := &models.ListSubscriptionsResponse{
+		Subscriptions: []models.SubscriptionResponse{{ID: "1", ServiceName: "Netflix"}, {ID: "2", ServiceName: "Spotify"}},
+		Meta:          &models.PaginationMeta{Limit: 10, Offset: 0, SortBy: "id", Order: "asc", Total: 2},
+	}
+
+	t.Run("plain json", func(t *testing.T) {
+		c, w := newJSONAPIContext("")
+		RespondPaginated(c, res)
+
+		var body models.ListSubscriptionsResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if len(body.Subscriptions) != 2 || body.Meta == nil || body.Meta.Total != 2 {
+			t.Errorf("body = %s, want the subscriptions with their meta", w.Body.String())
+		}
+	})
+
+	t.Run("json:api", func(t *testing.T) {
+		c, w := newJSONAPIContext("application/json, " + JSONAPIMediaType)
+		RespondPaginated(c, res)
+
+		if got := w.Header().Get("Content-Type"); got != JSONAPIMediaType {
+			t.Errorf("Content-Type = %q, want %q", got, JSONAPIMediaType)
+		}
+		var doc struct {
+			Data []models.JSONAPIResource `json:"data"`
+			Meta models.PaginationMeta    `json:"meta"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+			t.Fatal(err)
+		}
+		if len(doc.Data) != 2 || doc.Data[0].ID != "1" || doc.Data[1].ID != "2" || doc.Data[1].Type != "subscriptions" {
+			t.Errorf("data = %+v, want subscriptions 1 and 2 in order", doc.Data)
+		}
+		if doc.Meta.Total != 2 || doc.Meta.Limit != 10 {
+			t.Errorf("meta = %+v, want the pagination meta", doc.Meta)
+		}
+	})
+	t.Run("json:api with totals", func(t *testing.T) {
+		withTotals := *res
+		withTotals.Totals = &models.ListTotals{PageCount: 2, PagePriceSum: 300, Count: 2, PriceSum: 300}
+		c, w := newJSONAPIContext(JSONAPIMediaType)
+		RespondPaginated(c, &withTotals)
+
+		var doc struct {
+			Meta struct {
+				models.PaginationMeta
+				Totals *models.ListTotals `json:"totals"`
+			} `json:"meta"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+			t.Fatal(err)
+		}
+		if doc.Meta.Total != 2 || doc.Meta.Totals == nil || *doc.Meta.Totals != *withTotals.Totals {
+			t.Errorf("meta = %s, want the pagination meta joined by the totals", w.Body)
+		}
+	})
+}
+
+func TestRespondPaginatedCursorPage(t *testing.T) {
+	res := &models.CursorSubscriptionsResponse{
+		Items:      []models.SubscriptionResponse{{ID: "1", ServiceName: "Netflix"}},
+		NextCursor: "MQ",
+	}
+
+	t.Run("plain json", func(t *testing.T) {
+		c, w := newJSONAPIContext("")
+		RespondPaginated(c, res)
+
+		var body models.CursorSubscriptionsResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if len(body.Items) != 1 || body.NextCursor != "MQ" {
+			t.Errorf("body = %s, want the items with their next_cursor", w.Body)
+		}
+	})
+
+	t.Run("json:api", func(t *testing.T) {
+		c, w := newJSONAPIContext(JSONAPIMediaType)
+		RespondPaginated(c, res)
+
+		var doc struct {
+			Data []models.JSONAPIResource `json:"data"`
+			Meta struct {
+				NextCursor string `json:"next_cursor"`
+			} `json:"meta"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+			t.Fatal(err)
+		}
+		if len(doc.Data) != 1 || doc.Data[0].ID != "1" || doc.Meta.NextCursor != "MQ" {
+			t.Errorf("document = %s, want the item with next_cursor in meta", w.Body)
+		}
+	})
+}
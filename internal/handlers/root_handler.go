@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/i18n"
+	"github.com/cyb3rkh4l1d/subsapi/internal/models"
+	"github.com/cyb3rkh4l1d/subsapi/internal/validations"
+	"github.com/gin-gonic/gin"
+)
+
+// rootCacheControl lets clients and proxies cache the static root document.
+// rootCacheControl позволяет клиентам и прокси кешировать статический корневой документ.
+const rootCacheControl = "public, max-age=3600"
+
+// RootHandler describes the service at "/" and links to its docs and probes,
+// so operators poking the service get something useful instead of a 404.
+// The document is built once as it never changes while the process runs.
+// RootHandler описывает сервис по пути "/" и дает ссылки на документацию и пробы,
+// чтобы операторы получали полезный ответ вместо 404.
+// Документ создается один раз, так как не меняется во время работы процесса.
+func RootHandler(name, version string, swaggerEnabled bool) gin.HandlerFunc {
+	res := models.RootResponse{
+		Service: name,
+		Version: version,
+		Links:   map[string]string{"healthz": "/api/v1/healthz", "readyz": "/api/v1/readyz"},
+	}
+	if swaggerEnabled {
+		res.Links["swagger"] = "/api/v1/swagger/index.html"
+	}
+
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", rootCacheControl)
+		c.JSON(http.StatusOK, res)
+	}
+}
+
+// NotFoundHandler answers requests matching no route with the JSON error envelope instead of gin's plain text 404.
+// NotFoundHandler отвечает на запросы, не соответствующие ни одному маршруту, JSON-конвертом ошибки вместо текстового 404 gin.
+func NotFoundHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusNotFound, i18n.ErrorResponse(c.GetHeader(i18n.AcceptLanguageHeader), validations.ErrRouteNotFound))
+	}
+}
@@ -2,12 +2,20 @@ package handlers
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/cyb3rkh4l1d/subsapi/internal/middleware"
 	"github.com/cyb3rkh4l1d/subsapi/internal/models"
 	"github.com/cyb3rkh4l1d/subsapi/internal/service"
 	"github.com/cyb3rkh4l1d/subsapi/internal/validations"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/sirupsen/logrus"
 )
 
@@ -35,76 +43,252 @@ func NewSubscriptionHandlers(ctx context.Context, handlerLogger *logrus.Entry, s
 	return &SubscriptionHandler{ctx: ctx, Logger: handlerLogger, service: serice}
 }
 
+// ResolveSubscriptionID returns the primary key of the subscription exposed under id, for the :id middleware.
+// ResolveSubscriptionID возвращает первичный ключ подписки, доступной под id, для промежуточного обработчика :id.
+func (h *SubscriptionHandler) ResolveSubscriptionID(ctx context.Context, id models.SubscriptionID) (uint, error) {
+	return h.service.ResolveSubscriptionID(ctx, id)
+}
+
 // @tag.name Subscriptions
 // @tag.description Subscription management endpoints
 
 // CreateSubscription handles HTTP POST requests to create a new subscription.
 // It validates input, parses dates, persists data, and returns the created record.
+// With upsert=true the subscription of the same user and service starting the same month is replaced instead.
 // CreateSubscription godoc
 // @Summary Create a new subscription
-// @Description Create a subscription for a user
+// @Description Create a subscription for a user. With upsert=true, the subscription of the same user and service (in any case) starting the same month is replaced with the payload and returned with 200 and its Content-Location; otherwise one is created and returned with 201 and its Location.
 // @Tags Subscriptions
 // @Accept json
-// @Produce json
+// @Produce json,application/vnd.api+json
 // @Param subscription body models.CreateSubscriptionRequest true "Subscription payload"
+// @Param upsert query bool false "Replace the subscription of the same user and service starting the same month"
+// @Success 200 {object} models.SubscriptionResponse "Existing subscription replaced (upsert)"
 // @Success 201 {object} models.SubscriptionResponse
+// @Header 200 {string} Content-Location "URL of the replaced subscription"
+// @Header 201 {string} Location "URL of the created subscription"
 // @Failure 400 {object} models.ErrorResponse "Bad Request"
 // @Failure 500 {object} models.ErrorResponse "Internal Server Error"
 // @Router /subscriptions [post]
 func (h *SubscriptionHandler) CreateSubscription(c *gin.Context) {
 
 	var req *models.CreateSubscriptionRequest
+	var query models.CreateSubscriptionQuery
 
 	// Bind and validate request payload
 	//Привяжите и проверьте полезную нагрузку запроса.
+	if err := c.ShouldBindQuery(&query); err != nil {
+		h.respondInvalidInput(c, err)
+		return
+	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.Logger.WithError(err).Info(validations.ErrInvalidRequestInput)
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: validations.ErrInvalidRequestInput.Error(), Details: err.Error()})
+		h.respondInvalidInput(c, err)
 		return
 	}
 
-	h.Logger.Infof("creating subscription: ServiceName: %+v, UserID: %+v, Price: %+v,StartDate: %+v, EndDate: %+v", req.ServiceName, req.UserID, req.Price, req.StartDate, req.EndDate)
+	c.Set(middleware.UserIDKey, req.UserID)
+	h.requestLogger(c).Infof("creating subscription: ServiceName: %+v, UserID: %+v, Price: %+v,StartDate: %+v, EndDate: %+v, Upsert: %+v", req.ServiceName, req.UserID, req.Price, req.StartDate, req.EndDate, query.Upsert)
 
 	//Process business logic for create subscription request
 	//Обработка бизнес-логики для создания запроса на подписку
-	sub, err := h.service.CreateSubscription(c.Request.Context(), req)
+	var sub *models.Subscription
+	var warnings []string
+	var err error
+	created := true
+	if query.Upsert {
+		sub, created, warnings, err = h.service.UpsertSubscription(c.Request.Context(), req)
+	} else {
+		sub, warnings, err = h.service.CreateSubscription(c.Request.Context(), req)
+	}
+	if err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	res := FormatToSubscriptionResponse(sub)
+	res.Warnings = warnings
+	location := strings.TrimSuffix(c.Request.URL.Path, "/") + "/" + string(sub.ExposedID())
+	if !created {
+		c.Header("Content-Location", location)
+		respondSubscription(c, http.StatusOK, res)
+		return
+	}
+	c.Header("Location", location)
+	respondSubscription(c, http.StatusCreated, res)
+}
+
+// ValidateSubscriptionsBatch validates and normalizes many create payloads without saving them.
+// Each item goes through the same binding and service validation as CreateSubscription.
+// ValidateSubscriptionsBatch godoc
+// @Summary Validate subscriptions without saving
+// @Description Validate and normalize up to 100 create payloads, returning each normalized result with its non-blocking warnings, or its errors. Nothing is written to the database.
+// @Tags Subscriptions
+// @Accept json
+// @Produce json
+// @Param subscriptions body models.ValidateBatchRequest true "Batch of subscription payloads"
+// @Success 200 {object} models.ValidateBatchResponse
+// @Failure 400 {object} models.ErrorResponse "Bad Request - Empty or oversized batch"
+// @Router /subscriptions/validate-batch [post]
+func (h *SubscriptionHandler) ValidateSubscriptionsBatch(c *gin.Context) {
+
+	var req *models.ValidateBatchRequest
+
+	// Bind and validate request payload
+	//Привяжите и проверьте полезную нагрузку запроса.
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondInvalidInput(c, err)
+		return
+	}
+
+	h.requestLogger(c).Infof("validating subscription batch: Size: %+v", len(req.Subscriptions))
+
+	res := &models.ValidateBatchResponse{Results: make([]models.BatchValidationResult, len(req.Subscriptions))}
+	for i := range req.Subscriptions {
+		item := &req.Subscriptions[i]
+		result := models.BatchValidationResult{Index: i}
+
+		// Apply the request binding rules first, then the service validation used by create
+		// Сначала применяются правила привязки запроса, затем проверка сервиса, используемая при создании
+		if err := binding.Validator.ValidateStruct(item); err != nil {
+			result.Errors = validationMessages(err)
+		} else if sub, err := h.service.ValidateCreateRequest(c.Request.Context(), item); err != nil {
+			result.Errors = append(result.Errors, err.Error())
+		} else {
+			formatted := FormatToSubscriptionResponse(sub)
+			result.Subscription = &formatted
+			result.Valid = true
+			// valid rows may still be suspicious, reported like on create
+			// корректные строки все еще могут быть подозрительными, о них сообщается как при создании
+			result.Warnings = h.service.CollectWarnings(c.Request.Context(), sub)
+		}
+
+		if result.Valid {
+			res.Valid++
+		} else {
+			res.Invalid++
+		}
+		res.Results[i] = result
+	}
 
+	c.JSON(http.StatusOK, res)
+}
+
+// CompareSubscriptions returns subscriptions side by side with their annualized cost,
+// marking the cheapest and the most expensive one.
+// CompareSubscriptions godoc
+// @Summary Compare subscriptions
+// @Description Compare two or more subscriptions side by side with their annualized cost
+// @Tags Subscriptions
+// @Accept json
+// @Produce json
+// @Param ids body models.CompareSubscriptionsRequest true "Subscription IDs to compare"
+// @Success 200 {object} models.CompareSubscriptionsResponse
+// @Failure 400 {object} models.ErrorResponse "Bad Request - Fewer than two or invalid IDs"
+// @Failure 404 {object} models.ErrorResponse "Not Found - Lists the missing subscription IDs"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /subscriptions/compare [post]
+func (h *SubscriptionHandler) CompareSubscriptions(c *gin.Context) {
+
+	var req *models.CompareSubscriptionsRequest
+
+	// Bind and validate request payload
+	//Привяжите и проверьте полезную нагрузку запроса.
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondInvalidInput(c, err)
+		return
+	}
+
+	h.requestLogger(c).Infof("comparing subscriptions: IDs: %+v", req.IDs)
+
+	//process business logic for CompareSubscriptions
+	//Обработка бизнес-логики для CompareSubscriptions
+	subs, missing, err := h.service.CompareSubscriptions(c.Request.Context(), req.IDs)
 	if err != nil {
 		h.handleServiceError(c, err)
 		return
 	}
+	if len(missing) > 0 {
+		h.requestLogger(c).Infof("compared subscriptions not found: IDs: %+v", missing)
+		RespondError(c, http.StatusNotFound, validations.ErrSubscriptionNotFound, fmt.Sprintf("missing ids: %v", missing))
+		return
+	}
+
+	cheapestID, mostExpensiveID := service.PriceExtremes(subs)
+	res := &models.CompareSubscriptionsResponse{Subscriptions: make([]models.ComparedSubscription, len(subs))}
+	for i, sub := range subs {
+		compared := models.ComparedSubscription{SubscriptionResponse: FormatToSubscriptionResponse(&sub), AnnualCost: service.AnnualCost(&sub)}
+		switch sub.ID {
+		case cheapestID:
+			compared.Annotation = models.CompareCheapest
+		case mostExpensiveID:
+			compared.Annotation = models.CompareMostExpensive
+		}
+		res.Subscriptions[i] = compared
+	}
 
-	c.JSON(http.StatusCreated, FormatToSubscriptionResponse(sub))
+	c.JSON(http.StatusOK, res)
 }
 
 // ListSubscriptions retrieves paginated subscriptions with optional sorting and filtering
 // It converts internal date fields to MM-YYYY format and returns a paginated API response
+// With pagination=cursor or after it switches to keyset pagination, answered by listSubscriptionsByCursor
 // ListSubscriptions godoc
 // @Summary List subscriptions with pagination
-// @Description Retrieve paginated list of subscriptions with optional sorting
+// @Description Retrieve paginated list of subscriptions with optional sorting.
+// @Description With pagination=cursor, or when after is given, the list is keyset paginated in ascending id order instead: limit defaults to 50 (max 200), the other parameters are ignored, and the response is a models.CursorSubscriptionsResponse with items and next_cursor, empty on the last page.
+// @Description With page or page_size, the response is a models.PaginatedSubscriptions with items, total, page, page_size and total_pages instead, keeping the counts and totals options.
 // @Tags Subscriptions
 // @Accept json
-// @Produce json
-// @Param limit query int false "Maximum number of items to return" default(10) minimum(1) maximum(100)
+// @Produce json,application/vnd.api+json
+// @Param limit query int false "Maximum number of items to return, at most 100, with pagination=cursor default 50 and at most 200" default(10) minimum(1) maximum(200)
 // @Param offset query int false "Number of items to skip" default(0) minimum(0)
 // @Param sort_by query string false "Field to sort by" default(id) Enums(id, user_id, service_name, price, start_date, end_date)
 // @Param order query string false "Sort order" default(desc) Enums(asc, desc)
+// @Param status query string false "Filter by status derived from the dates" Enums(active, upcoming, expired)
+// @Param include_counts query bool false "Include the unfiltered and filtered-out totals in meta"
+// @Param with_totals query bool false "Append a totals footer with the row count and price sum of the page and of every filtered row"
+// @Param with_cost query bool false "Add the cost of each subscription over the from-to period"
+// @Param from query string false "Cost period start (MM-YYYY) with with_cost, defaults to the start of DEFAULT_STATS_PERIOD before to"
+// @Param to query string false "Cost period end (MM-YYYY) with with_cost, defaults to the current month"
+// @Param search query string false "Keep subscriptions whose service name or description contains it, case-insensitively" maxlength(100)
+// @Param pagination query string false "cursor selects keyset pagination" Enums(cursor)
+// @Param after query string false "Opaque next_cursor of the previous page, implies pagination=cursor" maxlength(64)
+// @Param page query int false "Page number, replaces offset with (page-1)*page_size, invalid values mean 1" minimum(1)
+// @Param page_size query int false "Page size, replaces limit and is clamped to 100, invalid values keep limit" minimum(1) maximum(100)
 // @Success 200 {object} models.ListSubscriptionsResponse
-// @Failure 400 {object} models.ErrorResponse "Bad Request - Invalid query parameters"
+// @Failure 400 {object} models.ErrorResponse "Bad Request - Invalid query parameters or cost period"
 // @Failure 500 {object} models.ErrorResponse "Internal Server Error"
 // @Router /subscriptions [get]
 func (h *SubscriptionHandler) ListSubscriptions(c *gin.Context) {
 
+	// Keyset pagination has its own query and items/next_cursor envelope
+	// Keyset-пагинация имеет собственные параметры и конверт items/next_cursor
+	if c.Query("pagination") == models.PaginationCursor || c.Query("after") != "" {
+		h.listSubscriptionsByCursor(c)
+		return
+	}
+
 	var req *models.ListSubscriptionRequest
 
 	// Bind and validate request payload
 	//Привяжите и проверьте полезную нагрузку запроса.
 	if err := c.ShouldBindQuery(&req); err != nil {
-		h.Logger.WithError(err).Info(validations.ErrInvalidRequestInput)
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: validations.ErrInvalidRequestInput.Error(), Details: err.Error()})
+		h.respondInvalidInput(c, err)
+		return
+	}
+	page, err := ParsePagination(c)
+	if err != nil {
+		h.respondInvalidInput(c, err)
 		return
 	}
-	h.Logger.Infof("getting subscriptions:- Limit: %+v, Offset: %+v, SortBy: %+v, Order: %+v", req.Limit, req.Offset, req.SortBy, req.Order)
+	req.Pagination = page
+	period, err := ParseDateRange(c)
+	if err != nil {
+		h.respondInvalidInput(c, err)
+		return
+	}
+	req.DateRange = period
+	h.requestLogger(c).Infof("getting subscriptions:- Limit: %+v, Offset: %+v, SortBy: %+v, Order: %+v, Status: %+v", req.Limit, req.Offset, req.SortBy, req.Order, req.Status)
 
 	//process business logic for ListSubscriptionRequest
 	//Обработка бизнес-логики для ListSubscriptionRequest
@@ -116,21 +300,92 @@ func (h *SubscriptionHandler) ListSubscriptions(c *gin.Context) {
 
 	// Convert each subscription model to API response format
 	// Преобразовать каждую модель подписки в формат ответа API
-	formatedSubs := make([]models.SubscriptionResponse, len(subs))
-	for i, sub := range subs {
-		formatedSubs[i] = FormatToSubscriptionResponse(&sub)
+	formatedSubs := formatSubscriptions(subs)
+
+	// Add the cost of each subscription over the requested period when asked
+	// Добавить стоимость каждой подписки за запрошенный период, если запрошено
+	if req.WithCost {
+		costs, err := h.service.ListPeriodCosts(req, subs)
+		if err != nil {
+			h.handleServiceError(c, err)
+			return
+		}
+		for i := range formatedSubs {
+			formatedSubs[i].Cost = &costs[i]
+		}
 	}
 
 	// Create pagination metadata for the response
 	// Создание метаданных для пагинации ответа
 	paginationMeta := &models.PaginationMeta{Limit: req.Limit, Offset: req.Offset, SortBy: req.SortBy, Order: req.Order, Total: total}
 
+	// Add the unfiltered total and the number of filtered out subscriptions when requested
+	// Добавить общее количество без фильтров и количество отфильтрованных подписок, если запрошено
+	if req.IncludeCounts {
+		unfiltered, err := h.service.CountAllSubscriptions(c.Request.Context())
+		if err != nil {
+			h.handleServiceError(c, err)
+			return
+		}
+		filteredOut := unfiltered - total
+		paginationMeta.UnfilteredTotal = &unfiltered
+		paginationMeta.FilteredOut = &filteredOut
+	}
+
 	// Create a final response with subscription and pagination data
 	// Создать окончательный ответ с данными о подписке и постраничной навигации
 	res := &models.ListSubscriptionsResponse{Subscriptions: formatedSubs, Meta: paginationMeta}
 
-	c.JSON(http.StatusOK, res)
+	// Add the totals footer of the returned and the filtered rows when requested
+	// Добавить итоговый блок по возвращенным и отфильтрованным строкам, если запрошено
+	if req.WithTotals {
+		priceSum, err := h.service.SumListedPrices(c.Request.Context(), req)
+		if err != nil {
+			h.handleServiceError(c, err)
+			return
+		}
+		res.Totals = &models.ListTotals{PageCount: len(subs), Count: total, PriceSum: priceSum}
+		for _, sub := range subs {
+			res.Totals.PagePriceSum += int64(sub.Price)
+		}
+	}
+
+	// A list requested by page number is answered with the page envelope
+	// Список, запрошенный по номеру страницы, возвращается в конверте страницы
+	if req.ByPage() {
+		RespondPaginated(c, newPaginatedSubscriptions(res))
+		return
+	}
+
+	RespondPaginated(c, res)
+
+}
 
+// listSubscriptionsByCursor answers the keyset paginated list: up to limit subscriptions in ascending id order
+// after the opaque cursor in after, with the cursor of the next page, empty on the last one.
+// listSubscriptionsByCursor отвечает списком с keyset-пагинацией: до limit подписок в порядке возрастания id
+// после непрозрачного курсора в after, с курсором следующей страницы, пустым на последней.
+func (h *SubscriptionHandler) listSubscriptionsByCursor(c *gin.Context) {
+
+	var req *models.CursorListRequest
+
+	// Bind and validate request payload
+	//Привяжите и проверьте полезную нагрузку запроса.
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.respondInvalidInput(c, err)
+		return
+	}
+	h.requestLogger(c).Infof("getting subscriptions by cursor:- Limit: %+v, After: %+v", req.Limit, req.After)
+
+	//process business logic for CursorListRequest
+	//Обработка бизнес-логики для CursorListRequest
+	subs, nextCursor, err := h.service.ListSubscriptionsAfter(c.Request.Context(), req.Limit, req.After)
+	if err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	RespondPaginated(c, &models.CursorSubscriptionsResponse{Items: formatSubscriptions(subs), NextCursor: nextCursor})
 }
 
 // GetSubscription retrieves a single subscription by its ID.
@@ -140,8 +395,8 @@ func (h *SubscriptionHandler) ListSubscriptions(c *gin.Context) {
 // @Description Retrieve a subscription using its ID
 // @Tags Subscriptions
 // @Accept json
-// @Produce json
-// @Param id path int true "Subscription ID" minimum(1)
+// @Produce json,application/vnd.api+json
+// @Param id path string true "Subscription ID, a UUID when UUID_SUBSCRIPTION_IDS is set"
 // @Success 200 {object} models.SubscriptionResponse
 // @Failure 400 {object} models.ErrorResponse "Bad Request - Invalid subscription ID"
 // @Failure 404 {object} models.ErrorResponse "Not Found - Subscription does not exist"
@@ -154,14 +409,11 @@ func (h *SubscriptionHandler) GetSubscription(c *gin.Context) {
 	// Bind and validate request payload
 	//Привяжите и проверьте полезную нагрузку запроса.
 	if err := c.ShouldBindUri(&req); err != nil {
-		h.Logger.WithError(err).Info(validations.ErrInvalidRequestInput)
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error: validations.ErrInvalidRequestInput.Error(), Details: err.Error(),
-		})
+		h.respondInvalidInput(c, err)
 		return
 	}
 
-	h.Logger.Info("getting subscription by ID: ", req.ID)
+	h.requestLogger(c).Info("getting subscription by ID: ", req.ID)
 
 	//process business logic for GetSubscriptionRequest
 	//Обработка бизнес-логики для GetSubscription Request
@@ -171,8 +423,147 @@ func (h *SubscriptionHandler) GetSubscription(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, FormatToSubscriptionResponse(sub))
+	respondSubscription(c, http.StatusOK, FormatToSubscriptionResponse(sub))
+
+}
+
+// GetCostPerMonth returns the effective monthly cost of a subscription
+// over its active months within an optional period.
+// GetCostPerMonth godoc
+// @Summary Get subscription cost per month
+// @Description Compute the effective monthly cost of a subscription over its active months in a period
+// @Tags Subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "Subscription ID, a UUID when UUID_SUBSCRIPTION_IDS is set"
+// @Param from query string false "Start date (MM-YYYY), defaults to the subscription start"
+// @Param to query string false "End date (MM-YYYY), defaults to the current month"
+// @Success 200 {object} models.CostPerMonthResponse
+// @Failure 400 {object} models.ErrorResponse "Bad Request - Invalid subscription ID or period"
+// @Failure 404 {object} models.ErrorResponse "Not Found - Subscription does not exist"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /subscriptions/{id}/cost-per-month [get]
+func (h *SubscriptionHandler) GetCostPerMonth(c *gin.Context) {
+
+	var uri *models.SubscriptionUriIDRequest
+	var req models.CostPerMonthRequest
+
+	// Bind and validate uri and query request payload
+	//Привязка и проверка полезной нагрузки URI и параметров запроса
+	if err := c.ShouldBindUri(&uri); err != nil {
+		h.respondInvalidInput(c, err)
+		return
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.respondInvalidInput(c, err)
+		return
+	}
+	period, err := ParseDateRange(c)
+	if err != nil {
+		h.respondInvalidInput(c, err)
+		return
+	}
+	req.DateRange = period
+
+	h.requestLogger(c).Infof("getting cost per month: ID: %+v, PeriodStart: %+v, PeriodEnd: %+v", uri.ID, req.From, req.To)
+
+	//process business logic for GetCostPerMonth
+	//Обработка бизнес-логики для GetCostPerMonth
+	res, err := h.service.GetCostPerMonth(c.Request.Context(), uri.ID, &req)
+	if err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// GetSubscriptionTimeline returns the monthly cost contribution of a subscription as a time series.
+// GetSubscriptionTimeline godoc
+// @Summary Get subscription cost timeline
+// @Description Monthly cost contribution of a subscription, one point per month, over its lifespan or the queried period. Open-ended subscriptions run up to the current month; inactive months cost 0.
+// @Tags Subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "Subscription ID, a UUID when UUID_SUBSCRIPTION_IDS is set"
+// @Param from query string false "Start date (MM-YYYY), defaults to the subscription start"
+// @Param to query string false "End date (MM-YYYY), defaults to the subscription end or the current month"
+// @Success 200 {object} models.SubscriptionTimelineResponse
+// @Failure 400 {object} models.ErrorResponse "Bad Request - Invalid subscription ID or period"
+// @Failure 404 {object} models.ErrorResponse "Not Found - Subscription does not exist"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /subscriptions/{id}/timeline [get]
+func (h *SubscriptionHandler) GetSubscriptionTimeline(c *gin.Context) {
+
+	var uri *models.SubscriptionUriIDRequest
+	var req models.SubscriptionTimelineRequest
+
+	// Bind and validate uri and query request payload
+	//Привязка и проверка полезной нагрузки URI и параметров запроса
+	if err := c.ShouldBindUri(&uri); err != nil {
+		h.respondInvalidInput(c, err)
+		return
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.respondInvalidInput(c, err)
+		return
+	}
+	period, err := ParseDateRange(c)
+	if err != nil {
+		h.respondInvalidInput(c, err)
+		return
+	}
+	req.DateRange = period
+
+	h.requestLogger(c).Infof("getting subscription timeline: ID: %+v, PeriodStart: %+v, PeriodEnd: %+v", uri.ID, req.From, req.To)
+
+	//process business logic for GetSubscriptionTimeline
+	//Обработка бизнес-логики для GetSubscriptionTimeline
+	res, err := h.service.GetSubscriptionTimeline(c.Request.Context(), uri.ID, &req)
+	if err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// GetSubscriptionPeriods returns the continuous coverage periods of a user's service.
+// GetSubscriptionPeriods godoc
+// @Summary Get subscription coverage periods
+// @Description Continuous ranges of months a user was subscribed to a service, in chronological order. Overlapping subscriptions and subscriptions starting the month after another ends are merged; to is null for an open-ended range.
+// @Tags Subscriptions
+// @Accept json
+// @Produce json
+// @Param user_id query string true "User ID (UUID)"
+// @Param service_name query string true "Service name, matched case-insensitively"
+// @Success 200 {object} models.SubscriptionPeriodsResponse
+// @Failure 400 {object} models.ErrorResponse "Bad Request - Invalid user ID or service name"
+// @Failure 422 {object} models.ErrorResponse "Unprocessable Entity - Too many subscriptions"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /subscriptions/periods [get]
+func (h *SubscriptionHandler) GetSubscriptionPeriods(c *gin.Context) {
+
+	var req models.SubscriptionPeriodsRequest
+
+	// Bind and validate query request payload
+	//Привязка и проверка параметров запроса
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.respondInvalidInput(c, err)
+		return
+	}
+
+	h.requestLogger(c).Infof("getting subscription periods: UserID: %+v, ServiceName: %+v", req.UserID, req.ServiceName)
+
+	//process business logic for GetSubscriptionPeriods
+	//Обработка бизнес-логики для GetSubscriptionPeriods
+	res, err := h.service.GetSubscriptionPeriods(c.Request.Context(), &req)
+	if err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
 
+	c.JSON(http.StatusOK, res)
 }
 
 // UpdateSubscription updates an existing subscription by ID.
@@ -183,8 +574,8 @@ func (h *SubscriptionHandler) GetSubscription(c *gin.Context) {
 // @Description Partially update subscription fields by ID (only provided fields are modified)
 // @Tags Subscriptions
 // @Accept json
-// @Produce json
-// @Param id path int true "Subscription ID" minimum(1)
+// @Produce json,application/vnd.api+json
+// @Param id path string true "Subscription ID, a UUID when UUID_SUBSCRIPTION_IDS is set"
 // @Param subscription body models.UpdateSubscriptionRequest true "Update payload (partial update)"
 // @Success 200 {object} models.SubscriptionResponse
 // @Failure 400 {object} models.ErrorResponse "Bad Request - Invalid input or validation failed"
@@ -198,32 +589,121 @@ func (h *SubscriptionHandler) UpdateSubscription(c *gin.Context) {
 	// Bind and validate uri request payload
 	//Привязка и проверка полезной нагрузки запроса URI
 	if err := c.ShouldBindUri(&reqUri); err != nil {
-		h.Logger.WithError(err).Info(validations.ErrInvalidRequestInput)
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error: validations.ErrInvalidRequestInput.Error(), Details: err.Error(),
-		})
+		h.respondInvalidInput(c, err)
 		return
 	}
 	// Bind and validate update request payload.
 	// Привязать и проверить полезную нагрузку запроса на обновление.
 	var req *models.UpdateSubscriptionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.Logger.WithError(err).Info(validations.ErrInvalidRequestInput)
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: validations.ErrInvalidRequestInput.Error(), Details: err.Error()})
+		h.respondInvalidInput(c, err)
 		return
 	}
 
-	h.Logger.Info("updating subscription:")
+	h.requestLogger(c).Info("updating subscription:")
 
 	//process business logic for UpdateSubscriptionRequest
 	//Обработка бизнес-логики для GetSubscription Request
-	sub, err := h.service.UpdateSubscriptionByID(c.Request.Context(), reqUri.ID, req)
+	sub, warnings, err := h.service.UpdateSubscriptionByID(c.Request.Context(), reqUri.ID, req)
 	if err != nil {
 		h.handleServiceError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, FormatToSubscriptionResponse(sub))
+	res := FormatToSubscriptionResponse(sub)
+	res.Warnings = warnings
+	respondSubscription(c, http.StatusOK, res)
+}
+
+// CancelSubscription marks a subscription as ending by setting its end_date,
+// the current month when the body is empty.
+// CancelSubscription godoc
+// @Summary Cancel subscription
+// @Description Set the end_date of a subscription to the given month (MM-YYYY), the current month by default. The end_date must not be before the start_date.
+// @Tags Subscriptions
+// @Accept json
+// @Produce json,application/vnd.api+json
+// @Param id path string true "Subscription ID, a UUID when UUID_SUBSCRIPTION_IDS is set"
+// @Param cancel body models.CancelSubscriptionRequest false "Month the subscription ends"
+// @Success 200 {object} models.SubscriptionResponse
+// @Failure 400 {object} models.ErrorResponse "Bad Request - Invalid input or end_date before start_date"
+// @Failure 404 {object} models.ErrorResponse "Not Found - Subscription does not exist"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /subscriptions/{id}/cancel [post]
+func (h *SubscriptionHandler) CancelSubscription(c *gin.Context) {
+
+	var reqUri *models.SubscriptionUriIDRequest
+	var req models.CancelSubscriptionRequest
+
+	// Bind and validate uri and request payload, an empty body cancels at the current month
+	//Привязка и проверка URI и полезной нагрузки, пустое тело отменяет подписку текущим месяцем
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		h.respondInvalidInput(c, err)
+		return
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.respondInvalidInput(c, err)
+		return
+	}
+
+	h.requestLogger(c).Infof("cancelling subscription: ID: %+v, EndDate: %+v", reqUri.ID, req.EndDate)
+
+	//process business logic for CancelSubscription
+	//Обработка бизнес-логики для CancelSubscription
+	sub, warnings, err := h.service.CancelSubscription(c.Request.Context(), reqUri.ID, &req)
+	if err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	res := FormatToSubscriptionResponse(sub)
+	res.Warnings = warnings
+	respondSubscription(c, http.StatusOK, res)
+}
+
+// ExtendSubscription pushes the end_date of a subscription forward by a number of monthly periods.
+// ExtendSubscription godoc
+// @Summary Extend subscription
+// @Description Push the end_date of a subscription forward by 1 to 120 monthly periods. Open-ended subscriptions can't be extended.
+// @Tags Subscriptions
+// @Accept json
+// @Produce json,application/vnd.api+json
+// @Param id path string true "Subscription ID, a UUID when UUID_SUBSCRIPTION_IDS is set"
+// @Param extend body models.ExtendSubscriptionRequest true "Number of periods to extend by"
+// @Success 200 {object} models.SubscriptionResponse
+// @Failure 400 {object} models.ErrorResponse "Bad Request - Invalid input or subscription has no end date"
+// @Failure 404 {object} models.ErrorResponse "Not Found - Subscription does not exist"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /subscriptions/{id}/extend [post]
+func (h *SubscriptionHandler) ExtendSubscription(c *gin.Context) {
+
+	var reqUri *models.SubscriptionUriIDRequest
+	var req models.ExtendSubscriptionRequest
+
+	// Bind and validate uri and request payload
+	//Привязка и проверка URI и полезной нагрузки запроса
+	if err := c.ShouldBindUri(&reqUri); err != nil {
+		h.respondInvalidInput(c, err)
+		return
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondInvalidInput(c, err)
+		return
+	}
+
+	h.requestLogger(c).Infof("extending subscription: ID: %+v, Periods: %+v", reqUri.ID, req.Periods)
+
+	//process business logic for ExtendSubscription
+	//Обработка бизнес-логики для ExtendSubscription
+	sub, warnings, err := h.service.ExtendSubscription(c.Request.Context(), reqUri.ID, &req)
+	if err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	res := FormatToSubscriptionResponse(sub)
+	res.Warnings = warnings
+	respondSubscription(c, http.StatusOK, res)
 }
 
 // DeleteSubscription handles deleting a subscription by its ID.
@@ -235,7 +715,7 @@ func (h *SubscriptionHandler) UpdateSubscription(c *gin.Context) {
 // @Tags Subscriptions
 // @Accept json
 // @Produce json
-// @Param id path int true "Subscription ID" minimum(1)
+// @Param id path string true "Subscription ID, a UUID when UUID_SUBSCRIPTION_IDS is set"
 // @Success 204 "No Content - Subscription successfully deleted"
 // @Failure 400 {object} models.ErrorResponse "Bad Request - Invalid subscription ID"
 // @Failure 404 {object} models.ErrorResponse "Not Found - Subscription does not exist"
@@ -246,14 +726,11 @@ func (h *SubscriptionHandler) DeleteSubscription(c *gin.Context) {
 	// Bind and validate uri request payload
 	//Привязка и проверка полезной нагрузки запроса URI
 	if err := c.ShouldBindUri(&req); err != nil {
-		h.Logger.WithError(err).Info(validations.ErrInvalidRequestInput)
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error: validations.ErrInvalidRequestInput.Error(), Details: err.Error(),
-		})
+		h.respondInvalidInput(c, err)
 		return
 	}
 
-	h.Logger.Info("deleting subscription by ID: ", req.ID)
+	h.requestLogger(c).Info("deleting subscription by ID: ", req.ID)
 
 	//process business logic for DeleteSubscriptionRequest
 	//Обработка бизнес-логики для DeleteSubscription Request
@@ -266,6 +743,7 @@ func (h *SubscriptionHandler) DeleteSubscription(c *gin.Context) {
 
 // GetUserSubscriptionSummary calculates subscription statistics for a given user
 // within an optional date range and optional service name filter.
+// Without a service name every service of the user is summarized.
 // Returns total cost, unique months, and subscription count.
 // GetUserSubscriptionSummary godoc
 // @Summary Get user subscription summary
@@ -274,11 +752,16 @@ func (h *SubscriptionHandler) DeleteSubscription(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param user_id query string true "User UUID" format(uuid)
-// @Param service_name query string true "Filter by service name"
-// @Param from query string false "Start date (MM-YYYY)"
-// @Param to query string false "End date (MM-YYYY)"
+// @Param service_name query string false "Filter by service name, matched case-insensitively, all services when omitted"
+// @Param exact_service_name query bool false "Match service_name exactly (case-sensitive)"
+// @Param from query string false "Start date (MM-YYYY), defaults to the start of DEFAULT_STATS_PERIOD before to"
+// @Param to query string false "End date (MM-YYYY), defaults to the current month"
+// @Param include_members query bool false "Roll family plan members up into their parent subscriptions"
+// @Param budget query int false "Spend limit the total cost is compared against" minimum(0)
+// @Param diagnostics query bool false "List the subscriptions contributing nothing to the total and why"
 // @Success 200 {object} models.UserSubscriptionSummaryResponse
 // @Failure 400 {object} models.ErrorResponse "Bad Request - Invalid parameters"
+// @Failure 422 {object} models.ErrorResponse "Unprocessable Entity - Too many rows, narrow the query"
 // @Failure 500 {object} models.ErrorResponse "Internal Server Error"
 // @Router /subscriptions/summary [get]
 func (h *SubscriptionHandler) GetUserSubscriptionSummary(c *gin.Context) {
@@ -288,16 +771,22 @@ func (h *SubscriptionHandler) GetUserSubscriptionSummary(c *gin.Context) {
 	// Bind and validate request payload
 	//Привяжите и проверьте полезную нагрузку запроса.
 	if err := c.ShouldBindQuery(&req); err != nil {
-		h.Logger.WithError(err).Info(validations.ErrInvalidRequestInput)
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: validations.ErrInvalidRequestInput.Error(), Details: err.Error()})
+		h.respondInvalidInput(c, err)
 		return
 	}
+	period, err := ParseDateRange(c)
+	if err != nil {
+		h.respondInvalidInput(c, err)
+		return
+	}
+	req.DateRange = period
 
-	h.Logger.Infof("getting user's subscription summary: UserID: %+v, ServiceName: %+v, PeriodStart: %+v, PeriodEnd: %+v", req.UserID, req.ServiceName, req.From, req.To)
+	c.Set(middleware.UserIDKey, req.UserID)
+	h.requestLogger(c).Infof("getting user's subscription summary: UserID: %+v, ServiceName: %+v, PeriodStart: %+v, PeriodEnd: %+v", req.UserID, req.ServiceName, req.From, req.To)
 
 	//process business logic for GetUserSubscriptionSummaryRequest
 	//Обработка бизнес-логики для GetUserSubscriptionSummaryRequest
-	unitPrice, totalAmount, totalMonths, err := h.service.GetUserSubscriptionSummary(c.Request.Context(), req)
+	unitPrice, totalAmount, totalMonths, excluded, err := h.service.GetUserSubscriptionSummary(c.Request.Context(), req)
 	if err != nil {
 		h.handleServiceError(c, err)
 		return
@@ -309,7 +798,537 @@ func (h *SubscriptionHandler) GetUserSubscriptionSummary(c *gin.Context) {
 		TotalMonths: totalMonths,
 		UnitPrice:   unitPrice,
 		TotalAmount: totalAmount,
+		Period:      h.service.StatsPeriod(req.From, req.To),
+		Excluded:    excluded,
+	}
+
+	// Compare the total against the budget when provided
+	// Сравнить общую сумму с бюджетом, если он указан
+	if req.Budget != nil {
+		overage := max(totalAmount-*req.Budget, 0)
+		overBudget := overage > 0
+		res.OverBudget = &overBudget
+		res.Overage = &overage
 	}
 	c.JSON(http.StatusOK, res)
 
 }
+
+// GetServiceStats returns the per-service summaries of a user in one call.
+// GetServiceStats godoc
+// @Summary Get per-service summaries of a user
+// @Description Summarize total cost, months and subscription count of each service of a user, by cost descending
+// @Tags Subscriptions
+// @Accept json
+// @Produce json
+// @Param user_id query string true "User UUID" format(uuid)
+// @Param from query string false "Start date (MM-YYYY), defaults to the start of DEFAULT_STATS_PERIOD before to"
+// @Param to query string false "End date (MM-YYYY), defaults to the current month"
+// @Success 200 {object} models.ServiceStatsResponse
+// @Failure 400 {object} models.ErrorResponse "Bad Request - Invalid parameters"
+// @Failure 422 {object} models.ErrorResponse "Unprocessable Entity - Too many rows, narrow the query"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /subscriptions/stats/services [get]
+func (h *SubscriptionHandler) GetServiceStats(c *gin.Context) {
+
+	var req models.ServiceStatsRequest
+
+	// Bind and validate request payload
+	//Привяжите и проверьте полезную нагрузку запроса.
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.respondInvalidInput(c, err)
+		return
+	}
+	period, err := ParseDateRange(c)
+	if err != nil {
+		h.respondInvalidInput(c, err)
+		return
+	}
+	req.DateRange = period
+
+	c.Set(middleware.UserIDKey, req.UserID)
+	h.requestLogger(c).Infof("getting user's per-service summaries: UserID: %+v, PeriodStart: %+v, PeriodEnd: %+v", req.UserID, req.From, req.To)
+
+	//process business logic for GetServiceStats
+	//Обработка бизнес-логики для GetServiceStats
+	services, err := h.service.GetServiceStats(c.Request.Context(), &req)
+	if err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, &models.ServiceStatsResponse{UserID: req.UserID, Period: h.service.StatsPeriod(req.From, req.To), Services: services})
+}
+
+// GetQuarterlyStats returns the cost of a user for each quarter of a year.
+// GetQuarterlyStats godoc
+// @Summary Get quarterly costs of a user
+// @Description Total cost of a user for each of the four quarters of a year, every service included, computed like a summary of each quarter. Quarters without activity cost 0.
+// @Tags Subscriptions
+// @Accept json
+// @Produce json
+// @Param user_id query string true "User UUID" format(uuid)
+// @Param year query int true "Year" minimum(1970) maximum(2100)
+// @Success 200 {object} models.QuarterlyStatsResponse
+// @Failure 400 {object} models.ErrorResponse "Bad Request - Invalid user ID or year"
+// @Failure 422 {object} models.ErrorResponse "Unprocessable Entity - Too many rows, narrow the query"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /subscriptions/stats/quarterly [get]
+func (h *SubscriptionHandler) GetQuarterlyStats(c *gin.Context) {
+
+	var req models.QuarterlyStatsRequest
+
+	// Bind and validate request payload
+	//Привяжите и проверьте полезную нагрузку запроса.
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.respondInvalidInput(c, err)
+		return
+	}
+
+	c.Set(middleware.UserIDKey, req.UserID)
+	h.requestLogger(c).Infof("getting quarterly stats: UserID: %+v, Year: %+v", req.UserID, req.Year)
+
+	//process business logic for GetQuarterlyStats
+	//Обработка бизнес-логики для GetQuarterlyStats
+	res, err := h.service.GetQuarterlyStats(c.Request.Context(), &req)
+	if err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// GetPriceStats returns the price statistics of a service across all users.
+// GetPriceStats godoc
+// @Summary Get average price of a service
+// @Description Average, median, min and max monthly price of a service across all users, the service name matched case-insensitively
+// @Tags Subscriptions
+// @Accept json
+// @Produce json
+// @Param service_name query string true "Service name"
+// @Success 200 {object} models.PriceStatsResponse
+// @Failure 400 {object} models.ErrorResponse "Bad Request - Invalid service name"
+// @Failure 404 {object} models.ErrorResponse "Not Found - Service has no subscriptions"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /subscriptions/stats/avg-price [get]
+func (h *SubscriptionHandler) GetPriceStats(c *gin.Context) {
+
+	var req models.PriceStatsRequest
+
+	// Bind and validate request payload
+	//Привяжите и проверьте полезную нагрузку запроса.
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.respondInvalidInput(c, err)
+		return
+	}
+
+	h.requestLogger(c).Infof("getting price statistics: ServiceName: %+v", req.ServiceName)
+
+	//process business logic for GetPriceStats
+	//Обработка бизнес-логики для GetPriceStats
+	res, err := h.service.GetPriceStats(c.Request.Context(), &req)
+	if err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// GetRevenueByMonth returns the revenue of every month of a period.
+// GetRevenueByMonth godoc
+// @Summary Get revenue by month
+// @Description Summed price of the subscriptions active in each month of the period, of every subscription or of one user. The period defaults to DEFAULT_STATS_PERIOD up to the current month (the last 12 months when it is all_time) and spans at most 1200 months.
+// @Tags Subscriptions
+// @Accept json
+// @Produce json
+// @Param user_id query string false "User UUID, all users when omitted" format(uuid)
+// @Param from query string false "Start date (MM-YYYY), defaults to the start of DEFAULT_STATS_PERIOD before to"
+// @Param to query string false "End date (MM-YYYY), defaults to the current month"
+// @Success 200 {object} models.RevenueByMonthResponse
+// @Failure 400 {object} models.ErrorResponse "Bad Request - Invalid user ID or period"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /subscriptions/stats/revenue [get]
+func (h *SubscriptionHandler) GetRevenueByMonth(c *gin.Context) {
+
+	var req models.RevenueByMonthRequest
+
+	// Bind and validate request payload
+	//Привяжите и проверьте полезную нагрузку запроса.
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.respondInvalidInput(c, err)
+		return
+	}
+	period, err := ParseDateRange(c)
+	if err != nil {
+		h.respondInvalidInput(c, err)
+		return
+	}
+	req.DateRange = period
+
+	if req.UserID != "" {
+		c.Set(middleware.UserIDKey, req.UserID)
+	}
+	h.requestLogger(c).Infof("getting revenue by month: UserID: %+v, PeriodStart: %+v, PeriodEnd: %+v", req.UserID, req.From, req.To)
+
+	//process business logic for GetRevenueByMonth
+	//Обработка бизнес-логики для GetRevenueByMonth
+	res, err := h.service.GetRevenueByMonth(c.Request.Context(), &req)
+	if err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// CompareStatsPeriods compares the spend of a user over two periods.
+// CompareStatsPeriods godoc
+// @Summary Compare the spend of two periods
+// @Description Total cost and months of a user over period A and period B, with the absolute and percent change from A to B. delta_percent is null when period A cost nothing.
+// @Tags Subscriptions
+// @Accept json
+// @Produce json
+// @Param user_id query string true "User UUID" format(uuid)
+// @Param period_a_from query string true "Base period start (MM-YYYY)"
+// @Param period_a_to query string true "Base period end (MM-YYYY)"
+// @Param period_b_from query string true "Compared period start (MM-YYYY)"
+// @Param period_b_to query string true "Compared period end (MM-YYYY)"
+// @Success 200 {object} models.StatsCompareResponse
+// @Failure 400 {object} models.ErrorResponse "Bad Request - Invalid user ID or period"
+// @Failure 422 {object} models.ErrorResponse "Unprocessable Entity - Too many rows, narrow the query"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /subscriptions/stats/compare [get]
+func (h *SubscriptionHandler) CompareStatsPeriods(c *gin.Context) {
+
+	var req models.StatsCompareRequest
+
+	// Bind and validate request payload
+	//Привяжите и проверьте полезную нагрузку запроса.
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.respondInvalidInput(c, err)
+		return
+	}
+
+	c.Set(middleware.UserIDKey, req.UserID)
+	h.requestLogger(c).Infof("comparing user's spend: UserID: %+v, PeriodA: %+v-%+v, PeriodB: %+v-%+v", req.UserID, req.PeriodAFrom, req.PeriodATo, req.PeriodBFrom, req.PeriodBTo)
+
+	//process business logic for CompareStatsPeriods
+	//Обработка бизнес-логики для CompareStatsPeriods
+	res, err := h.service.CompareStatsPeriods(c.Request.Context(), &req)
+	if err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// GetTeamStats returns the combined spend of a list of users with a per-user breakdown.
+// GetTeamStats godoc
+// @Summary Get team spend
+// @Description Combine the total cost of up to 100 users over a period, with a per-user breakdown
+// @Tags Subscriptions
+// @Accept json
+// @Produce json
+// @Param team body models.TeamStatsRequest true "User IDs and optional period (MM-YYYY)"
+// @Success 200 {object} models.TeamStatsResponse
+// @Failure 400 {object} models.ErrorResponse "Bad Request - Invalid user IDs or period"
+// @Failure 422 {object} models.ErrorResponse "Unprocessable Entity - Too many rows, narrow the query"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /subscriptions/stats/team [post]
+func (h *SubscriptionHandler) GetTeamStats(c *gin.Context) {
+
+	var req *models.TeamStatsRequest
+
+	// Bind and validate request payload
+	//Привяжите и проверьте полезную нагрузку запроса.
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondInvalidInput(c, err)
+		return
+	}
+
+	h.requestLogger(c).Infof("getting team spend: Users: %+v, PeriodStart: %+v, PeriodEnd: %+v", len(req.UserIDs), req.From, req.To)
+
+	//process business logic for GetTeamStats
+	//Обработка бизнес-логики для GetTeamStats
+	res, err := h.service.GetTeamStats(c.Request.Context(), req)
+	if err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// ExportUserData returns every subscription of a user as a downloadable JSON document,
+// to answer data-subject (GDPR) access requests.
+// ExportUserData godoc
+// @Summary Export a user's data
+// @Description Download every subscription of a user as a single JSON document
+// @Tags Users
+// @Produce json
+// @Param user_id path string true "User UUID" format(uuid)
+// @Success 200 {object} models.UserExportResponse
+// @Failure 400 {object} models.ErrorResponse "Bad Request - Invalid user ID"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /users/{user_id}/export [get]
+func (h *SubscriptionHandler) ExportUserData(c *gin.Context) {
+
+	var req models.UserUriRequest
+
+	// Bind and validate uri request payload
+	//Привязка и проверка полезной нагрузки запроса URI
+	if err := c.ShouldBindUri(&req); err != nil {
+		h.respondInvalidInput(c, err)
+		return
+	}
+
+	c.Set(middleware.UserIDKey, req.UserID)
+	h.requestLogger(c).Info("exporting user data")
+
+	//process business logic for ExportUserData
+	//Обработка бизнес-логики для ExportUserData
+	subs, err := h.service.ExportUserData(c.Request.Context(), req.UserID)
+	if err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	res := &models.UserExportResponse{UserID: req.UserID, ExportedAt: time.Now().UTC(), Subscriptions: formatSubscriptions(subs)}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="subscriptions-%s.json"`, req.UserID))
+	c.JSON(http.StatusOK, res)
+}
+
+// EraseUserData deletes every subscription of a user to answer data-subject (GDPR) erasure requests.
+// EraseUserData godoc
+// @Summary Erase a user's data
+// @Description Delete every subscription of a user in one transaction and return how many were removed (admin only)
+// @Tags Users
+// @Produce json
+// @Security AdminKey
+// @Param user_id path string true "User UUID" format(uuid)
+// @Success 200 {object} models.UserEraseResponse
+// @Failure 400 {object} models.ErrorResponse "Bad Request - Invalid user ID"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized - Missing or invalid admin key"
+// @Failure 403 {object} models.ErrorResponse "Forbidden - Admin api is disabled"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /users/{user_id}/subscriptions [delete]
+func (h *SubscriptionHandler) EraseUserData(c *gin.Context) {
+
+	var req models.UserUriRequest
+
+	// Bind and validate uri request payload
+	//Привязка и проверка полезной нагрузки запроса URI
+	if err := c.ShouldBindUri(&req); err != nil {
+		h.respondInvalidInput(c, err)
+		return
+	}
+
+	c.Set(middleware.UserIDKey, req.UserID)
+	h.requestLogger(c).Info("erasing user data")
+
+	//process business logic for EraseUserData
+	//Обработка бизнес-логики для EraseUserData
+	deleted, err := h.service.EraseUserData(c.Request.Context(), req.UserID)
+	if err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, &models.UserEraseResponse{UserID: req.UserID, Deleted: deleted})
+}
+
+// GetUserStats returns the number of distinct customers for admin dashboards
+// without exposing any individual subscription data.
+// GetUserStats godoc
+// @Summary Get distinct user statistics
+// @Description Count distinct users with at least one subscription and with a subscription active now (admin only)
+// @Tags Admin
+// @Produce json
+// @Security AdminKey
+// @Success 200 {object} models.UserStatsResponse
+// @Failure 401 {object} models.ErrorResponse "Unauthorized - Missing or invalid admin key"
+// @Failure 403 {object} models.ErrorResponse "Forbidden - Admin api is disabled"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /admin/stats/users [get]
+func (h *SubscriptionHandler) GetUserStats(c *gin.Context) {
+
+	h.requestLogger(c).Info("getting distinct user statistics")
+
+	//process business logic for GetUserStats
+	//Обработка бизнес-логики для GetUserStats
+	totalUsers, activeUsers, err := h.service.GetUserStats(c.Request.Context())
+	if err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, &models.UserStatsResponse{TotalUsers: totalUsers, ActiveUsers: activeUsers})
+}
+
+// ExplainSummary returns the PostgreSQL plan of the summary query to diagnose slow aggregations.
+// The query runs with ANALYZE, so it is actually executed.
+// ExplainSummary godoc
+// @Summary Explain the summary query
+// @Description Run EXPLAIN (ANALYZE, FORMAT JSON) on the summary query (admin only, requires ENABLE_EXPLAIN)
+// @Tags Admin
+// @Produce json
+// @Security AdminKey
+// @Param user_id query string true "User UUID" format(uuid)
+// @Param service_name query string false "Filter by service name, matched case-insensitively, all services when omitted"
+// @Param exact_service_name query bool false "Match service_name exactly (case-sensitive)"
+// @Param from query string false "Start date (MM-YYYY), defaults to the start of DEFAULT_STATS_PERIOD before to"
+// @Param to query string false "End date (MM-YYYY), defaults to the current month"
+// @Success 200 {object} models.ExplainResponse
+// @Failure 400 {object} models.ErrorResponse "Bad Request - Invalid parameters"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized - Missing or invalid admin key"
+// @Failure 403 {object} models.ErrorResponse "Forbidden - Admin api is disabled"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /admin/stats/explain [get]
+func (h *SubscriptionHandler) ExplainSummary(c *gin.Context) {
+
+	var req models.UserSubscriptionSummaryRequest
+
+	// Bind and validate request payload
+	//Привяжите и проверьте полезную нагрузку запроса.
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.respondInvalidInput(c, err)
+		return
+	}
+	period, err := ParseDateRange(c)
+	if err != nil {
+		h.respondInvalidInput(c, err)
+		return
+	}
+	req.DateRange = period
+
+	c.Set(middleware.UserIDKey, req.UserID)
+	h.requestLogger(c).Infof("explaining summary query: UserID: %+v, ServiceName: %+v", req.UserID, req.ServiceName)
+
+	//process business logic for ExplainSummary
+	//Обработка бизнес-логики для ExplainSummary
+	plan, err := h.service.ExplainSummary(c.Request.Context(), &req)
+	if err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, &models.ExplainResponse{Plan: json.RawMessage(plan)})
+}
+
+// FindSubscriptionsByUserPrefix lets admins look up subscriptions from a partial user ID, e.g. copied from a log.
+// FindSubscriptionsByUserPrefix godoc
+// @Summary Find subscriptions by user ID prefix
+// @Description Return a page of subscriptions whose user_id starts with the given prefix (at least 8 hexadecimal characters, admin only)
+// @Tags Admin
+// @Produce json
+// @Security AdminKey
+// @Param user_prefix query string true "User ID prefix" minlength(8) maxlength(36)
+// @Param limit query int false "Maximum number of items to return" default(10) minimum(1) maximum(100)
+// @Param offset query int false "Number of items to skip" default(0) minimum(0)
+// @Success 200 {object} models.ListSubscriptionsResponse
+// @Failure 400 {object} models.ErrorResponse "Bad Request - Invalid prefix or pagination"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized - Missing or invalid admin key"
+// @Failure 403 {object} models.ErrorResponse "Forbidden - Admin api is disabled"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /admin/subscriptions [get]
+func (h *SubscriptionHandler) FindSubscriptionsByUserPrefix(c *gin.Context) {
+
+	var req *models.UserPrefixSearchRequest
+
+	// Bind and validate request payload
+	//Привяжите и проверьте полезную нагрузку запроса.
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.respondInvalidInput(c, err)
+		return
+	}
+	page, err := ParsePagination(c)
+	if err != nil {
+		h.respondInvalidInput(c, err)
+		return
+	}
+	req.Pagination = page
+
+	h.requestLogger(c).Infof("finding subscriptions by user prefix: Prefix: %+v, Limit: %+v, Offset: %+v", req.UserPrefix, req.Limit, req.Offset)
+
+	//process business logic for UserPrefixSearchRequest
+	//Обработка бизнес-логики для UserPrefixSearchRequest
+	total, subs, err := h.service.FindSubscriptionsByUserIDPrefix(c.Request.Context(), req)
+	if err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	formatedSubs := formatSubscriptions(subs)
+
+	paginationMeta := &models.PaginationMeta{Limit: req.Limit, Offset: req.Offset, SortBy: "user_id", Order: "asc", Total: total}
+	c.JSON(http.StatusOK, &models.ListSubscriptionsResponse{Subscriptions: formatedSubs, Meta: paginationMeta})
+}
+
+// NormalizeDates lets admins backfill the first-of-month dates of historical subscriptions.
+// NormalizeDates godoc
+// @Summary Normalize subscription dates
+// @Description Rewrite every start_date and end_date not on the first of its month to it, across all tenants, in batches within one transaction, and return the number of subscriptions rewritten (admin only)
+// @Tags Admin
+// @Produce json
+// @Security AdminKey
+// @Success 200 {object} models.NormalizeDatesResponse
+// @Failure 401 {object} models.ErrorResponse "Unauthorized - Missing or invalid admin key"
+// @Failure 403 {object} models.ErrorResponse "Forbidden - Admin api is disabled"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /admin/subscriptions/normalize-dates [post]
+func (h *SubscriptionHandler) NormalizeDates(c *gin.Context) {
+
+	h.requestLogger(c).Info("normalizing subscription dates")
+
+	//process business logic for NormalizeDates
+	//Обработка бизнес-логики для NormalizeDates
+	normalized, err := h.service.NormalizeDates(c.Request.Context())
+	if err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, &models.NormalizeDatesResponse{Normalized: normalized})
+}
+
+// ListSubscriptionMembers returns a subscription with the family/group plan members linked to it.
+// ListSubscriptionMembers godoc
+// @Summary List family plan members
+// @Description Retrieve the member subscriptions linked to a primary subscription
+// @Tags Subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "Subscription ID, a UUID when UUID_SUBSCRIPTION_IDS is set"
+// @Success 200 {object} models.SubscriptionMembersResponse
+// @Failure 400 {object} models.ErrorResponse "Bad Request - Invalid subscription ID"
+// @Failure 404 {object} models.ErrorResponse "Not Found - Subscription does not exist"
+// @Failure 422 {object} models.ErrorResponse "Unprocessable Entity - Too many rows, narrow the query"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /subscriptions/{id}/members [get]
+func (h *SubscriptionHandler) ListSubscriptionMembers(c *gin.Context) {
+	var req *models.SubscriptionUriIDRequest
+	// Bind and validate uri request payload
+	//Привязка и проверка полезной нагрузки запроса URI
+	if err := c.ShouldBindUri(&req); err != nil {
+		h.respondInvalidInput(c, err)
+		return
+	}
+
+	h.requestLogger(c).Info("listing members of subscription: ", req.ID)
+
+	//process business logic for ListSubscriptionMembers
+	//Обработка бизнес-логики для ListSubscriptionMembers
+	sub, members, err := h.service.ListMembers(c.Request.Context(), req.ID)
+	if err != nil {
+		h.handleServiceError(c, err)
+		return
+	}
+
+	formatedMembers := formatSubscriptions(members)
+
+	c.JSON(http.StatusOK, &models.SubscriptionMembersResponse{Subscription: FormatToSubscriptionResponse(sub), Members: formatedMembers})
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/config"
+	"github.com/cyb3rkh4l1d/subsapi/internal/models"
+	"github.com/cyb3rkh4l1d/subsapi/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+)
+
+type ctxKey struct{}
+
+func TestNewSubscriptionHandlers(t *testing.T) {
+	logger, _ := logtest.NewNullLogger()
+	entry := logrus.NewEntry(logger)
+	svc := service.NewSubscriptionService(nil, &config.Config{}, entry)
+	ctx := context.WithValue(context.Background(), ctxKey{}, "app")
+
+	h := NewSubscriptionHandlers(ctx, entry, svc)
+	if h == nil {
+		t.Fatal("handler is nil")
+	}
+	if h.ctx != ctx || h.Logger != entry || h.service != svc {
+		t.Errorf("handler = %+v, want the context, logger and service it was built with", h)
+	}
+}
+
+func TestEmptyBody(t *testing.T) {
+	h, _ := newTestHandler()
+	router := gin.New()
+	router.POST("/subscriptions", h.CreateSubscription)
+	router.PUT("/subscriptions/:id", h.UpdateSubscription)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		code   string
+	}{
+		{"create empty", http.MethodPost, "/subscriptions", "", "empty_body"},
+		{"create whitespace", http.MethodPost, "/subscriptions", " \n\t ", "empty_body"},
+		{"create empty object", http.MethodPost, "/subscriptions", "{}", "invalid_request_input"},
+		{"update empty", http.MethodPut, "/subscriptions/1", "", "empty_body"},
+		{"update whitespace", http.MethodPut, "/subscriptions/1", "\r\n ", "empty_body"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusBadRequest)
+			}
+			var res models.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+				t.Fatal(err)
+			}
+			if res.Code != tt.code {
+				t.Errorf("code = %q, want %q", res.Code, tt.code)
+			}
+			if tt.code == "invalid_request_input" && res.Details == "" {
+				t.Error("missing fields not reported in details")
+			}
+		})
+	}
+}
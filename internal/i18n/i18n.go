@@ -0,0 +1,118 @@
+package i18n
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/models"
+	"github.com/cyb3rkh4l1d/subsapi/internal/validations"
+)
+
+// AcceptLanguageHeader is the request header selecting the language of error messages.
+// AcceptLanguageHeader — заголовок запроса, выбирающий язык сообщений об ошибках.
+const AcceptLanguageHeader = "Accept-Language"
+
+// DefaultLanguage is the language of the error messages themselves, used for unsupported locales.
+// DefaultLanguage — язык самих сообщений об ошибках, используемый для неподдерживаемых локалей.
+const DefaultLanguage = "en"
+
+// catalogs holds the translated error messages of each supported language, keyed by error code.
+// Codes missing from a catalog keep the English message.
+// catalogs содержит переведенные сообщения об ошибках каждого поддерживаемого языка по коду ошибки.
+// Коды, отсутствующие в каталоге, сохраняют английское сообщение.
+var catalogs = map[string]map[string]string{
+	"ru": {
+		"invalid_service_name":    "необходимо указать название сервиса",
+		"invalid_subscription_id": "неверный ID подписки",
+		"invalid_price":           "цена должна быть положительным целым числом",
+		"price_too_high":          "цена превышает максимальную месячную цену",
+		"description_too_long":    "описание длиннее 500 символов",
+		"invalid_date_format":     "неверный формат даты, ожидается MM-YYYY",
+		"end_date_before_start":   "дата окончания не должна быть раньше даты начала",
+		"invalid_user_id":         "неверный ID пользователя",
+		"empty_user_id":           "ID пользователя пуст",
+		"subscription_exists":     "подписка уже существует",
+		"subscription_not_found":  "подписка не найдена",
+		"service_not_found":       "подписки на сервис не найдены",
+		"invalid_start_date":      "неверный формат start_date, ожидается MM-YYYY",
+		"invalid_end_date":        "неверный формат end_date, ожидается MM-YYYY",
+		"invalid_year":            "год должен быть между 1970 и 2100",
+		"invalid_request_input":   "неверные входные данные запроса",
+		"empty_body":              "требуется тело запроса",
+		"invalid_user_id_prefix":  "неверный префикс ID пользователя, ожидается не менее 8 шестнадцатеричных символов UUID",
+		"parent_not_found":        "родительская подписка не найдена",
+		"parent_is_self":          "подписка не может быть собственной родительской",
+		"parent_cycle":            "родительская подписка образует цикл",
+		"open_ended_extension":    "у подписки нет даты окончания для продления",
+		"timeline_too_long":       "период превышает 1200 месяцев, сузьте from и to",
+		"period_reversed":         "from не должен быть позже to",
+		"query_too_long":          "строка запроса слишком длинная",
+		"unknown_query_param":     "неизвестный параметр запроса",
+		"result_too_large":        "результат превышает максимальное количество строк, сузьте запрос",
+		"too_many_in_flight":      "слишком много одновременных запросов, повторите позже",
+		"offset_too_large":        "смещение превышает максимальную глубину страниц, сузьте фильтры вместо перехода глубже",
+		"invalid_cursor":          "неверный курсор пагинации",
+		"admin_unauthorized":      "требуется авторизация администратора",
+		"admin_api_disabled":      "API администратора отключен",
+		"tenant_unauthorized":     "требуется API-ключ арендатора",
+		"invalid_tenant_id":       "неверный ID арендатора, ожидается от 1 до 64 букв, цифр, '_' или '-'",
+		"internal_error":          "Внутренняя ошибка сервера",
+		"route_not_found":         "маршрут не найден",
+		"service_unavailable":     "Сервис недоступен",
+	},
+}
+
+/*.....................................................................
+
+					Functions/Methods Definations
+
+........................................................................*/
+
+// Language picks the supported language preferred by an Accept-Language header, by quality value and then
+// by order, and DefaultLanguage when none is supported. Regional variants such as ru-RU match their language.
+// Language выбирает поддерживаемый язык, предпочитаемый заголовком Accept-Language, по значению качества, затем
+// по порядку, и DefaultLanguage, если ни один не поддерживается. Региональные варианты, такие как ru-RU, соответствуют своему языку.
+func Language(acceptLanguage string) string {
+	best, bestQuality := DefaultLanguage, 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		lang, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if _, ok := catalogs[lang]; !ok && lang != DefaultLanguage {
+			continue
+		}
+		if quality > bestQuality {
+			best, bestQuality = lang, quality
+		}
+	}
+	return best
+}
+
+// Message returns the message of an error code in a language, fallback when it has no translation.
+// Message возвращает сообщение кода ошибки на языке или fallback, если перевода нет.
+func Message(lang, code, fallback string) string {
+	if message, ok := catalogs[lang][code]; ok {
+		return message
+	}
+	return fallback
+}
+
+// ErrorResponse builds the error envelope of err with its stable code and its message localized for an
+// Accept-Language header. Details are passed through as they are.
+// ErrorResponse формирует конверт ошибки err с ее стабильным кодом и сообщением, локализованным по заголовку
+// Accept-Language. Подробности передаются как есть.
+func ErrorResponse(acceptLanguage string, err error, details ...string) models.ErrorResponse {
+	code := validations.Code(err)
+	return models.ErrorResponse{
+		Code:    code,
+		Error:   Message(Language(acceptLanguage), code, err.Error()),
+		Details: strings.Join(details, "; "),
+	}
+}
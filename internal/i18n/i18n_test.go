@@ -0,0 +1,58 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/validations"
+)
+
+func TestErrorResponse(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		want           string
+	}{
+		{"russian", "ru", "подписка не найдена"},
+		{"russian regional variant", "ru-RU", "подписка не найдена"},
+		{"english", "en", "subscription not found"},
+		{"unsupported locale falls back to english", "de-DE", "subscription not found"},
+		{"missing header falls back to english", "", "subscription not found"},
+		{"preferred by quality", "en;q=0.5, ru;q=0.9", "подписка не найдена"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := ErrorResponse(tt.acceptLanguage, validations.ErrSubscriptionNotFound, "id 42")
+			if res.Code != "subscription_not_found" {
+				t.Errorf("code = %q, want subscription_not_found", res.Code)
+			}
+			if res.Error != tt.want {
+				t.Errorf("error = %q, want %q", res.Error, tt.want)
+			}
+			if res.Details != "id 42" {
+				t.Errorf("details = %q, want them passed through", res.Details)
+			}
+		})
+	}
+}
+
+func TestErrorResponseCodeIsStableAcrossLanguages(t *testing.T) {
+	for _, err := range []error{validations.ErrInvalidPrice, validations.ErrEmptyUserID, validations.ErrInternalServer} {
+		en := ErrorResponse("en", err)
+		ru := ErrorResponse("ru", err)
+		if en.Code == "" || en.Code != ru.Code {
+			t.Errorf("%v: codes %q and %q, want the same non-empty code", err, en.Code, ru.Code)
+		}
+		if en.Error != err.Error() {
+			t.Errorf("%v: english message %q, want the error itself", err, en.Error)
+		}
+		if ru.Error == en.Error {
+			t.Errorf("%v: russian message not translated", err)
+		}
+	}
+}
+
+func TestMessageFallsBackForUntranslatedCodes(t *testing.T) {
+	if got := Message("ru", "no_such_code", "fallback"); got != "fallback" {
+		t.Errorf("Message = %q, want fallback", got)
+	}
+}
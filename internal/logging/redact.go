@@ -0,0 +1,109 @@
+package logging
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// redactedPrefixLength is the number of leading characters of a redacted value kept to correlate log lines.
+// redactedPrefixLength — количество начальных символов скрытого значения, сохраняемых для связи строк журнала.
+const redactedPrefixLength = 8
+
+// UserIDField is the log field carrying user IDs. User IDs are the only UUIDs the service logs,
+// so redacting it also masks every UUID written in log messages, such as dumped subscriptions.
+// UserIDField — поле журнала с ID пользователей. ID пользователей — единственные UUID в журнале сервиса,
+// поэтому его скрытие также маскирует все UUID в сообщениях журнала, например в выведенных подписках.
+const UserIDField = "user_id"
+
+// uuidPattern matches UUIDs in their text form.
+// uuidPattern сопоставляет UUID в текстовой форме.
+var uuidPattern = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+
+// RedactionHook is a logrus hook masking the values of the configured fields in every log entry,
+// keeping their first 8 characters followed by "***". The raw values are also masked in the message.
+// RedactionHook — хук logrus, маскирующий значения настроенных полей в каждой записи журнала,
+// сохраняя их первые 8 символов и "***". Исходные значения также маскируются в сообщении.
+type RedactionHook struct {
+	fields map[string]bool
+}
+
+/*.....................................................................
+
+					Functions/Methods Definations
+
+........................................................................*/
+
+// NewRedactionHook creates a RedactionHook for a comma separated list of field names.
+// It returns nil when the list names no field, leaving the logs unredacted.
+// NewRedactionHook создает RedactionHook для списка имен полей, разделенных запятыми.
+// Возвращает nil, если список не содержит полей, оставляя журнал без скрытия.
+func NewRedactionHook(list string) *RedactionHook {
+	fields := make(map[string]bool)
+	for _, field := range strings.Split(list, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields[field] = true
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return &RedactionHook{fields: fields}
+}
+
+// Levels applies the hook to every log level.
+// Levels применяет хук ко всем уровням журнала.
+func (h *RedactionHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire masks the configured fields of an entry and their raw values in its message,
+// and with user_id redacted every UUID of its message and text fields.
+// Fire маскирует настроенные поля записи и их исходные значения в ее сообщении,
+// а при скрытии user_id — все UUID в ее сообщении и текстовых полях.
+func (h *RedactionHook) Fire(entry *logrus.Entry) error {
+	for field, value := range entry.Data {
+		if !h.fields[field] {
+			continue
+		}
+		raw := fmt.Sprint(value)
+		masked := Mask(raw)
+		entry.Data[field] = masked
+		if raw != "" {
+			entry.Message = strings.ReplaceAll(entry.Message, raw, masked)
+		}
+	}
+	if !h.fields[UserIDField] {
+		return nil
+	}
+	entry.Message = uuidPattern.ReplaceAllStringFunc(entry.Message, Mask)
+	// errors and other text fields may quote a user ID too
+	// ошибки и другие текстовые поля тоже могут содержать ID пользователя
+	for field, value := range entry.Data {
+		var text string
+		switch v := value.(type) {
+		case string:
+			text = v
+		case error:
+			text = v.Error()
+		default:
+			continue
+		}
+		if uuidPattern.MatchString(text) {
+			entry.Data[field] = uuidPattern.ReplaceAllStringFunc(text, Mask)
+		}
+	}
+	return nil
+}
+
+// Mask keeps the first 8 characters of a value followed by "***", or only "***" for shorter values.
+// Mask сохраняет первые 8 символов значения и "***", или только "***" для более коротких значений.
+func Mask(value string) string {
+	runes := []rune(value)
+	if len(runes) <= redactedPrefixLength {
+		return "***"
+	}
+	return string(runes[:redactedPrefixLength]) + "***"
+}
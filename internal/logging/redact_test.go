@@ -0,0 +1,59 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestRedactionHookNeverLogsRawUserID(t *testing.T) {
+	const userID = "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+	const masked = "60601fee***"
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	logger.AddHook(NewRedactionHook("user_id"))
+	// the test hook fires after the redaction hook and records what would be written
+	// тестовый хук срабатывает после хука скрытия и записывает то, что попало бы в журнал
+	recorded := logtest.NewLocal(logger)
+
+	logger.WithField(UserIDField, userID).Infof("listing subscriptions of %s", userID)
+	logger.WithError(fmt.Errorf("user %s: %w", userID, errors.New("not found"))).Error("lookup failed")
+	logger.WithField("subscription", fmt.Sprintf("%+v", struct{ UserID string }{userID})).Info("subscription created")
+	logger.Infof("subscription {UserID:%s Price:400}", strings.ToUpper(userID))
+
+	for _, entry := range recorded.AllEntries() {
+		line, err := entry.String()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(strings.ToLower(line), userID) {
+			t.Errorf("raw user id logged: %s", line)
+		}
+	}
+	if got := recorded.AllEntries()[0]; got.Data[UserIDField] != masked || !strings.Contains(got.Message, masked) {
+		t.Errorf("first entry = %q with %v, want the user id masked as %s", got.Message, got.Data, masked)
+	}
+}
+
+func TestNewRedactionHook(t *testing.T) {
+	if hook := NewRedactionHook(" , "); hook != nil {
+		t.Errorf("hook for no field = %+v, want nil", hook)
+	}
+	hook := NewRedactionHook("email, user_id")
+	entry := &logrus.Entry{Data: logrus.Fields{"email": "someone@example.com", "service_name": "Netflix"}, Message: "sent to someone@example.com"}
+	if err := hook.Fire(entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.Data["email"] != "someone@***" || entry.Data["service_name"] != "Netflix" || entry.Message != "sent to someone@***" {
+		t.Errorf("entry = %q with %v, want only the email masked", entry.Message, entry.Data)
+	}
+	if got := Mask("short"); got != "***" {
+		t.Errorf("Mask(short) = %q, want ***", got)
+	}
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/i18n"
+	"github.com/cyb3rkh4l1d/subsapi/internal/validations"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminKeyHeader is the HTTP header carrying the admin API key.
+// AdminKeyHeader — HTTP-заголовок, содержащий ключ API администратора.
+const AdminKeyHeader = "X-Admin-Key"
+
+// AdminAuth guards admin-only routes with a shared API key. When no key is configured
+// every admin request is rejected, so admin endpoints are never exposed by accident.
+// AdminAuth защищает маршруты администратора общим ключом API. Если ключ не настроен,
+// все запросы администратора отклоняются, чтобы эндпоинты не были открыты случайно.
+func AdminAuth(apiKey string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if apiKey == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, i18n.ErrorResponse(c.GetHeader(i18n.AcceptLanguageHeader), validations.ErrAdminAPIDisabled))
+			return
+		}
+
+		provided := c.GetHeader(AdminKeyHeader)
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(apiKey)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, i18n.ErrorResponse(c.GetHeader(i18n.AcceptLanguageHeader), validations.ErrAdminUnauthorized))
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ForwardedProtoHeader is the header a TLS terminating proxy sets to the scheme of the original request.
+// ForwardedProtoHeader — заголовок, в котором прокси, завершающий TLS, передает схему исходного запроса.
+const ForwardedProtoHeader = "X-Forwarded-Proto"
+
+// ParseTrustedProxies parses a comma separated list of IPs and CIDR ranges.
+// ParseTrustedProxies разбирает список IP-адресов и CIDR-диапазонов, разделенных запятыми.
+func ParseTrustedProxies(list string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, err
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// EnforceHTTPS sets Strict-Transport-Security on HTTPS responses when hsts is enabled and,
+// when redirect is enabled, permanently redirects plain HTTP requests to HTTPS.
+// X-Forwarded-Proto is only honoured from trusted proxies, so clients can't spoof the scheme.
+// EnforceHTTPS устанавливает Strict-Transport-Security в HTTPS-ответах, если включен hsts, и,
+// если включен redirect, навсегда перенаправляет HTTP-запросы на HTTPS.
+// X-Forwarded-Proto учитывается только от доверенных прокси, чтобы клиенты не могли подменить схему.
+func EnforceHTTPS(hsts bool, hstsMaxAge int, redirect bool, trustedProxies []netip.Prefix) gin.HandlerFunc {
+	hstsValue := fmt.Sprintf("max-age=%d; includeSubDomains", hstsMaxAge)
+
+	return func(c *gin.Context) {
+		secure := c.Request.TLS != nil
+		if !secure && fromTrustedProxy(c, trustedProxies) {
+			proto := strings.ToLower(c.GetHeader(ForwardedProtoHeader))
+			if redirect && proto == "http" {
+				c.Redirect(http.StatusPermanentRedirect, "https://"+c.Request.Host+c.Request.URL.RequestURI())
+				c.Abort()
+				return
+			}
+			secure = proto == "https"
+		}
+
+		if hsts && secure {
+			c.Header("Strict-Transport-Security", hstsValue)
+		}
+		c.Next()
+	}
+}
+
+// fromTrustedProxy reports whether the direct peer of the request is one of the trusted proxies.
+// fromTrustedProxy сообщает, является ли непосредственный отправитель запроса доверенным прокси.
+func fromTrustedProxy(c *gin.Context, trustedProxies []netip.Prefix) bool {
+	addr, err := netip.ParseAddr(c.RemoteIP())
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestEnforceHTTPS(t *testing.T) {
+	trusted, err := ParseTrustedProxies("10.0.0.0/8, 192.168.1.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		peer     string
+		proto    string
+		tls      bool
+		status   int
+		location string
+		hsts     bool
+	}{
+		{"direct TLS", "203.0.113.7:5000", "", true, http.StatusOK, "", true},
+		{"trusted proxy over https", "10.1.2.3:5000", "https", false, http.StatusOK, "", true},
+		{"trusted proxy over http", "192.168.1.1:5000", "http", false, http.StatusPermanentRedirect, "https://example.com/api/v1/subscriptions?limit=1", false},
+		{"untrusted peer claiming https", "203.0.113.7:5000", "https", false, http.StatusOK, "", false},
+		{"untrusted peer claiming http", "203.0.113.7:5000", "http", false, http.StatusOK, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			engine := gin.New()
+			engine.Use(EnforceHTTPS(true, 600, true, trusted))
+			engine.GET("/api/v1/subscriptions", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(http.MethodGet, "http://example.com/api/v1/subscriptions?limit=1", nil)
+			req.RemoteAddr = tt.peer
+			if tt.proto != "" {
+				req.Header.Set(ForwardedProtoHeader, tt.proto)
+			}
+			if tt.tls {
+				req.TLS = &tls.ConnectionState{}
+			}
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+
+			if w.Code != tt.status || w.Header().Get("Location") != tt.location {
+				t.Errorf("got %d to %q, want %d to %q", w.Code, w.Header().Get("Location"), tt.status, tt.location)
+			}
+			if got := w.Header().Get("Strict-Transport-Security"); (got != "") != tt.hsts {
+				t.Errorf("Strict-Transport-Security = %q, want it set: %v", got, tt.hsts)
+			} else if tt.hsts && got != "max-age=600; includeSubDomains" {
+				t.Errorf("Strict-Transport-Security = %q", got)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxiesRejectsInvalidEntries(t *testing.T) {
+	for _, list := range []string{"10.0.0.0/33", "not-an-ip", "10.0.0.1, 300.0.0.1"} {
+		if _, err := ParseTrustedProxies(list); err == nil {
+			t.Errorf("ParseTrustedProxies(%q) accepted an invalid entry", list)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/i18n"
+	"github.com/cyb3rkh4l1d/subsapi/internal/validations"
+	"github.com/gin-gonic/gin"
+)
+
+// InFlightRetryAfter is the delay, in seconds, suggested to clients rejected by the in-flight limit.
+// InFlightRetryAfter — задержка в секундах, предлагаемая клиентам, отклоненным ограничением одновременных запросов.
+const InFlightRetryAfter = 1
+
+// InFlightLimiter bounds the number of requests processed at once with a semaphore,
+// so a burst of traffic can't queue unboundedly on the database pool.
+// InFlightLimiter ограничивает количество одновременно обрабатываемых запросов семафором,
+// чтобы всплеск трафика не выстраивался в неограниченную очередь к пулу базы данных.
+type InFlightLimiter struct {
+	slots    chan struct{}
+	inFlight atomic.Int64
+}
+
+// NewInFlightLimiter creates a limiter admitting at most maxInFlight concurrent requests,
+// 0 disables the limit while still counting the requests in flight.
+// NewInFlightLimiter создает ограничитель, допускающий не более maxInFlight одновременных запросов,
+// 0 отключает ограничение, но запросы в обработке продолжают подсчитываться.
+func NewInFlightLimiter(maxInFlight int) *InFlightLimiter {
+	limiter := &InFlightLimiter{}
+	if maxInFlight > 0 {
+		limiter.slots = make(chan struct{}, maxInFlight)
+	}
+	return limiter
+}
+
+// InFlight returns the number of requests currently being processed.
+// InFlight возвращает количество запросов, обрабатываемых в данный момент.
+func (l *InFlightLimiter) InFlight() int64 {
+	return l.inFlight.Load()
+}
+
+// Limit returns the maximum number of concurrent requests, 0 when unlimited.
+// Limit возвращает максимальное количество одновременных запросов, 0 — без ограничения.
+func (l *InFlightLimiter) Limit() int {
+	return cap(l.slots)
+}
+
+// Handler admits a request when a slot is free and rejects it otherwise with 503 Service Unavailable
+// and a Retry-After header, instead of making it wait. The exempt paths, such as the health probes,
+// are never rejected nor counted, so an overloaded instance still answers its orchestrator.
+// Handler допускает запрос, если есть свободный слот, иначе отклоняет его со статусом 503 Service Unavailable
+// и заголовком Retry-After, не заставляя ждать. Исключенные пути, например пробы работоспособности,
+// никогда не отклоняются и не подсчитываются, чтобы перегруженный экземпляр отвечал оркестратору.
+func (l *InFlightLimiter) Handler(exempt ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if slices.Contains(exempt, c.FullPath()) {
+			c.Next()
+			return
+		}
+
+		if l.slots != nil {
+			select {
+			case l.slots <- struct{}{}:
+				defer func() { <-l.slots }()
+			default:
+				c.Header("Retry-After", strconv.Itoa(InFlightRetryAfter))
+				c.AbortWithStatusJSON(http.StatusServiceUnavailable, i18n.ErrorResponse(c.GetHeader(i18n.AcceptLanguageHeader), validations.ErrTooManyInFlight))
+				return
+			}
+		}
+
+		l.inFlight.Add(1)
+		defer l.inFlight.Add(-1)
+		c.Next()
+	}
+}
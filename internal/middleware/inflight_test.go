@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestInFlightLimiter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limiter := NewInFlightLimiter(2)
+	engine := gin.New()
+	engine.Use(limiter.Handler("/healthz"))
+
+	entered, release := make(chan struct{}), make(chan struct{})
+	engine.GET("/slow", func(c *gin.Context) {
+		entered <- struct{}{}
+		<-release
+		c.Status(http.StatusOK)
+	})
+	engine.GET("/healthz", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	// saturate the limiter with requests held by the handler
+	// заполнить ограничитель запросами, удерживаемыми обработчиком
+	var wg sync.WaitGroup
+	for range limiter.Limit() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+		}()
+		<-entered
+	}
+	if got := limiter.InFlight(); got != 2 {
+		t.Errorf("in flight = %d, want 2", got)
+	}
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != "1" {
+		t.Errorf("saturated: got %d with Retry-After %q, want 503 with 1", w.Code, w.Header().Get("Retry-After"))
+	}
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("exempt path while saturated: status = %d, want 200", w.Code)
+	}
+
+	close(release)
+	wg.Wait()
+	if got := limiter.InFlight(); got != 0 {
+		t.Errorf("in flight after release = %d, want 0", got)
+	}
+	w = httptest.NewRecorder()
+	go func() { <-entered }()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("after release: status = %d, want 200", w.Code)
+	}
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/i18n"
+	"github.com/cyb3rkh4l1d/subsapi/internal/validations"
+	"github.com/gin-gonic/gin"
+)
+
+// MaxQueryLength rejects requests whose raw query string is longer than maxLength bytes
+// with 414 URI Too Long, guarding the filter endpoints against pathological query strings.
+// A maxLength of 0 disables the check.
+// MaxQueryLength отклоняет запросы, строка параметров которых длиннее maxLength байт,
+// со статусом 414 URI Too Long, защищая эндпоинты с фильтрами от чрезмерных строк запроса.
+// Значение maxLength, равное 0, отключает проверку.
+func MaxQueryLength(maxLength int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if maxLength > 0 && len(c.Request.URL.RawQuery) > maxLength {
+			c.AbortWithStatusJSON(http.StatusRequestURITooLong, i18n.ErrorResponse(c.GetHeader(i18n.AcceptLanguageHeader), validations.ErrQueryTooLong))
+			return
+		}
+		c.Next()
+	}
+}
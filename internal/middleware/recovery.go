@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/i18n"
+	"github.com/cyb3rkh4l1d/subsapi/internal/validations"
+	"github.com/gin-gonic/gin"
+)
+
+// Recovery recovers from panics like gin.Recovery, logging the stack, but answers with the JSON error envelope
+// every other failure uses instead of an empty 500.
+// Recovery восстанавливается после паник как gin.Recovery, записывая стек в журнал, но отвечает JSON-конвертом
+// ошибки, общим для всех остальных сбоев, вместо пустого ответа 500.
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, _ any) {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, i18n.ErrorResponse(c.GetHeader(i18n.AcceptLanguageHeader), validations.ErrInternalServer))
+	})
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	// RequestIDHeader is the HTTP header carrying the request identifier.
+	// RequestIDHeader — HTTP-заголовок, содержащий идентификатор запроса.
+	RequestIDHeader = "X-Request-ID"
+	// RequestIDKey is the gin context key under which the request identifier is stored.
+	// RequestIDKey — ключ контекста gin, под которым хранится идентификатор запроса.
+	RequestIDKey = "request_id"
+	// UserIDKey is the gin context key under which handlers store the user ID of the request.
+	// UserIDKey — ключ контекста gin, под которым обработчики сохраняют ID пользователя запроса.
+	UserIDKey = "user_id"
+
+	// maxRequestIDLength bounds client supplied identifiers so they can't flood the logs.
+	// maxRequestIDLength ограничивает идентификаторы клиента, чтобы они не засоряли журналы.
+	maxRequestIDLength = 64
+)
+
+// RequestID assigns every request an identifier, reusing the incoming X-Request-ID header when present,
+// and echoes it back in the response so log entries can be correlated with client calls.
+// RequestID присваивает каждому запросу идентификатор, повторно используя входящий заголовок X-Request-ID,
+// и возвращает его в ответе, чтобы записи журнала можно было сопоставить с вызовами клиента.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = uuid.NewString()
+		}
+		c.Set(RequestIDKey, requestID)
+		c.Header(RequestIDHeader, requestID)
+		c.Next()
+	}
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/i18n"
+	"github.com/cyb3rkh4l1d/subsapi/internal/validations"
+	"github.com/gin-gonic/gin"
+)
+
+/*.....................................................................
+
+					Functions/Methods Definations
+
+........................................................................*/
+
+// QueryParams returns the query parameter names bound by the form tags of the given request structs,
+// including those of embedded structs, even skipped by the binding, so an allow-list can't drift from what the handler binds.
+// QueryParams возвращает имена параметров запроса, привязываемых тегами form указанных структур запроса,
+// включая встроенные структуры, даже пропускаемые привязкой, чтобы список разрешенных параметров не расходился с привязкой обработчика.
+func QueryParams(requests ...any) []string {
+	var params []string
+	var collect func(t reflect.Type)
+	collect = func(t reflect.Type) {
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return
+		}
+		for i := range t.NumField() {
+			field := t.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("form"), ",")
+			switch {
+			// embedded structs tagged "-", such as Pagination, are read by parse helpers rather than the binding
+			// встроенные структуры с тегом "-", например Pagination, читаются функциями разбора, а не привязкой
+			case field.Anonymous && (name == "" || name == "-"):
+				collect(field.Type)
+			case name == "-":
+			case name != "":
+				params = append(params, name)
+			}
+		}
+	}
+	for _, request := range requests {
+		collect(reflect.TypeOf(request))
+	}
+	return params
+}
+
+// AllowQueryParams rejects requests carrying query parameters outside allowed with 400, naming the unknown ones
+// in details, so a misspelled filter fails instead of silently widening the result.
+// AllowQueryParams отклоняет запросы с параметрами вне allowed статусом 400, перечисляя неизвестные в подробностях,
+// чтобы опечатка в фильтре приводила к ошибке, а не к незаметному расширению результата.
+func AllowQueryParams(allowed ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var unknown []string
+		for param := range c.Request.URL.Query() {
+			if !slices.Contains(allowed, param) {
+				unknown = append(unknown, param)
+			}
+		}
+		if len(unknown) > 0 {
+			slices.Sort(unknown)
+			c.AbortWithStatusJSON(http.StatusBadRequest, i18n.ErrorResponse(
+				c.GetHeader(i18n.AcceptLanguageHeader), validations.ErrUnknownQueryParam, strings.Join(unknown, ", "),
+			))
+			return
+		}
+		c.Next()
+	}
+}
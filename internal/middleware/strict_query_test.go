@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+type embeddedQuery struct {
+	Limit int `form:"limit"`
+}
+
+type parsedQuery struct {
+	Offset int `form:"offset"`
+}
+
+type testQuery struct {
+	embeddedQuery
+	parsedQuery `form:"-"`
+	UserID      string `form:"user_id,omitempty"`
+	Decoded     uint   `form:"-"`
+	Ignored     string
+}
+
+func TestQueryParams(t *testing.T) {
+	got := QueryParams(testQuery{}, &struct {
+		From string `form:"from"`
+	}{})
+	want := []string{"limit", "offset", "user_id", "from"}
+	if !slices.Equal(got, want) {
+		t.Errorf("QueryParams = %v, want %v", got, want)
+	}
+}
+
+func TestAllowQueryParams(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/strict", AllowQueryParams("limit", "user_id"), func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/lenient", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		name    string
+		target  string
+		status  int
+		details string
+	}{
+		{"strict known", "/strict?limit=5&user_id=u", http.StatusOK, ""},
+		{"strict no query", "/strict", http.StatusOK, ""},
+		{"strict unknown", "/strict?limit=5&user_di=u&zeta=1", http.StatusBadRequest, "user_di, zeta"},
+		{"lenient unknown", "/lenient?limit=5&user_di=u", http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if tt.status != http.StatusBadRequest {
+				return
+			}
+			var res models.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+				t.Fatal(err)
+			}
+			if res.Code != "unknown_query_param" || res.Details != tt.details {
+				t.Errorf("got %+v, want code unknown_query_param with details %q", res, tt.details)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/i18n"
+	"github.com/cyb3rkh4l1d/subsapi/internal/models"
+	"github.com/cyb3rkh4l1d/subsapi/internal/validations"
+	"github.com/gin-gonic/gin"
+)
+
+// SubscriptionIDParam is the path parameter carrying the subscription ID.
+// SubscriptionIDParam — параметр пути, содержащий ID подписки.
+const SubscriptionIDParam = "id"
+
+// ValidateSubscriptionID rejects requests whose :id path parameter isn't a positive integer,
+// so every id-based route of a group answers a bad id the same way.
+// ValidateSubscriptionID отклоняет запросы, параметр пути :id которых не является положительным целым числом,
+// чтобы все маршруты группы, основанные на id, одинаково отвечали на неверный id.
+func ValidateSubscriptionID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, err := validations.ValidateSubscriptionID(c.Param(SubscriptionIDParam)); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, i18n.ErrorResponse(
+				c.GetHeader(i18n.AcceptLanguageHeader), validations.ErrInvalidRequestInput, err.Error(),
+			))
+			return
+		}
+		c.Next()
+	}
+}
+
+// ResolveSubscriptionID replaces ValidateSubscriptionID when subscriptions are exposed by UUID: it rejects an :id
+// that isn't a UUID, answers 404 for an unknown one and rewrites it to the primary key, so that the handlers
+// of the group keep binding an integer ID.
+// ResolveSubscriptionID заменяет ValidateSubscriptionID, когда подписки доступны по UUID: отклоняет :id,
+// не являющийся UUID, отвечает 404 на неизвестный и заменяет его первичным ключом, чтобы обработчики
+// группы по-прежнему привязывали целочисленный ID.
+func ResolveSubscriptionID(resolve func(ctx context.Context, id models.SubscriptionID) (uint, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		lang := c.GetHeader(i18n.AcceptLanguageHeader)
+		id, err := resolve(c.Request.Context(), models.SubscriptionID(c.Param(SubscriptionIDParam)))
+		switch {
+		case errors.Is(err, validations.ErrInvalidSubscriptionID):
+			c.AbortWithStatusJSON(http.StatusBadRequest, i18n.ErrorResponse(lang, validations.ErrInvalidRequestInput, err.Error()))
+			return
+		case errors.Is(err, validations.ErrSubscriptionNotFound):
+			c.AbortWithStatusJSON(http.StatusNotFound, i18n.ErrorResponse(lang, validations.ErrSubscriptionNotFound))
+			return
+		case err != nil:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, i18n.ErrorResponse(lang, validations.ErrInternalServer))
+			return
+		}
+
+		for i := range c.Params {
+			if c.Params[i].Key == SubscriptionIDParam {
+				c.Params[i].Value = strconv.FormatUint(uint64(id), 10)
+			}
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/i18n"
+	"github.com/cyb3rkh4l1d/subsapi/internal/tenancy"
+	"github.com/cyb3rkh4l1d/subsapi/internal/validations"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// TenantHeader is the HTTP header naming the tenant when no tenant API keys are configured.
+	// TenantHeader — HTTP-заголовок с арендатором, если ключи API арендаторов не настроены.
+	TenantHeader = "X-Tenant-ID"
+	// TenantAPIKeyHeader is the HTTP header carrying a tenant API key.
+	// TenantAPIKeyHeader — HTTP-заголовок, содержащий ключ API арендатора.
+	TenantAPIKeyHeader = "X-API-Key"
+	// TenantKey is the gin context key under which the tenant of the request is stored.
+	// TenantKey — ключ контекста gin, под которым хранится арендатор запроса.
+	TenantKey = "tenant_id"
+)
+
+// tenantIDPattern restricts tenant identifiers to short slugs, matching the tenant_id column size.
+// tenantIDPattern ограничивает идентификаторы арендаторов короткими slug, по размеру столбца tenant_id.
+var tenantIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// ParseTenantAPIKeys parses a comma separated list of key:tenant pairs.
+// ParseTenantAPIKeys разбирает список пар ключ:арендатор, разделенных запятыми.
+func ParseTenantAPIKeys(list string) (map[string]string, error) {
+	keys := make(map[string]string)
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		key, tenantID, ok := strings.Cut(entry, ":")
+		if !ok || key == "" || !tenantIDPattern.MatchString(tenantID) {
+			return nil, validations.ErrInvalidConfigValue
+		}
+		keys[key] = tenantID
+	}
+	return keys, nil
+}
+
+// Tenant resolves the tenant of every request and scopes its context to it, so the repository
+// only reads and writes that tenant's rows. With API keys the tenant comes from X-API-Key,
+// otherwise from X-Tenant-ID, which must then be set by a trusted gateway.
+// Tenant определяет арендатора каждого запроса и ограничивает им контекст, чтобы репозиторий
+// читал и записывал только строки этого арендатора. С ключами API арендатор берется из X-API-Key,
+// иначе из X-Tenant-ID, который в этом случае должен выставлять доверенный шлюз.
+func Tenant(apiKeys map[string]string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var tenantID string
+		if len(apiKeys) > 0 {
+			tenantID = lookupTenantAPIKey(apiKeys, c.GetHeader(TenantAPIKeyHeader))
+			if tenantID == "" {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, i18n.ErrorResponse(c.GetHeader(i18n.AcceptLanguageHeader), validations.ErrTenantUnauthorized))
+				return
+			}
+		} else {
+			tenantID = c.GetHeader(TenantHeader)
+			if !tenantIDPattern.MatchString(tenantID) {
+				c.AbortWithStatusJSON(http.StatusBadRequest, i18n.ErrorResponse(c.GetHeader(i18n.AcceptLanguageHeader), validations.ErrInvalidTenantID))
+				return
+			}
+		}
+
+		c.Set(TenantKey, tenantID)
+		c.Request = c.Request.WithContext(tenancy.WithTenant(c.Request.Context(), tenantID))
+		c.Next()
+	}
+}
+
+// lookupTenantAPIKey returns the tenant of the provided key, comparing keys in constant time.
+// lookupTenantAPIKey возвращает арендатора переданного ключа, сравнивая ключи за постоянное время.
+func lookupTenantAPIKey(apiKeys map[string]string, provided string) string {
+	var tenantID string
+	for key, tenant := range apiKeys {
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(key)) == 1 {
+			tenantID = tenant
+		}
+	}
+	return tenantID
+}
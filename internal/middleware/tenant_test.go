@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/tenancy"
+	"github.com/gin-gonic/gin"
+)
+
+func TestTenant(t *testing.T) {
+	apiKeys, err := ParseTenantAPIKeys("key-acme:acme, key-globex:globex")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		apiKeys map[string]string
+		header  string
+		value   string
+		status  int
+		tenant  string
+	}{
+		{"header tenant", nil, TenantHeader, "acme", http.StatusOK, "acme"},
+		{"missing header", nil, "", "", http.StatusBadRequest, ""},
+		{"invalid header", nil, TenantHeader, "acme;drop", http.StatusBadRequest, ""},
+		{"api key tenant", apiKeys, TenantAPIKeyHeader, "key-globex", http.StatusOK, "globex"},
+		{"unknown api key", apiKeys, TenantAPIKeyHeader, "key-initech", http.StatusUnauthorized, ""},
+		{"header ignored with api keys", apiKeys, TenantHeader, "acme", http.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			engine := gin.New()
+			engine.Use(Tenant(tt.apiKeys))
+			var tenant string
+			engine.GET("/api/v1/subscriptions", func(c *gin.Context) {
+				tenant, _ = tenancy.FromContext(c.Request.Context())
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/subscriptions", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+
+			if w.Code != tt.status || tenant != tt.tenant {
+				t.Errorf("got %d for tenant %q, want %d for %q", w.Code, tenant, tt.status, tt.tenant)
+			}
+		})
+	}
+}
+
+func TestParseTenantAPIKeysRejectsInvalidEntries(t *testing.T) {
+	for _, list := range []string{"key-acme", ":acme", "key-acme:", "key-acme:acme corp"} {
+		if _, err := ParseTenantAPIKeys(list); err == nil {
+			t.Errorf("ParseTenantAPIKeys(%q) accepted an invalid entry", list)
+		}
+	}
+}
@@ -1,66 +1,440 @@
 package models
 
 import (
+	"encoding/json"
+	"strconv"
 	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Subscription statuses derived from the start and end dates compared to the current month.
+// Статусы подписки, определяемые сравнением дат начала и окончания с текущим месяцем.
+const (
+	StatusActive   = "active"
+	StatusUpcoming = "upcoming"
+	StatusExpired  = "expired"
 )
 
 // Subscription represents a subscription record in the database.
 // Maps directly to the 'subscriptions' table in PostgreSQL with GORM annotations.
-// Indexes: Primary key (ID), composite index on (UserID, ServiceName).
+// Indexes: Primary key (ID), composite index on (UserID, ServiceName), index on ParentID,
+// and (user_id, LOWER(service_name), start_date) for case-insensitive lookups, created by migration 00008.
+// ParentID links a family/group plan member to its primary subscription.
+// ReminderSentAt records when the expiry reminder was sent, it is reset when the end_date changes.
+// TenantID isolates the subscriptions of each tenant when multi-tenancy is enabled, empty otherwise.
+// Description is an optional free-text note on why the user has the subscription.
+// PublicID is the UUID the subscription is exposed under instead of ID when UUID subscription IDs are enabled,
+// added by migration 00009.
+// CreatedAt and UpdatedAt are the times of the creation and the last modification of the record, added by
+// migration 00010; rows older than it report the time of the migration.
 // Subscription представляет собой запись о подписке в базе данных.
 // Сопоставляется напрямую с таблицей 'subscriptions' в PostgreSQL с использованием аннотаций GORM.
-// Индексы: первичный ключ (ID), составной индекс по (UserID, ServiceName).
+// Индексы: первичный ключ (ID), составной индекс по (UserID, ServiceName), индекс по ParentID,
+// и (user_id, LOWER(service_name), start_date) для регистронезависимых поисков, создается миграцией 00008.
+// ParentID связывает участника семейного/группового плана с его основной подпиской.
+// ReminderSentAt хранит время отправки напоминания об окончании, сбрасывается при изменении end_date.
+// TenantID изолирует подписки каждого арендатора при включенной мультиарендности, иначе пуст.
+// Description — необязательная текстовая заметка о том, зачем пользователю подписка.
+// PublicID — UUID, под которым подписка доступна вместо ID при включенных UUID-идентификаторах подписок,
+// добавляется миграцией 00009.
+// CreatedAt и UpdatedAt — время создания и последнего изменения записи, добавлены миграцией 00010;
+// строки старше нее сообщают время миграции.
 type Subscription struct {
-	ID          uint       `gorm:"primaryKey" json:"id"`
-	UserID      string     `gorm:"type:uuid;not null;index:idx_summary_service,priority:1" json:"user_id" example:"a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"`
-	ServiceName string     `gorm:"type:varchar(100);not null;index:idx_summary_service,priority:2" json:"service_name" example:"Yandex Plus"`
-	Price       int        `gorm:"not null" json:"price" example:"400"`
-	StartDate   time.Time  `gorm:"type:date;not null" json:"start_date"`
-	EndDate     *time.Time `gorm:"type:date" json:"end_date" binding:"omitempty"`
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	UserID         string     `gorm:"type:uuid;not null;index:idx_summary_service,priority:1" json:"user_id" example:"a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"`
+	ServiceName    string     `gorm:"type:varchar(100);not null;index:idx_summary_service,priority:2" json:"service_name" example:"Yandex Plus"`
+	Price          int        `gorm:"not null" json:"price" example:"400"`
+	StartDate      time.Time  `gorm:"type:date;not null" json:"start_date"`
+	EndDate        *time.Time `gorm:"type:date" json:"end_date" binding:"omitempty"`
+	ParentID       *uint      `gorm:"index" json:"parent_id"`
+	ReminderSentAt *time.Time `gorm:"type:timestamptz" json:"-"`
+	TenantID       string     `gorm:"type:varchar(64);not null;default:'';index" json:"-"`
+	Description    string     `gorm:"type:varchar(500);not null;default:''" json:"description"`
+	// Upserted marks the subscriptions created with upsert=true, unique per user, service and start month
+	// through the partial index of migration 00007
+	// Upserted помечает подписки, созданные с upsert=true, уникальные для пользователя, сервиса и месяца начала
+	// благодаря частичному индексу миграции 00007
+	Upserted bool   `gorm:"not null;default:false" json:"-"`
+	PublicID string `gorm:"type:uuid;not null;uniqueIndex:idx_subscriptions_public_id" json:"-"`
+	// ParentPublicID is the PublicID of the parent, loaded by the service when UUID subscription IDs are enabled
+	// ParentPublicID — PublicID родителя, загружаемый сервисом при включенных UUID-идентификаторах подписок
+	ParentPublicID *string   `gorm:"-" json:"-"`
+	CreatedAt      time.Time `gorm:"type:timestamptz;not null;default:CURRENT_TIMESTAMP;autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time `gorm:"type:timestamptz;not null;default:CURRENT_TIMESTAMP;autoUpdateTime" json:"updated_at"`
+}
+
+// BeforeCreate generates the public ID of a new subscription, whatever the ID mode, so that
+// a deployment can switch to UUID subscription IDs without backfilling
+// BeforeCreate генерирует публичный ID новой подписки в любом режиме идентификаторов, чтобы
+// развертывание могло перейти на UUID-идентификаторы подписок без заполнения существующих строк
+func (s *Subscription) BeforeCreate(*gorm.DB) error {
+	if s.PublicID == "" {
+		s.PublicID = uuid.NewString()
+	}
+	return nil
+}
+
+// ExposedID returns the ID the subscription is known by in the API.
+// ExposedID возвращает ID, под которым подписка известна в API.
+func (s *Subscription) ExposedID() SubscriptionID {
+	if uuidSubscriptionIDs {
+		return SubscriptionID(s.PublicID)
+	}
+	return SubscriptionID(strconv.FormatUint(uint64(s.ID), 10))
+}
+
+// ExposedParentID returns the ID the parent of the subscription is known by in the API, nil without a parent.
+// ExposedParentID возвращает ID, под которым родитель подписки известен в API, nil без родителя.
+func (s *Subscription) ExposedParentID() *SubscriptionID {
+	if s.ParentID == nil {
+		return nil
+	}
+	id := SubscriptionID(strconv.FormatUint(uint64(*s.ParentID), 10))
+	if uuidSubscriptionIDs {
+		if s.ParentPublicID == nil {
+			return nil
+		}
+		id = SubscriptionID(*s.ParentPublicID)
+	}
+	return &id
+}
+
+// uuidSubscriptionIDs exposes subscriptions under their PublicID instead of their integer primary key.
+// uuidSubscriptionIDs открывает подписки под их PublicID вместо целочисленного первичного ключа.
+var uuidSubscriptionIDs bool
+
+// SetUUIDSubscriptionIDs configures whether subscriptions are exposed and looked up by UUID.
+// SetUUIDSubscriptionIDs настраивает, доступны ли подписки и ищутся ли они по UUID.
+func SetUUIDSubscriptionIDs(enabled bool) {
+	uuidSubscriptionIDs = enabled
+}
+
+// UUIDSubscriptionIDs reports whether subscriptions are exposed and looked up by UUID.
+// UUIDSubscriptionIDs сообщает, доступны ли подписки и ищутся ли они по UUID.
+func UUIDSubscriptionIDs() bool {
+	return uuidSubscriptionIDs
+}
+
+// SubscriptionID is a subscription ID as exposed by the API: the integer primary key rendered as a JSON number,
+// or the UUID public ID rendered as a JSON string when UUID subscription IDs are enabled.
+// Both forms are accepted on input.
+// SubscriptionID — ID подписки в том виде, в котором его открывает API: целочисленный первичный ключ в виде
+// JSON-числа или публичный UUID в виде JSON-строки при включенных UUID-идентификаторах подписок.
+// На входе принимаются обе формы.
+type SubscriptionID string
+
+// MarshalJSON renders integer IDs as numbers so that clients of integer deployments keep their payloads.
+// MarshalJSON выводит целочисленные ID как числа, чтобы клиенты развертываний с целыми ID сохранили свои данные.
+func (id SubscriptionID) MarshalJSON() ([]byte, error) {
+	if _, err := strconv.ParseUint(string(id), 10, 64); err == nil {
+		return []byte(id), nil
+	}
+	return json.Marshal(string(id))
+}
+
+// UnmarshalJSON accepts both a JSON number and a JSON string.
+// UnmarshalJSON принимает как JSON-число, так и JSON-строку.
+func (id *SubscriptionID) UnmarshalJSON(data []byte) error {
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err == nil {
+		*id = SubscriptionID(number)
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	*id = SubscriptionID(text)
+	return nil
 }
 
 // @Description Defines the request body for creating a new subscription.
 // Определяет тело запроса для создания новой подписки.
 type CreateSubscriptionRequest struct {
-	ServiceName string `json:"service_name" binding:"required,max=15"`
-	Price       int    `json:"price" binding:"required,gt=0"`
-	UserID      string `json:"user_id" binding:"required,uuid"`
-	StartDate   string `json:"start_date" binding:"required"`
-	EndDate     string `json:"end_date,omitempty"`
+	ServiceName string          `json:"service_name" binding:"required,max=15"`
+	Price       int             `json:"price" binding:"required,gt=0"`
+	UserID      string          `json:"user_id" binding:"required,uuid"`
+	StartDate   string          `json:"start_date" binding:"required"`
+	EndDate     string          `json:"end_date,omitempty"`
+	ParentID    *SubscriptionID `json:"parent_id,omitempty" binding:"omitempty,min=1" swaggertype:"string"`
+	Description string          `json:"description,omitempty"`
+}
+
+// @Description Defines the request query options of a subscription creation.
+// Определяет параметры запроса при создании подписки.
+type CreateSubscriptionQuery struct {
+	// Upsert replaces the subscription of the same user and service starting the same month instead of adding one
+	// Upsert заменяет подписку того же пользователя на тот же сервис, начинающуюся в том же месяце, вместо добавления новой
+	Upsert bool `form:"upsert"`
+}
+
+// @Description Defines the request body for validating many subscriptions without saving them.
+// Определяет тело запроса для проверки нескольких подписок без их сохранения.
+type ValidateBatchRequest struct {
+	Subscriptions []CreateSubscriptionRequest `json:"subscriptions" binding:"required,min=1,max=100"`
+}
+
+// @Description Defines the validation outcome of a single item of a batch.
+// Определяет результат проверки одного элемента пакета.
+type BatchValidationResult struct {
+	Index        int                   `json:"index"`
+	Valid        bool                  `json:"valid"`
+	Subscription *SubscriptionResponse `json:"subscription,omitempty"`
+	Errors       []string              `json:"errors,omitempty"`
+	Warnings     []string              `json:"warnings,omitempty"`
+}
+
+// @Description Defines the API response structure for a batch validation request.
+// Определяет структуру ответа API для запроса пакетной проверки.
+type ValidateBatchResponse struct {
+	Results []BatchValidationResult `json:"results"`
+	Valid   int                     `json:"valid"`
+	Invalid int                     `json:"invalid"`
 }
 
 // @Description Defines the request body for updating a subscription.
 // Определяет тело запроса для обновления подписки.
 type UpdateSubscriptionRequest struct {
-	ServiceName string `json:"service_name" binding:"omitempty,max=15"`
-	Price       int    `json:"price" binding:"omitempty,gt=0"`
-	StartDate   string `json:"start_date" binding:"omitempty"`
-	EndDate     string `json:"end_date" binding:"omitempty"`
+	ServiceName string          `json:"service_name" binding:"omitempty,max=15"`
+	Price       int             `json:"price" binding:"omitempty,gt=0"`
+	StartDate   string          `json:"start_date" binding:"omitempty"`
+	EndDate     *string         `json:"end_date" binding:"omitempty" extensions:"x-nullable"` // omitted or null leaves it unchanged, "" clears it
+	ParentID    *SubscriptionID `json:"parent_id,omitempty" swaggertype:"string"`             // 0 detaches the subscription from its parent
+	Description *string         `json:"description" extensions:"x-nullable"`                  // omitted or null leaves it unchanged, "" clears it
 }
 
 // @Description Defines the API response structure for a subscription.
+// @Description end_date is always present and is null for open-ended subscriptions.
+// @Description warnings lists non-blocking issues found on create or update.
+// @Description created_at and updated_at are RFC3339 timestamps of the creation and the last modification, omitted for unsaved subscriptions.
 // Определяет структуру ответа API для подписки.
+// end_date всегда присутствует и равен null для бессрочных подписок.
+// warnings перечисляет неблокирующие замечания, найденные при создании или обновлении.
+// created_at и updated_at — метки времени RFC3339 создания и последнего изменения, опускаются для несохраненных подписок.
 type SubscriptionResponse struct {
-	ID          uint   `json:"service_id"`
-	ServiceName string `json:"service_name"`
-	Price       int    `json:"price"`
-	UserID      string `json:"user_id"`
-	StartDate   string `json:"start_date"`
-	EndDate     string `json:"end_date,omitempty"`
+	ID          SubscriptionID  `json:"service_id" swaggertype:"string" example:"42"`
+	ServiceName string          `json:"service_name"`
+	Price       int             `json:"price"`
+	UserID      string          `json:"user_id"`
+	StartDate   string          `json:"start_date"`
+	EndDate     *string         `json:"end_date" extensions:"x-nullable" example:"12-2025"`
+	Status      string          `json:"status" enums:"active,upcoming,expired"`
+	ParentID    *SubscriptionID `json:"parent_id" extensions:"x-nullable" swaggertype:"string"`
+	Description string          `json:"description,omitempty"`
+	Cost        *int64          `json:"cost,omitempty"`
+	Warnings    []string        `json:"warnings,omitempty"`
+	CreatedAt   *string         `json:"created_at,omitempty" example:"2025-07-01T12:00:00Z"`
+	UpdatedAt   *string         `json:"updated_at,omitempty" example:"2025-07-01T12:00:00Z"`
 }
 
 // @Description Defines the request query for fetching subscription summary of a user.
 // Определяет запрос для получения сводной информации о подписке пользователя.
 type UserSubscriptionSummaryRequest struct {
 	UserID      string `form:"user_id" binding:"required,uuid"`
-	ServiceName string `form:"service_name,omitempty" binding:"required"`
-	From        string `form:"from,omitempty"`
-	To          string `form:"to,omitempty"`
+	ServiceName string `form:"service_name,omitempty"`
+	DateRange   `form:"-"`
+	// ExactServiceName matches service_name case-sensitively instead of on its normalized form
+	// ExactServiceName сравнивает service_name с учетом регистра вместо нормализованной формы
+	ExactServiceName bool `form:"exact_service_name"`
+	// IncludeMembers rolls the cost of family plan members up into their parent subscriptions
+	// IncludeMembers добавляет стоимость участников семейного плана к их родительским подпискам
+	IncludeMembers bool `form:"include_members"`
+	// Budget compares the total cost against a spend limit when provided
+	// Budget сравнивает общую стоимость с лимитом расходов, если указан
+	Budget *int64 `form:"budget" binding:"omitempty,min=0"`
+	// Diagnostics lists the subscriptions contributing nothing to the summary and why
+	// Diagnostics перечисляет подписки, не вносящие вклад в сводку, и причину
+	Diagnostics bool `form:"diagnostics"`
+}
+
+// @Description Defines the request query for the per-service summaries of a user
+// Определяет запрос для сводок пользователя по каждому сервису.
+type ServiceStatsRequest struct {
+	UserID    string `form:"user_id" binding:"required,uuid"`
+	DateRange `form:"-"`
+}
+
+// @Description Defines the summary of one service of a user
+// Определяет сводку по одному сервису пользователя.
+type ServiceSummary struct {
+	ServiceName       string `json:"service_name"`
+	TotalCost         int64  `json:"total_cost"`
+	Months            int    `json:"months"`
+	SubscriptionCount int    `json:"subscription_count"`
+}
+
+// @Description Defines the API response structure for the per-service summaries of a user, by cost descending
+// Определяет структуру ответа API для сводок пользователя по сервисам, по убыванию стоимости.
+type ServiceStatsResponse struct {
+	UserID   string           `json:"user_id"`
+	Period   StatsPeriod      `json:"period"`
+	Services []ServiceSummary `json:"services"`
+}
+
+// @Description Defines the request query for the quarterly costs of a user over a year
+// Определяет запрос квартальных расходов пользователя за год.
+type QuarterlyStatsRequest struct {
+	UserID string `form:"user_id" binding:"required,uuid"`
+	Year   int    `form:"year" binding:"required"`
+}
+
+// @Description Defines the cost of a user over one quarter, computed like a summary of its three months
+// Определяет расходы пользователя за один квартал, вычисленные как сводка за его три месяца.
+type QuarterCost struct {
+	Quarter   int    `json:"quarter" example:"1"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+	TotalCost int64  `json:"total_cost"`
+	Months    int    `json:"months"`
+}
+
+// @Description Defines the API response structure for the quarterly costs of a user, always four quarters in order
+// Определяет структуру ответа API для квартальных расходов пользователя, всегда четыре квартала по порядку.
+type QuarterlyStatsResponse struct {
+	UserID    string        `json:"user_id"`
+	Year      int           `json:"year"`
+	TotalCost int64         `json:"total_cost"`
+	Quarters  []QuarterCost `json:"quarters"`
+}
+
+// @Description Defines the request query for the price statistics of a service across all users
+// Определяет запрос статистики цен сервиса по всем пользователям.
+type PriceStatsRequest struct {
+	ServiceName string `form:"service_name" binding:"required"`
+}
+
+// @Description Defines the API response structure for the price statistics of a service across all users.
+// @Description average is rounded to 2 decimals, median is the interpolated 50th percentile.
+// Определяет структуру ответа API для статистики цен сервиса по всем пользователям.
+// average округляется до 2 знаков, median — интерполированный 50-й процентиль.
+type PriceStatsResponse struct {
+	ServiceName       string  `json:"service_name"`
+	SubscriptionCount int64   `json:"subscription_count"`
+	Average           float64 `json:"average"`
+	Median            float64 `json:"median"`
+	Min               int     `json:"min"`
+	Max               int     `json:"max"`
+}
+
+// @Description Defines the period covered by stats. from is null when the period is unbounded,
+// @Description default names the DEFAULT_STATS_PERIOD applied when from or to was omitted.
+// Определяет период, охватываемый статистикой. from равен null, если период не ограничен,
+// default указывает DEFAULT_STATS_PERIOD, примененный, если from или to не был указан.
+type StatsPeriod struct {
+	From    *string `json:"from" extensions:"x-nullable" example:"11-2025"`
+	To      string  `json:"to" example:"10-2026"`
+	Default string  `json:"default,omitempty" example:"last_12_months"`
+}
+
+// @Description Defines the request query comparing the spend of a user over two periods, B against the base period A
+// Определяет запрос сравнения расходов пользователя за два периода, B относительно базового периода A.
+type StatsCompareRequest struct {
+	UserID      string `form:"user_id" binding:"required,uuid"`
+	PeriodAFrom string `form:"period_a_from" binding:"required"`
+	PeriodATo   string `form:"period_a_to" binding:"required"`
+	PeriodBFrom string `form:"period_b_from" binding:"required"`
+	PeriodBTo   string `form:"period_b_to" binding:"required"`
+}
+
+// @Description Defines the spend of a user over one period
+// Определяет расходы пользователя за один период.
+type PeriodStats struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	TotalCost int64  `json:"total_cost"`
+	Months    int    `json:"months"`
+}
+
+// @Description Defines the API response structure comparing two periods.
+// @Description delta is period B minus period A, delta_percent is null when period A cost nothing.
+// Определяет структуру ответа API для сравнения двух периодов.
+// delta — период B минус период A, delta_percent равен null, если период A ничего не стоил.
+type StatsCompareResponse struct {
+	UserID       string      `json:"user_id"`
+	PeriodA      PeriodStats `json:"period_a"`
+	PeriodB      PeriodStats `json:"period_b"`
+	Delta        int64       `json:"delta"`
+	DeltaPercent *float64    `json:"delta_percent" extensions:"x-nullable"`
+}
+
+// @Description Defines the request query for the revenue per month, of every subscription or of one user
+// Определяет запрос выручки по месяцам для всех подписок или одного пользователя.
+type RevenueByMonthRequest struct {
+	UserID    string `form:"user_id,omitempty" binding:"omitempty,uuid"`
+	DateRange `form:"-"`
+}
+
+// @Description Defines the summed price of the subscriptions active in one month
+// Определяет сумму цен подписок, активных в одном месяце.
+type RevenuePoint struct {
+	Month   string `json:"month"`
+	Revenue int64  `json:"revenue"`
+}
+
+// @Description Defines the API response structure for the revenue per month
+// Определяет структуру ответа API для выручки по месяцам.
+type RevenueByMonthResponse struct {
+	UserID       string         `json:"user_id,omitempty"`
+	From         string         `json:"from"`
+	To           string         `json:"to"`
+	Period       StatsPeriod    `json:"period"`
+	TotalRevenue int64          `json:"total_revenue"`
+	Points       []RevenuePoint `json:"points"`
+}
+
+// @Description Defines the request payload for the combined spend of a team of users
+// Определяет полезную нагрузку запроса для общих расходов команды пользователей.
+type TeamStatsRequest struct {
+	UserIDs []string `json:"user_ids" binding:"required,min=1,max=100,dive,uuid"`
+	From    string   `json:"from,omitempty"`
+	To      string   `json:"to,omitempty"`
+}
+
+// @Description Defines the spend of one user of a team
+// Определяет расходы одного пользователя команды.
+type UserCostSummary struct {
+	UserID            string `json:"user_id"`
+	TotalCost         int64  `json:"total_cost"`
+	TotalMonths       int    `json:"total_months"`
+	SubscriptionCount int    `json:"subscription_count"`
+}
+
+// @Description Defines the API response structure for the combined spend of a team, with a per-user breakdown
+// Определяет структуру ответа API для общих расходов команды с разбивкой по пользователям.
+type TeamStatsResponse struct {
+	Period    StatsPeriod       `json:"period"`
+	TotalCost int64             `json:"total_cost"`
+	Users     []UserCostSummary `json:"users"`
+}
+
+// Health statuses reported by the probes.
+// Статусы работоспособности, возвращаемые пробами.
+const (
+	HealthStatusOK          = "ok"
+	HealthStatusUnavailable = "unavailable"
+)
+
+// @Description Defines the API response structure of the health probes.
+// Определяет структуру ответа API для проб работоспособности.
+type HealthResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
 }
 
-// @Description Defines the generic error
-// Определяет общую ошибку
+// @Description Defines the API response structure of the root path.
+// Определяет структуру ответа API для корневого пути.
+type RootResponse struct {
+	Service string            `json:"service"`
+	Version string            `json:"version"`
+	Links   map[string]string `json:"links"`
+}
+
+// @Description Defines the generic error, error is localized from the Accept-Language header while code stays the same in every language
+// Определяет общую ошибку; error локализуется по заголовку Accept-Language, а code одинаков на всех языках
 type ErrorResponse struct {
+	Code    string `json:"code,omitempty"`
 	Error   string `json:"error"`
 	Details string `json:"details,omitempty"`
 }
@@ -68,20 +442,273 @@ type ErrorResponse struct {
 // @Description Defines the structure of the API response for the /summary endpoint.
 // Определяет структуру ответа API для конечной точки /summary.
 type UserSubscriptionSummaryResponse struct {
-	UserID      string `json:"user_id"`
-	ServiceName string `json:"service_name"`
-	UnitPrice   int    `json:"unit_price"`
-	TotalMonths int    `json:"total_months"`
-	TotalAmount int64  `json:"total_amount"`
+	UserID      string      `json:"user_id"`
+	ServiceName string      `json:"service_name"`
+	UnitPrice   int         `json:"unit_price"`
+	TotalMonths int         `json:"total_months"`
+	TotalAmount int64       `json:"total_amount"`
+	Period      StatsPeriod `json:"period"`
+	// only set when a budget is requested
+	// заполняются только при запросе бюджета
+	OverBudget *bool  `json:"over_budget,omitempty"`
+	Overage    *int64 `json:"overage,omitempty"`
+	// only set when diagnostics is requested
+	// заполняется только при запросе diagnostics
+	Excluded []ExcludedSubscription `json:"excluded,omitempty"`
+}
+
+// Reasons a subscription contributes nothing to a summary.
+// Причины, по которым подписка не вносит вклад в сводку.
+const (
+	ExcludedStartsAfterPeriod    = "starts_after_period"
+	ExcludedEndedBeforePeriod    = "ended_before_period"
+	ExcludedMonthsAlreadyCharged = "months_already_charged"
+)
+
+// @Description Defines a subscription left out of a summary, reported by the diagnostics mode
+// Определяет подписку, не учтенную в сводке, сообщаемую режимом диагностики.
+type ExcludedSubscription struct {
+	ID          SubscriptionID `json:"id" swaggertype:"string"`
+	ServiceName string         `json:"service_name"`
+	Reason      string         `json:"reason" enums:"starts_after_period,ended_before_period,months_already_charged"`
+}
+
+// @Description Defines the limit and offset query parameters shared by the offset paginated lists
+// Определяет параметры запроса limit и offset, общие для списков с пагинацией по смещению.
+type Pagination struct {
+	Limit  int `form:"limit,default=10" json:"limit" binding:"omitempty,min=1,max=100"` // Max items to return
+	Offset int `form:"offset,default=0" json:"offset" binding:"omitempty,min=0"`        // Items to skip
+}
+
+// @Description Defines the optional from and to query parameters (MM-YYYY) shared by the date range endpoints
+// Определяет необязательные параметры запроса from и to (MM-YYYY), общие для эндпоинтов с диапазоном дат.
+type DateRange struct {
+	From string `form:"from,omitempty"`
+	To   string `form:"to,omitempty"`
 }
 
 // @Description Defines the request query for fetching subscriptions with pagination, sorting and ordering
 // Определяет запрос для получения подписок с пагинацией, сортировкой и упорядочиванием.
 type ListSubscriptionRequest struct {
-	Limit  int    `form:"limit,default=10" json:"limit" binding:"omitempty,min=1,max=100"`                      // Max items to return
-	Offset int    `form:"offset,default=0" json:"offset" binding:"omitempty,min=0"`                             // Items to skip
+	// Pagination and DateRange are parsed by ParsePagination and ParseDateRange, not by the request binding
+	// Pagination и DateRange разбираются ParsePagination и ParseDateRange, а не привязкой запроса
+	Pagination `form:"-"`
+
 	SortBy string `form:"sort_by,default=id" binding:"oneof=id user_id service_name price start_date end_date"` // created_at, price, start_date
 	Order  string `form:"order,default=desc" binding:"oneof=desc asc"`                                          // asc, desc
+	Status string `form:"status" binding:"omitempty,oneof=active upcoming expired"`                             // active, upcoming, expired
+	// IncludeCounts adds the unfiltered and filtered-out totals to the response meta
+	// IncludeCounts добавляет в метаданные ответа общее количество без фильтров и количество отфильтрованных
+	IncludeCounts bool `form:"include_counts"`
+	// WithTotals adds a totals footer with the row count and price sum to the response
+	// WithTotals добавляет в ответ итоговый блок с количеством строк и суммой цен
+	WithTotals bool `form:"with_totals"`
+	// WithCost adds to each subscription its cost over the from-to period, like a summary of it alone
+	// WithCost добавляет к каждой подписке ее стоимость за период from-to, как сводка по ней одной
+	WithCost  bool `form:"with_cost"`
+	DateRange `form:"-"`
+	// Search keeps the subscriptions whose service name or description contains it, case-insensitively
+	// Search оставляет подписки, имя сервиса или описание которых содержит его, без учета регистра
+	Search string `form:"search" binding:"omitempty,max=100"`
+	// PaginationMode is the pagination parameter, cursor switching to the keyset pagination of CursorListRequest
+	// PaginationMode — параметр pagination, значение cursor переключает на keyset-пагинацию CursorListRequest
+	PaginationMode string `form:"pagination" binding:"omitempty,oneof=cursor"`
+	// Page and PageSize select a page by number instead of limit and offset, invalid values fall back to page 1 and limit
+	// Page и PageSize выбирают страницу по номеру вместо limit и offset, неверные значения заменяются страницей 1 и limit
+	Page     string `form:"page"`
+	PageSize string `form:"page_size"`
+}
+
+// ByPage reports whether the list was requested by page number, to be answered as PaginatedSubscriptions.
+// ByPage сообщает, запрошен ли список по номеру страницы, и ответ должен быть PaginatedSubscriptions.
+func (r *ListSubscriptionRequest) ByPage() bool {
+	return r.Page != "" || r.PageSize != ""
+}
+
+// PaginationCursor is the pagination value selecting the keyset paginated list.
+// PaginationCursor — значение pagination, выбирающее список с keyset-пагинацией.
+const PaginationCursor = "cursor"
+
+// @Description Defines the request query of the keyset paginated subscription list, selected with pagination=cursor or by after.
+// Определяет параметры запроса списка подписок с keyset-пагинацией, выбираемого pagination=cursor или наличием after.
+type CursorListRequest struct {
+	Pagination string `form:"pagination" binding:"omitempty,oneof=cursor"`
+	Limit      int    `form:"limit,default=50" binding:"min=1,max=200"`
+	// After is the opaque next_cursor of the previous page, the first page when empty
+	// After — непрозрачный next_cursor предыдущей страницы, первая страница, если пуст
+	After string `form:"after" binding:"omitempty,max=64"`
+}
+
+// @Description Defines the API response structure for the keyset paginated subscription list.
+// @Description next_cursor is passed as after for the next page and is empty on the last one.
+// Определяет структуру ответа API для списка подписок с keyset-пагинацией.
+// next_cursor передается в after для следующей страницы и пуст на последней.
+type CursorSubscriptionsResponse struct {
+	Items      []SubscriptionResponse `json:"items"`
+	NextCursor string                 `json:"next_cursor"`
+}
+
+func (r *CursorSubscriptionsResponse) PageItems() []SubscriptionResponse {
+	return r.Items
+}
+
+// PageMeta returns the cursor of the next page, the only pagination metadata of the keyset paginated list.
+// PageMeta возвращает курсор следующей страницы, единственные метаданные пагинации списка с keyset-пагинацией.
+func (r *CursorSubscriptionsResponse) PageMeta() any {
+	return struct {
+		NextCursor string `json:"next_cursor"`
+	}{r.NextCursor}
+}
+
+// @Description Defines the API response structure for the members of a family/group plan.
+// Определяет структуру ответа API для участников семейного/группового плана.
+type SubscriptionMembersResponse struct {
+	Subscription SubscriptionResponse   `json:"subscription"`
+	Members      []SubscriptionResponse `json:"members"`
+}
+
+// @Description Defines the API response structure for the plan of the summary query.
+// Определяет структуру ответа API для плана запроса сводки.
+type ExplainResponse struct {
+	Plan json.RawMessage `json:"plan" swaggertype:"object"`
+}
+
+// @Description Defines the API response structure for the date normalization backfill, normalized counts the rows rewritten.
+// Определяет структуру ответа API для нормализации дат, normalized — количество переписанных строк.
+type NormalizeDatesResponse struct {
+	Normalized int64 `json:"normalized"`
+}
+
+// @Description Defines the request path addressing a user
+// Определяет путь запроса, указывающий на пользователя.
+type UserUriRequest struct {
+	UserID string `uri:"user_id" binding:"required,uuid"`
+}
+
+// @Description Defines the export of every record held about a user
+// Определяет экспорт всех записей, хранящихся о пользователе.
+type UserExportResponse struct {
+	UserID        string                 `json:"user_id"`
+	ExportedAt    time.Time              `json:"exported_at"`
+	Subscriptions []SubscriptionResponse `json:"subscriptions"`
+}
+
+// @Description Defines the API response structure for a user data erasure
+// Определяет структуру ответа API для удаления данных пользователя.
+type UserEraseResponse struct {
+	UserID  string `json:"user_id"`
+	Deleted int64  `json:"deleted"`
+}
+
+// @Description Defines the request query for the admin lookup of subscriptions by user ID prefix
+// Определяет запрос администратора для поиска подписок по префиксу ID пользователя.
+type UserPrefixSearchRequest struct {
+	UserPrefix string `form:"user_prefix" binding:"required"`
+	Pagination `form:"-"`
+}
+
+// @Description Defines the request payload to cancel a subscription, end_date defaults to the current month
+// Определяет полезную нагрузку запроса отмены подписки, end_date по умолчанию — текущий месяц.
+type CancelSubscriptionRequest struct {
+	EndDate string `json:"end_date" binding:"omitempty"`
+}
+
+// @Description Defines the request payload to extend a subscription end_date by a number of monthly periods
+// Определяет полезную нагрузку запроса продления end_date подписки на число месячных периодов.
+type ExtendSubscriptionRequest struct {
+	Periods int `json:"periods" binding:"required,min=1,max=120"`
+}
+
+// @Description Defines the request query for the effective monthly cost of a subscription
+// Определяет запрос эффективной ежемесячной стоимости подписки.
+type CostPerMonthRequest struct {
+	DateRange `form:"-"`
+}
+
+// @Description Defines the API response structure for the effective monthly cost of a subscription
+// Определяет структуру ответа API для эффективной ежемесячной стоимости подписки.
+type CostPerMonthResponse struct {
+	ID           SubscriptionID `json:"id" swaggertype:"string"`
+	From         string         `json:"from"`
+	To           string         `json:"to"`
+	ActiveMonths int            `json:"active_months"`
+	TotalCost    int64          `json:"total_cost"`
+	CostPerMonth float64        `json:"cost_per_month"`
+}
+
+// @Description Defines the request query for the monthly cost timeline of a subscription
+// Определяет запрос помесячной временной шкалы стоимости подписки.
+type SubscriptionTimelineRequest struct {
+	DateRange `form:"-"`
+}
+
+// @Description Defines the cost contribution of a subscription in one month, 0 when it isn't active
+// Определяет вклад подписки в стоимость за один месяц, 0, если она не активна.
+type TimelinePoint struct {
+	Month string `json:"month"`
+	Cost  int64  `json:"cost"`
+}
+
+// @Description Defines the API response structure for the monthly cost timeline of a subscription
+// Определяет структуру ответа API для помесячной временной шкалы стоимости подписки.
+type SubscriptionTimelineResponse struct {
+	ID        SubscriptionID  `json:"id" swaggertype:"string"`
+	From      string          `json:"from"`
+	To        string          `json:"to"`
+	TotalCost int64           `json:"total_cost"`
+	Points    []TimelinePoint `json:"points"`
+}
+
+// @Description Defines the request query for the coverage periods of a user's service
+// Определяет запрос периодов покрытия сервиса пользователя.
+type SubscriptionPeriodsRequest struct {
+	UserID      string `form:"user_id" binding:"required,uuid"`
+	ServiceName string `form:"service_name" binding:"required"`
+}
+
+// @Description Defines a continuous range of months covered by subscriptions, to is null while open-ended
+// Определяет непрерывный диапазон месяцев, покрытых подписками; to равен null для бессрочного.
+type CoveragePeriod struct {
+	From string  `json:"from"`
+	To   *string `json:"to" extensions:"x-nullable"`
+}
+
+// @Description Defines the API response structure for the coverage periods of a user's service, in chronological order.
+// @Description Adjacent and overlapping subscriptions are merged, so consecutive periods are separated by a gap.
+// Определяет структуру ответа API для периодов покрытия сервиса пользователя в хронологическом порядке.
+// Смежные и пересекающиеся подписки объединяются, поэтому последовательные периоды разделены пропуском.
+type SubscriptionPeriodsResponse struct {
+	UserID            string           `json:"user_id"`
+	ServiceName       string           `json:"service_name"`
+	SubscriptionCount int              `json:"subscription_count"`
+	Periods           []CoveragePeriod `json:"periods"`
+}
+
+// @Description Defines the request payload for comparing subscriptions side by side
+// Определяет полезную нагрузку запроса для сравнения подписок.
+type CompareSubscriptionsRequest struct {
+	IDs []SubscriptionID `json:"ids" binding:"required,min=2,max=20,dive,min=1" swaggertype:"array,string"`
+}
+
+// Annotations marking the extremes of a comparison.
+// Аннотации, отмечающие крайние значения сравнения.
+const (
+	CompareCheapest      = "cheapest"
+	CompareMostExpensive = "most_expensive"
+)
+
+// @Description Defines a subscription of a comparison with its computed annualized cost
+// Определяет подписку в сравнении с вычисленной годовой стоимостью.
+type ComparedSubscription struct {
+	SubscriptionResponse
+	AnnualCost int64  `json:"annual_cost"`
+	Annotation string `json:"annotation,omitempty" enums:"cheapest,most_expensive"`
+}
+
+// @Description Defines the API response structure for a subscription comparison
+// Определяет структуру ответа API для сравнения подписок.
+type CompareSubscriptionsResponse struct {
+	Subscriptions []ComparedSubscription `json:"subscriptions"`
 }
 
 // @Description Defines the request query path processing subscription by ID
@@ -98,6 +725,10 @@ type PaginationMeta struct {
 	SortBy string `json:"sort_by"`
 	Order  string `json:"order"`
 	Total  int64  `json:"total"`
+	// only set when include_counts is requested
+	// заполняются только при запросе include_counts
+	UnfilteredTotal *int64 `json:"unfiltered_total,omitempty"`
+	FilteredOut     *int64 `json:"filtered_out,omitempty"`
 }
 
 // @Description Defines the API response structure for a ListSubscriptionRequest.
@@ -105,4 +736,166 @@ type PaginationMeta struct {
 type ListSubscriptionsResponse struct {
 	Subscriptions []SubscriptionResponse `json:"subscriptions"`
 	Meta          *PaginationMeta        `json:"meta"`
+	Totals        *ListTotals            `json:"totals,omitempty"`
+}
+
+// PaginatedResponse is a page of subscriptions in one of the list envelopes. PageItems and PageMeta
+// give its items and pagination metadata for the JSON:API representation of the same page.
+// PaginatedResponse — страница подписок в одном из конвертов списков. PageItems и PageMeta
+// возвращают ее элементы и метаданные пагинации для представления JSON:API той же страницы.
+type PaginatedResponse interface {
+	PageItems() []SubscriptionResponse
+	PageMeta() any
+}
+
+func (r *ListSubscriptionsResponse) PageItems() []SubscriptionResponse {
+	return r.Subscriptions
+}
+
+// PageMeta returns the pagination meta, joined by the totals footer when set,
+// JSON:API having no other top-level member for it.
+// PageMeta возвращает метаданные пагинации вместе с итоговым блоком, если он задан,
+// так как в JSON:API для него нет другого члена верхнего уровня.
+func (r *ListSubscriptionsResponse) PageMeta() any {
+	if r.Totals == nil {
+		return r.Meta
+	}
+	return struct {
+		*PaginationMeta
+		Totals *ListTotals `json:"totals"`
+	}{r.Meta, r.Totals}
+}
+
+// @Description Defines the API response structure of a subscription list requested by page and page_size.
+// @Description total counts every matching subscription and total_pages the pages of page_size covering them.
+// @Description unfiltered_total, filtered_out and totals are set like in a ListSubscriptionsResponse.
+// Определяет структуру ответа API для списка подписок, запрошенного по page и page_size.
+// total считает все подходящие подписки, а total_pages — страницы размера page_size, покрывающие их.
+// unfiltered_total, filtered_out и totals заполняются как в ListSubscriptionsResponse.
+type PaginatedSubscriptions struct {
+	Items []SubscriptionResponse `json:"items"`
+	PageNumberMeta
+	Totals *ListTotals `json:"totals,omitempty"`
+}
+
+// @Description Defines the page numbers of a subscription list requested by page and page_size.
+// Определяет номера страниц списка подписок, запрошенного по page и page_size.
+type PageNumberMeta struct {
+	Total      int64 `json:"total"`
+	Page       int   `json:"page"`
+	PageSize   int   `json:"page_size"`
+	TotalPages int   `json:"total_pages"`
+	// only set when include_counts is requested
+	// заполняются только при запросе include_counts
+	UnfilteredTotal *int64 `json:"unfiltered_total,omitempty"`
+	FilteredOut     *int64 `json:"filtered_out,omitempty"`
+}
+
+func (r *PaginatedSubscriptions) PageItems() []SubscriptionResponse {
+	return r.Items
+}
+
+// PageMeta returns the page numbers, joined by the totals footer when set.
+// PageMeta возвращает номера страниц вместе с итоговым блоком, если он задан.
+func (r *PaginatedSubscriptions) PageMeta() any {
+	if r.Totals == nil {
+		return r.PageNumberMeta
+	}
+	return struct {
+		PageNumberMeta
+		Totals *ListTotals `json:"totals"`
+	}{r.PageNumberMeta, r.Totals}
+}
+
+// @Description Defines the totals footer of a subscription list, set when with_totals is requested.
+// @Description page_* cover the returned rows, count and price_sum every row matching the filters.
+// Определяет итоговый блок списка подписок, заполняется при запросе with_totals.
+// page_* относятся к возвращенным строкам, count и price_sum — ко всем строкам, подходящим под фильтры.
+type ListTotals struct {
+	PageCount    int   `json:"page_count"`
+	PagePriceSum int64 `json:"page_price_sum"`
+	Count        int64 `json:"count"`
+	PriceSum     int64 `json:"price_sum"`
+}
+
+// @Description Defines the API response structure for the admin user statistics.
+// Определяет структуру ответа API для статистики пользователей администратора.
+type UserStatsResponse struct {
+	TotalUsers  int64 `json:"total_users"`
+	ActiveUsers int64 `json:"active_users"`
+}
+
+// @Description Defines the API response structure for the admin in-flight request statistics.
+// Определяет структуру ответа API для статистики одновременных запросов администратора.
+type InFlightStatsResponse struct {
+	InFlight    int64 `json:"in_flight"`
+	MaxInFlight int   `json:"max_in_flight"`
+}
+
+// @Description Defines a JSON:API resource object wrapping a subscription.
+// Определяет объект ресурса JSON:API, содержащий подписку.
+type JSONAPIResource struct {
+	Type       string               `json:"type"`
+	ID         string               `json:"id"`
+	Attributes SubscriptionResponse `json:"attributes"`
+}
+
+// @Description Defines a JSON:API top-level document holding one resource or a collection.
+// Определяет документ верхнего уровня JSON:API, содержащий один ресурс или коллекцию.
+type JSONAPIDocument struct {
+	Data any `json:"data"`
+	Meta any `json:"meta,omitempty"`
+}
+
+// ReminderEventExpiring is the event of the reminder sent before a subscription ends.
+// ReminderEventExpiring — событие напоминания, отправляемого до окончания подписки.
+const ReminderEventExpiring = "subscription.expiring"
+
+// ReminderNotification is the payload sent by notifiers for a subscription about to end.
+// ReminderNotification — полезная нагрузка, отправляемая уведомителями для подписки, которая скоро закончится.
+type ReminderNotification struct {
+	Event          string         `json:"event"`
+	TenantID       string         `json:"tenant_id,omitempty"`
+	SubscriptionID SubscriptionID `json:"subscription_id" swaggertype:"string"`
+	UserID         string         `json:"user_id"`
+	ServiceName    string         `json:"service_name"`
+	Price          int            `json:"price"`
+	EndDate        string         `json:"end_date"`
+}
+
+// SummaryMonth is the precomputed cost of one service of a user in one month, months being
+// deduplicated within the service the same way as the summaries.
+// SummaryMonth — предварительно вычисленная стоимость одного сервиса пользователя за один месяц,
+// месяцы дедуплицируются внутри сервиса так же, как в сводках.
+type SummaryMonth struct {
+	TenantID   string    `gorm:"type:varchar(64);primaryKey"`
+	UserID     string    `gorm:"type:uuid;primaryKey"`
+	ServiceKey string    `gorm:"type:varchar(100);primaryKey"`
+	Month      time.Time `gorm:"type:date;primaryKey"`
+	Cost       int64     `gorm:"not null"`
+}
+
+// SummaryService is the precomputed description of one service of a user, listed by the per-service
+// stats even for periods none of its subscriptions cover.
+// SummaryService — предварительно вычисленное описание одного сервиса пользователя, выводимое статистикой
+// по сервисам даже за периоды, не покрытые ни одной его подпиской.
+type SummaryService struct {
+	TenantID          string `gorm:"type:varchar(64);primaryKey"`
+	UserID            string `gorm:"type:uuid;primaryKey"`
+	ServiceKey        string `gorm:"type:varchar(100);primaryKey"`
+	ServiceName       string `gorm:"type:varchar(100);not null"`
+	UnitPrice         int    `gorm:"not null"`
+	SubscriptionCount int    `gorm:"not null"`
+}
+
+// SummaryCacheState tracks the freshness of the summary months of a user: they are only read when
+// refreshed after the last invalidation and cover the months up to Horizon.
+// SummaryCacheState отслеживает актуальность месяцев сводки пользователя: они читаются, только если
+// обновлены после последней инвалидации, и покрывают месяцы до Horizon.
+type SummaryCacheState struct {
+	TenantID      string     `gorm:"type:varchar(64);primaryKey"`
+	UserID        string     `gorm:"type:uuid;primaryKey"`
+	RefreshedAt   *time.Time `gorm:"type:timestamptz"`
+	InvalidatedAt *time.Time `gorm:"type:timestamptz"`
+	Horizon       *time.Time `gorm:"type:date"`
 }
@@ -0,0 +1,66 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/models"
+	"github.com/cyb3rkh4l1d/subsapi/internal/validations"
+)
+
+// webhookTimeout bounds every webhook delivery.
+// webhookTimeout ограничивает время каждой доставки вебхука.
+const webhookTimeout = 10 * time.Second
+
+// Notifier delivers reminders to users through a provider such as a webhook or email.
+// Notifier доставляет напоминания пользователям через провайдера, например вебхук или email.
+type Notifier interface {
+	Notify(ctx context.Context, reminder models.ReminderNotification) error
+}
+
+// WebhookNotifier posts reminders as JSON to a URL, leaving the delivery to the receiving service.
+// WebhookNotifier отправляет напоминания в формате JSON на URL, оставляя доставку принимающему сервису.
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+/*.....................................................................
+
+					Functions/Methods Definations
+
+........................................................................*/
+
+// NewWebhookNotifier creates a WebhookNotifier posting to url.
+// NewWebhookNotifier создает WebhookNotifier, отправляющий запросы на url.
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{url: url, client: &http.Client{Timeout: webhookTimeout}}
+}
+
+// Notify posts the reminder and fails unless the webhook answers with a 2xx status.
+// Notify отправляет напоминание и завершается ошибкой, если вебхук не ответил статусом 2xx.
+func (n *WebhookNotifier) Notify(ctx context.Context, reminder models.ReminderNotification) error {
+	body, err := json.Marshal(reminder)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("%w: webhook answered %d", validations.ErrNotificationFailed, res.StatusCode)
+	}
+	return nil
+}
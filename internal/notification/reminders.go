@@ -0,0 +1,119 @@
+package notification
+
+import (
+	"context"
+	"time"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/models"
+	"github.com/cyb3rkh4l1d/subsapi/internal/repository"
+	"github.com/cyb3rkh4l1d/subsapi/internal/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// reminderBatchSize is the number of due subscriptions loaded per query of a run.
+// reminderBatchSize — количество подписок, загружаемых одним запросом за запуск.
+const reminderBatchSize = 100
+
+// Reminders periodically sends a reminder for every subscription ending within the lead time,
+// once per end_date: a reminder is claimed in the database before it is sent and released when sending fails.
+// Reminders периодически отправляет напоминание для каждой подписки, заканчивающейся в пределах срока упреждения,
+// один раз на end_date: напоминание занимается в базе данных до отправки и освобождается при ошибке отправки.
+type Reminders struct {
+	repo       repository.Repository
+	notifier   Notifier
+	leadMonths int
+	interval   time.Duration
+	logger     *logrus.Entry
+}
+
+// NewReminders creates Reminders for subscriptions ending within leadMonths after the current month, checked every interval.
+// NewReminders создает Reminders для подписок, заканчивающихся в течение leadMonths после текущего месяца, с проверкой каждые interval.
+func NewReminders(repo repository.Repository, notifier Notifier, leadMonths int, interval time.Duration, logger *logrus.Entry) *Reminders {
+	return &Reminders{repo: repo, notifier: notifier, leadMonths: leadMonths, interval: interval, logger: logger}
+}
+
+// Start sends the due reminders in the background right away and then every interval until ctx is done.
+// Start отправляет напоминания в фоне сразу и затем каждые interval до завершения ctx.
+func (r *Reminders) Start(ctx context.Context) {
+	go func() {
+		r.run(ctx)
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.run(ctx)
+			}
+		}
+	}()
+}
+
+// DueWindow returns the first and last end_date months a reminder is due for at now.
+// DueWindow возвращает первый и последний месяцы end_date, для которых напоминание нужно в момент now.
+func (r *Reminders) DueWindow(now time.Time) (time.Time, time.Time) {
+	from := utils.StartOfMonth(now)
+	return from, from.AddDate(0, r.leadMonths, 0)
+}
+
+// run sends the reminders due now, batch by batch, and logs how many were sent.
+// Failed reminders are released and wait for the next run.
+// run отправляет напоминания, которые нужно отправить сейчас, пакет за пакетом, и логирует их количество.
+// Неудачные напоминания освобождаются и ждут следующего запуска.
+func (r *Reminders) run(ctx context.Context) {
+	from, to := r.DueWindow(utils.Now())
+	sent, failed := 0, 0
+	var afterID uint
+	for {
+		subs, err := r.repo.FindExpiring(ctx, from, to, afterID, reminderBatchSize)
+		if err != nil {
+			r.logger.WithError(err).Warn("reminders: failed to find expiring subscriptions")
+			break
+		}
+		for i := range subs {
+			ok, err := r.send(ctx, &subs[i])
+			switch {
+			case err != nil:
+				failed++
+				r.logger.WithError(err).Warnf("reminders: failed to remind subscription %+v", subs[i].ID)
+			case ok:
+				sent++
+			}
+		}
+		if len(subs) < reminderBatchSize {
+			break
+		}
+		afterID = subs[len(subs)-1].ID
+	}
+	if sent > 0 || failed > 0 {
+		r.logger.Infof("reminders: %+v sent, %+v failed", sent, failed)
+	}
+}
+
+// send claims the reminder of a subscription and notifies it, releasing the claim when the notification fails.
+// It reports false when another scheduler already claimed the reminder.
+// send занимает напоминание подписки и отправляет его, освобождая при ошибке уведомления.
+// Возвращает false, если напоминание уже занято другим планировщиком.
+func (r *Reminders) send(ctx context.Context, sub *models.Subscription) (bool, error) {
+	claimed, err := r.repo.ClaimReminder(ctx, sub.ID, time.Now().UTC())
+	if err != nil || !claimed {
+		return false, err
+	}
+	reminder := models.ReminderNotification{
+		Event:          models.ReminderEventExpiring,
+		TenantID:       sub.TenantID,
+		SubscriptionID: sub.ExposedID(),
+		UserID:         sub.UserID,
+		ServiceName:    sub.ServiceName,
+		Price:          sub.Price,
+		EndDate:        utils.FormatMonthYear(*sub.EndDate),
+	}
+	if err := r.notifier.Notify(ctx, reminder); err != nil {
+		if releaseErr := r.repo.ReleaseReminder(ctx, sub.ID); releaseErr != nil {
+			r.logger.WithError(releaseErr).Warnf("reminders: failed to release reminder of subscription %+v", sub.ID)
+		}
+		return false, err
+	}
+	return true, nil
+}
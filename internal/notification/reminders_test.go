@@ -0,0 +1,113 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/models"
+	"github.com/cyb3rkh4l1d/subsapi/internal/repository"
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+)
+
+// reminderRepository keeps the reminder marks of subs in memory.
+// reminderRepository хранит отметки напоминаний subs в памяти.
+type reminderRepository struct {
+	repository.Repository
+	mu   sync.Mutex
+	subs []models.Subscription
+	sent map[uint]bool
+}
+
+func (r *reminderRepository) FindExpiring(_ context.Context, from, to time.Time, afterID uint, limit int) ([]models.Subscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var due []models.Subscription
+	for _, sub := range r.subs {
+		if sub.EndDate != nil && !sub.EndDate.Before(from) && !sub.EndDate.After(to) && !r.sent[sub.ID] && sub.ID > afterID && len(due) < limit {
+			due = append(due, sub)
+		}
+	}
+	return due, nil
+}
+
+func (r *reminderRepository) ClaimReminder(_ context.Context, id uint, _ time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.sent[id] {
+		return false, nil
+	}
+	r.sent[id] = true
+	return true, nil
+}
+
+func (r *reminderRepository) ReleaseReminder(_ context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.sent, id)
+	return nil
+}
+
+// recordingNotifier records the subscriptions it is notified about, failing while err is set.
+// recordingNotifier запоминает подписки, о которых получает уведомления, и завершается ошибкой, пока задан err.
+type recordingNotifier struct {
+	mu       sync.Mutex
+	err      error
+	notified []models.SubscriptionID
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, reminder models.ReminderNotification) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.err != nil {
+		return n.err
+	}
+	n.notified = append(n.notified, reminder.SubscriptionID)
+	return nil
+}
+
+func TestRemindersRun(t *testing.T) {
+	logger, _ := logtest.NewNullLogger()
+	now := time.Now().UTC()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := func(months int) *time.Time {
+		t := month.AddDate(0, months, 0)
+		return &t
+	}
+	repo := &reminderRepository{sent: map[uint]bool{}, subs: []models.Subscription{
+		{ID: 1, ServiceName: "Netflix", EndDate: end(0)},
+		{ID: 2, ServiceName: "Spotify", EndDate: end(1)},
+		{ID: 3, ServiceName: "Yandex Plus", EndDate: end(2)},
+		{ID: 4, ServiceName: "Kinopoisk", EndDate: end(-1)},
+		{ID: 5, ServiceName: "Okko"},
+	}}
+
+	// a failing notifier releases the claims, so nothing is marked as sent
+	// неудачное уведомление освобождает занятые напоминания, поэтому ничего не отмечено как отправленное
+	failing := &recordingNotifier{err: errors.New("webhook down")}
+	NewReminders(repo, failing, 1, time.Hour, logrus.NewEntry(logger)).run(context.Background())
+	if len(repo.sent) != 0 {
+		t.Fatalf("sent after a failed run = %v, want none", repo.sent)
+	}
+
+	// concurrent schedulers send each due reminder once
+	// параллельные планировщики отправляют каждое напоминание один раз
+	notifier := &recordingNotifier{}
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			NewReminders(repo, notifier, 1, time.Hour, logrus.NewEntry(logger)).run(context.Background())
+		}()
+	}
+	wg.Wait()
+	slices.Sort(notifier.notified)
+	if !slices.Equal(notifier.notified, []models.SubscriptionID{"1", "2"}) {
+		t.Errorf("notified = %v, want subscriptions 1 and 2 once each", notifier.notified)
+	}
+}
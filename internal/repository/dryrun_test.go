@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newDryRunRepository returns a repository that builds statements without running them, and the SQL of its
+// last query or update. It needs no database.
+// newDryRunRepository возвращает репозиторий, который строит запросы без их выполнения, и SQL его последнего
+// запроса или обновления. База данных не нужна.
+func newDryRunRepository(t *testing.T) (*SubscriptionRepository, *string) {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true, Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sql string
+	capture := func(tx *gorm.DB) { sql = tx.Statement.SQL.String() }
+	if err := db.Callback().Query().After("gorm:query").Register("test:capture", capture); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Callback().Update().After("gorm:update").Register("test:capture", capture); err != nil {
+		t.Fatal(err)
+	}
+	log, _ := logtest.NewNullLogger()
+	return NewSubscriptionRepository(db, logrus.NewEntry(log), 0, 0), &sql
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"strings"
+	"time"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/models"
+	"github.com/cyb3rkh4l1d/subsapi/internal/utils"
+	"github.com/cyb3rkh4l1d/subsapi/internal/validations"
+	"gorm.io/gorm"
+)
+
+// statusFilter translates a subscription status into date predicates evaluated by the database
+// against the current month. An empty status leaves the query unfiltered.
+// statusFilter преобразует статус подписки в условия по датам, вычисляемые базой данных
+// относительно текущего месяца. Пустой статус оставляет запрос без фильтра.
+func statusFilter(status string) func(db *gorm.DB) *gorm.DB {
+	return statusFilterAt(status, utils.Now())
+}
+
+// statusFilterAt is statusFilter evaluated against the month of at instead of the current one.
+// statusFilterAt — это statusFilter, вычисляемый относительно месяца at вместо текущего.
+func statusFilterAt(status string, at time.Time) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		month := utils.StartOfMonth(at)
+		switch status {
+		case models.StatusActive:
+			return db.Where("start_date <= ? AND (end_date IS NULL OR end_date >= ?)", month, month)
+		case models.StatusUpcoming:
+			return db.Where("start_date > ?", month)
+		case models.StatusExpired:
+			return db.Where("end_date < ?", month)
+		default:
+			return db
+		}
+	}
+}
+
+// searchFilter keeps the subscriptions whose service name or description contains search, case-insensitively.
+// LIKE wildcards in search match literally. An empty search keeps every subscription.
+// searchFilter оставляет подписки, имя сервиса или описание которых содержит search, без учета регистра.
+// Символы подстановки LIKE в search сопоставляются буквально. Пустой search оставляет все подписки.
+func searchFilter(search string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if search == "" {
+			return db
+		}
+		// LOWER ... LIKE rather than ILIKE, which SQLite lacks
+		// LOWER ... LIKE вместо ILIKE, которого нет в SQLite
+		pattern := "%" + likeEscaper.Replace(strings.ToLower(search)) + "%"
+		return db.Where(`LOWER(service_name) LIKE ? ESCAPE '\' OR LOWER(description) LIKE ? ESCAPE '\'`, pattern, pattern)
+	}
+}
+
+// likeEscaper escapes the LIKE wildcards and the backslash escape character.
+// likeEscaper экранирует символы подстановки LIKE и символ экранирования.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// rowCap limits a query to one row more than maxRows, so that exceeding the cap can be detected
+// without loading the whole result. A maxRows of 0 leaves the query unbounded.
+// rowCap ограничивает запрос на одну строку больше maxRows, чтобы превышение лимита можно было обнаружить
+// без загрузки всего результата. Значение maxRows, равное 0, оставляет запрос без ограничения.
+func rowCap(maxRows int) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if maxRows <= 0 {
+			return db
+		}
+		return db.Limit(maxRows + 1)
+	}
+}
+
+// userServiceFilter restricts a query to the subscriptions of a user and, when set, of a service.
+// The service name matches case-insensitively, like service grouping, unless exact is set.
+// userServiceFilter ограничивает запрос подписками пользователя и, если указан, сервиса.
+// Имя сервиса сравнивается без учета регистра, как при группировке, если не задан exact.
+func userServiceFilter(userID, serviceName string, exact bool) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Where("user_id = ?", userID)
+		switch {
+		case serviceName == "":
+		case exact:
+			db = db.Where("service_name = ?", serviceName)
+		default:
+			db = db.Where("LOWER(service_name) = ?", validations.ServiceNameKey(serviceName))
+		}
+		return db
+	}
+}
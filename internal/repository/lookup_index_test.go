@@ -0,0 +1,83 @@
+package repository_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/models"
+	"github.com/cyb3rkh4l1d/subsapi/internal/repository"
+	"github.com/google/uuid"
+)
+
+// seedUsers inserts perUser subscriptions spread over 10 services for each of users generated users,
+// and returns the ID of the first one.
+// seedUsers вставляет perUser подписок на 10 сервисов для каждого из users сгенерированных пользователей
+// и возвращает ID первого из них.
+func seedUsers(tb testing.TB, repo *repository.SubscriptionRepository, users, perUser int) string {
+	tb.Helper()
+	subs := make([]models.Subscription, 0, users*perUser)
+	for u := range users {
+		userID := uuid.NewSHA1(uuid.NameSpaceOID, fmt.Appendf(nil, "user %d", u)).String()
+		for i := range perUser {
+			subs = append(subs, models.Subscription{
+				UserID:      userID,
+				ServiceName: fmt.Sprintf("Service %d", i%10),
+				Price:       100 + i%7*50,
+				StartDate:   time.Date(2024, time.Month(1+i%12), 1, 0, 0, 0, 0, time.UTC),
+			})
+		}
+	}
+	if err := repo.DB.CreateInBatches(subs, 500).Error; err != nil {
+		tb.Fatal(err)
+	}
+	return subs[0].UserID
+}
+
+// BenchmarkCalculateTotalCost computes the total cost of one service of one user among many, as the
+// summary does, with and without the case-insensitive lookup index.
+// BenchmarkCalculateTotalCost вычисляет общую стоимость одного сервиса одного пользователя среди многих,
+// как это делает сводка, с индексом регистронезависимого поиска и без него.
+func BenchmarkCalculateTotalCost(b *testing.B) {
+	for _, indexed := range []bool{true, false} {
+		b.Run(fmt.Sprintf("indexed=%t", indexed), func(b *testing.B) {
+			repo := repository.NewTestDB(b)
+			userID := seedUsers(b, repo, 2000, 10)
+			if !indexed {
+				if err := repo.DB.Exec("DROP INDEX idx_subscriptions_user_service_start").Error; err != nil {
+					b.Fatal(err)
+				}
+			}
+			_, live := newSummaryServices(repo)
+			req := &models.UserSubscriptionSummaryRequest{UserID: userID, ServiceName: "service 3", DateRange: models.DateRange{From: "01-2024", To: "12-2025"}}
+
+			b.ResetTimer()
+			for b.Loop() {
+				if _, _, _, _, err := live.GetUserSubscriptionSummary(context.Background(), req); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestSummaryLookupUsesIndex(t *testing.T) {
+	repository.SkipUnlessPostgres(t)
+	repo := repository.NewTestDB(t)
+	userID := seedUsers(t, repo, 2000, 10)
+	// refresh the statistics the planner estimates the row counts from
+	// обновить статистику, по которой планировщик оценивает количество строк
+	if err := repo.DB.Exec("ANALYZE subscriptions").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := repo.ExplainSubscriptionsByUserIDandServiceName(context.Background(), userID, "SERVICE 3", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(plan, `"Index Name": "idx_subscriptions_user_service_start"`) {
+		t.Errorf("plan doesn't use idx_subscriptions_user_service_start: %s", plan)
+	}
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// retryBaseDelay is the backoff before the first retry, doubled on every further attempt.
+// retryBaseDelay — задержка перед первой повторной попыткой, удваиваемая при каждой следующей.
+const retryBaseDelay = 50 * time.Millisecond
+
+// retryableCodes lists the PostgreSQL error codes of transient failures worth retrying:
+// serialization failures and deadlocks.
+// retryableCodes перечисляет коды ошибок PostgreSQL для временных сбоев, которые стоит повторить:
+// ошибки сериализации и взаимоблокировки.
+var retryableCodes = map[string]bool{
+	"40001": true, // serialization_failure
+	"40P01": true, // deadlock_detected
+}
+
+// isRetryable reports whether err is a transient PostgreSQL error.
+// isRetryable сообщает, является ли err временной ошибкой PostgreSQL.
+func isRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && retryableCodes[pgErr.Code]
+}
+
+// withRetry runs fn in a transaction, retrying it up to MaxRetries times with jittered
+// exponential backoff when it fails with a transient error.
+// withRetry выполняет fn в транзакции, повторяя ее до MaxRetries раз с экспоненциальной
+// задержкой со случайным разбросом, если она завершилась временной ошибкой.
+func (r *SubscriptionRepository) withRetry(ctx context.Context, db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = db.Transaction(fn)
+		if err == nil || !isRetryable(err) || attempt >= r.MaxRetries {
+			return err
+		}
+
+		// full jitter: wait a random duration up to the exponential backoff
+		// полный разброс: ожидание случайной длительности до экспоненциальной задержки
+		backoff := retryBaseDelay << attempt
+		delay := time.Duration(rand.Int64N(int64(backoff))) + 1
+		r.Logger.WithError(err).Warnf("transient database error, retrying in %+v (attempt %+v/%+v)", delay, attempt+1, r.MaxRetries)
+
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(delay):
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"serialization failure", &pgconn.PgError{Code: "40001"}, true},
+		{"deadlock", &pgconn.PgError{Code: "40P01"}, true},
+		{"wrapped serialization failure", fmt.Errorf("saving: %w", &pgconn.PgError{Code: "40001"}), true},
+		{"unique violation", &pgconn.PgError{Code: "23505"}, false},
+		{"plain error", errors.New("connection refused"), false},
+		{"no error", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryable(tt.err); got != tt.want {
+				t.Errorf("isRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	serializationFailure := &pgconn.PgError{Code: "40001"}
+
+	tests := []struct {
+		name       string
+		maxRetries int
+		failures   []error
+		wantCalls  int
+		wantErr    error
+	}{
+		{"succeeds on retry", 3, []error{serializationFailure}, 2, nil},
+		{"gives up after max retries", 2, []error{serializationFailure, serializationFailure, serializationFailure}, 3, serializationFailure},
+		{"does not retry other errors", 3, []error{gorm.ErrInvalidData}, 1, gorm.ErrInvalidData},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewTestDB(t)
+			repo.MaxRetries = tt.maxRetries
+
+			calls := 0
+			err := repo.withRetry(context.Background(), repo.DB, func(*gorm.DB) error {
+				calls++
+				if calls <= len(tt.failures) {
+					return tt.failures[calls-1]
+				}
+				return nil
+			})
+			if !errors.Is(err, tt.wantErr) || calls != tt.wantCalls {
+				t.Errorf("got %v after %d calls, want %v after %d", err, calls, tt.wantErr, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package repository_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/models"
+	"github.com/cyb3rkh4l1d/subsapi/internal/repository"
+	"github.com/cyb3rkh4l1d/subsapi/internal/service"
+	"github.com/cyb3rkh4l1d/subsapi/internal/utils"
+)
+
+var userIDs = []string{"a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", "b0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"}
+
+// seedRevenue creates n subscriptions spread over 2023-2025, fixed-term and open-ended, of two users.
+// seedRevenue создает n подписок в 2023-2025 годах, срочных и бессрочных, двух пользователей.
+func seedRevenue(tb testing.TB, repo *repository.SubscriptionRepository, n int) []models.Subscription {
+	tb.Helper()
+	subs := make([]models.Subscription, 0, n)
+	for i := range n {
+		sub := models.Subscription{
+			UserID:      userIDs[i%len(userIDs)],
+			ServiceName: fmt.Sprintf("Service %d", i%7),
+			Price:       100 + i%13*50,
+			StartDate:   time.Date(2023, time.Month(1+i%12), 1, 0, 0, 0, 0, time.UTC).AddDate(i%3, 0, 0),
+		}
+		if i%4 != 0 {
+			end := sub.StartDate.AddDate(0, i%19, 0)
+			sub.EndDate = &end
+		}
+		if err := repo.CreateSubscription(context.Background(), &sub); err != nil {
+			tb.Fatal(err)
+		}
+		subs = append(subs, sub)
+	}
+	return subs
+}
+
+// revenueInMemory sums the prices of subs per month with AddOverlapMonths, as the summaries do in memory.
+// revenueInMemory суммирует цены subs по месяцам через AddOverlapMonths, как сводки делают в памяти.
+func revenueInMemory(subs []models.Subscription, from, to time.Time, userID string) map[string]int64 {
+	revenue := make(map[string]int64)
+	for _, sub := range subs {
+		if userID != "" && sub.UserID != userID {
+			continue
+		}
+		start, end := utils.MaxTime(sub.StartDate, from), to
+		if sub.EndDate != nil {
+			end = utils.MinTime(*sub.EndDate, to)
+		}
+		if start.After(end) {
+			continue
+		}
+		months := make(map[string]bool)
+		service.AddOverlapMonths(months, start, end)
+		for month := range months {
+			revenue[month] += int64(sub.Price)
+		}
+	}
+	return revenue
+}
+
+func TestRevenueByMonthMatchesAddOverlapMonths(t *testing.T) {
+	repository.SkipUnlessPostgres(t)
+	repo := repository.NewTestDB(t)
+	subs := seedRevenue(t, repo, 200)
+	from, to := time.Date(2022, time.June, 1, 0, 0, 0, 0, time.UTC), time.Date(2027, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, userID := range append([]string{""}, userIDs...) {
+		t.Run("user "+userID, func(t *testing.T) {
+			revenue, err := repo.RevenueByMonth(context.Background(), from, to, repository.RevenueFilter{UserID: userID})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := utils.MonthSpan(from, to); len(revenue) != want {
+				t.Fatalf("%d months, want %d", len(revenue), want)
+			}
+			want := revenueInMemory(subs, from, to, userID)
+			for _, month := range revenue {
+				key := fmt.Sprintf("%d-%02d", month.Month.Year(), month.Month.Month())
+				if month.Revenue != want[key] {
+					t.Errorf("%s: SQL revenue %d, AddOverlapMonths %d", key, month.Revenue, want[key])
+				}
+			}
+		})
+	}
+}
+
+func TestRevenueByMonthOfOneSubscription(t *testing.T) {
+	repository.SkipUnlessPostgres(t)
+	repo := repository.NewTestDB(t)
+	subs := seedRevenue(t, repo, 5)
+
+	// the fourth subscription runs from April to July 2023
+	// четвертая подписка действует с апреля по июль 2023 года
+	from, to := time.Date(2023, time.March, 1, 0, 0, 0, 0, time.UTC), time.Date(2023, time.August, 1, 0, 0, 0, 0, time.UTC)
+	revenue, err := repo.RevenueByMonth(context.Background(), from, to, repository.RevenueFilter{SubscriptionID: subs[3].ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []int64
+	for _, month := range revenue {
+		got = append(got, month.Revenue)
+	}
+	price := int64(subs[3].Price)
+	if want := []int64{0, price, price, price, price, 0}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("revenue = %v, want %v", got, want)
+	}
+}
+
+func BenchmarkRevenueByMonth(b *testing.B) {
+	repository.SkipUnlessPostgres(b)
+	repo := repository.NewTestDB(b)
+	seedRevenue(b, repo, 5000)
+	from, to := time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, time.December, 1, 0, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	b.Run("sql", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := repo.RevenueByMonth(ctx, from, to, repository.RevenueFilter{UserID: userIDs[0]}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("in memory", func(b *testing.B) {
+		for b.Loop() {
+			subs, err := repo.FindSubscriptionsByUserIDandServiceName(ctx, userIDs[0], "", false)
+			if err != nil {
+				b.Fatal(err)
+			}
+			revenueInMemory(subs, from, to, "")
+		}
+	})
+}
@@ -3,22 +3,79 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/cyb3rkh4l1d/subsapi/internal/models"
+	"github.com/cyb3rkh4l1d/subsapi/internal/tenancy"
+	"github.com/cyb3rkh4l1d/subsapi/internal/utils"
 	"github.com/cyb3rkh4l1d/subsapi/internal/validations"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Repository defines data access operations for subscription management
 // Репозиторий определяет операции доступа к данным для управления подписками
 type Repository interface {
 	CreateSubscription(ctx context.Context, sub *models.Subscription) error
+	UpsertSubscription(ctx context.Context, sub *models.Subscription) (bool, error)
 	GetSubscriptionByID(ctx context.Context, id uint) (*models.Subscription, error)
+	GetSubscriptionsByIDs(ctx context.Context, ids []uint) ([]models.Subscription, error)
+	GetSubscriptionsByPublicIDs(ctx context.Context, publicIDs []string) ([]models.Subscription, error)
 	ListSubscription(ctx context.Context, req *models.ListSubscriptionRequest) (int64, []models.Subscription, error)
+	ListPaginated(ctx context.Context, limit int, afterID uint) ([]models.Subscription, error)
+	CountSubscriptions(ctx context.Context, status, search string) (int64, error)
+	SumSubscriptionPrices(ctx context.Context, status, search string) (int64, error)
 	UpdateSubscriptionByID(ctx context.Context, sub *models.Subscription) error
 	DeleteSubscriptionByID(ctx context.Context, id uint) error
-	FindSubscriptionsByUserIDandServiceName(ctx context.Context, userID string, serviceName string) ([]models.Subscription, error)
+	DeleteSubscriptionsByUserID(ctx context.Context, userID string) (int64, error)
+	FindSubscriptionsByUserIDandServiceName(ctx context.Context, userID string, serviceName string, exact bool) ([]models.Subscription, error)
+	CountDistinctUsers(ctx context.Context, activeAt *time.Time) (int64, error)
+	FindSubscriptionsByUserIDPrefix(ctx context.Context, prefix string, limit, offset int) (int64, []models.Subscription, error)
+	AveragePriceByServiceName(ctx context.Context, serviceName string, excludeID uint) (float64, int64, error)
+	PriceStatsByServiceName(ctx context.Context, serviceName string) (*PriceStats, error)
+	NormalizeDates(ctx context.Context, batchSize int) (int64, error)
+	FindSubscriptionsByParentIDs(ctx context.Context, parentIDs []uint) ([]models.Subscription, error)
+	FindSubscriptionsByUserIDs(ctx context.Context, userIDs []string) ([]models.Subscription, error)
+	FindSubscriptionsByUserIDInBatches(ctx context.Context, userID string, batchSize int, fn func(batch []models.Subscription) error) error
+	ExplainSubscriptionsByUserIDandServiceName(ctx context.Context, userID string, serviceName string, exact bool) (string, error)
+	FindExpiring(ctx context.Context, from, to time.Time, afterID uint, limit int) ([]models.Subscription, error)
+	ClaimReminder(ctx context.Context, id uint, sentAt time.Time) (bool, error)
+	ReleaseReminder(ctx context.Context, id uint) error
+	RevenueByMonth(ctx context.Context, from, to time.Time, filter RevenueFilter) ([]MonthlyRevenue, error)
+	GetSummaryCacheState(ctx context.Context, userID string) (*models.SummaryCacheState, error)
+	FindSummaryMonths(ctx context.Context, userID string, from, to time.Time) ([]models.SummaryMonth, error)
+	FindSummaryServices(ctx context.Context, userID string) ([]models.SummaryService, error)
+	ReplaceSummaryCache(ctx context.Context, userID string, services []models.SummaryService, months []models.SummaryMonth, refreshedAt, horizon time.Time) error
+	InvalidateSummaryCache(ctx context.Context, userID string, at time.Time) error
+	DeleteSummaryCache(ctx context.Context, userID string) error
+	FindStaleSummaryUsers(ctx context.Context, refreshedBefore time.Time, after SummaryCacheKey, limit int) ([]SummaryCacheKey, error)
+}
+
+// RevenueFilter narrows RevenueByMonth to the subscriptions of one user or to a single subscription,
+// the zero value covers every subscription.
+// RevenueFilter ограничивает RevenueByMonth подписками одного пользователя или одной подпиской,
+// нулевое значение охватывает все подписки.
+type RevenueFilter struct {
+	UserID         string
+	SubscriptionID uint
+}
+
+// MonthlyRevenue is the summed price of the subscriptions active in one month.
+// MonthlyRevenue — сумма цен подписок, активных в одном месяце.
+type MonthlyRevenue struct {
+	Month   time.Time
+	Revenue int64
+}
+
+// PriceStats holds the price aggregates of the subscriptions of one service.
+// PriceStats содержит агрегаты цен подписок одного сервиса.
+type PriceStats struct {
+	Count   int64
+	Average float64
+	Median  float64
+	Min     int
+	Max     int
 }
 
 // SubscriptionRepository manages CRUD operations for subscriptions.
@@ -26,10 +83,16 @@ type Repository interface {
 // SubscriptionRepository управляет операциями CRUD для подписок.
 // Он использует GORM для доступа к базе данных и Logrus для ведения журналов.
 type SubscriptionRepository struct {
-	DB     *gorm.DB
-	Logger *logrus.Entry
+	DB         *gorm.DB
+	Logger     *logrus.Entry
+	MaxRows    int
+	MaxRetries int
 }
 
+// Ensures SubscriptionRepository keeps satisfying the Repository interface the service depends on.
+// Гарантирует, что SubscriptionRepository продолжает реализовывать интерфейс Repository, от которого зависит сервис.
+var _ Repository = (*SubscriptionRepository)(nil)
+
 /*
 .....................................................................
 
@@ -38,18 +101,63 @@ type SubscriptionRepository struct {
 ........................................................................
 */
 // NewSubscriptionRepository initializes a new repository instance.
+// maxRows caps the rows returned by unpaginated queries, 0 leaves them unbounded.
+// maxRetries bounds the retries of transactions failing with a transient error.
 // NewSubscriptionRepository инициализирует новый экземпляр репозитория.
-func NewSubscriptionRepository(db *gorm.DB, logger *logrus.Entry) *SubscriptionRepository {
+// maxRows ограничивает количество строк, возвращаемых запросами без пагинации, 0 — без ограничения.
+// maxRetries ограничивает число повторов транзакций, завершившихся временной ошибкой.
+func NewSubscriptionRepository(db *gorm.DB, logger *logrus.Entry, maxRows, maxRetries int) *SubscriptionRepository {
 	return &SubscriptionRepository{
-		DB:     db,
-		Logger: logger,
+		DB:         db,
+		Logger:     logger,
+		MaxRows:    maxRows,
+		MaxRetries: maxRetries,
+	}
+}
+
+// conn returns a context-bound database session, or ErrDbInitializationFailed when the repository
+// was built without a database, so misconfiguration surfaces as an error instead of a nil dereference.
+// When ctx carries a tenant, every query of the session, transactions included, is scoped to it.
+// conn возвращает сессию базы данных с контекстом или ErrDbInitializationFailed, если репозиторий
+// создан без базы данных, чтобы ошибка конфигурации не приводила к разыменованию nil.
+// Если ctx содержит арендатора, каждый запрос сессии, включая транзакции, ограничивается им.
+func (r *SubscriptionRepository) conn(ctx context.Context) (*gorm.DB, error) {
+	if r.DB == nil {
+		r.Logger.Error(validations.ErrDbInitializationFailed)
+		return nil, validations.ErrDbInitializationFailed
+	}
+	db := r.DB.WithContext(ctx)
+	if tenantID, ok := tenancy.FromContext(ctx); ok {
+		// a new session keeps the condition on every statement derived from it
+		// новая сессия сохраняет условие во всех производных от нее запросах
+		db = db.Where("tenant_id = ?", tenantID).Session(&gorm.Session{})
 	}
+	return db, nil
+}
+
+// checkRowCap reports ErrResultTooLarge when a query capped by rowCap returned more than MaxRows rows,
+// rather than silently computing over a truncated result.
+// checkRowCap возвращает ErrResultTooLarge, если запрос, ограниченный rowCap, вернул больше MaxRows строк,
+// вместо того чтобы молча вычислять по усеченному результату.
+func (r *SubscriptionRepository) checkRowCap(rows int) error {
+	if r.MaxRows > 0 && rows > r.MaxRows {
+		r.Logger.Warnf("%+v: more than %+v rows", validations.ErrResultTooLarge, r.MaxRows)
+		return validations.ErrResultTooLarge
+	}
+	return nil
 }
 
 // CreateSubscription inserts a new subscription into the database.
 // Функция CreateSubscription вставляет новую подписку в базу данных.
 func (r *SubscriptionRepository) CreateSubscription(ctx context.Context, sub *models.Subscription) error {
-	result := r.DB.WithContext(ctx).Create(sub)
+	db, err := r.conn(ctx)
+	if err != nil {
+		return err
+	}
+	if tenantID, ok := tenancy.FromContext(ctx); ok {
+		sub.TenantID = tenantID
+	}
+	result := db.Create(sub)
 
 	if result.Error != nil {
 		r.Logger.WithError(result.Error).Error(validations.ErrCreateSubscriptionFailed)
@@ -60,11 +168,83 @@ func (r *SubscriptionRepository) CreateSubscription(ctx context.Context, sub *mo
 	return nil
 }
 
+// upsertKeyColumns are the columns of the partial unique index ON CONFLICT targets on upsert.
+// upsertKeyColumns — столбцы частичного уникального индекса, на который ссылается ON CONFLICT при upsert.
+var upsertKeyColumns = []clause.Column{{Name: "tenant_id"}, {Name: "user_id"}, {Name: "LOWER(service_name)", Raw: true}, {Name: "start_date"}}
+
+// UpsertSubscription inserts sub as an upserted subscription or, when an upserted subscription of the same
+// user and service, in any case, starts the same month, updates that one with it instead. It reports whether
+// sub was inserted. Only upserted subscriptions are unique per key, plain creates may still duplicate it.
+// A kept end_date keeps its expiry reminder.
+// UpsertSubscription вставляет sub как подписку upsert или, если подписка upsert того же пользователя на тот же
+// сервис, в любом регистре, начинается в том же месяце, обновляет ее вместо этого. Сообщает, была ли sub вставлена.
+// Уникальны по ключу только подписки upsert, обычное создание по-прежнему может его дублировать.
+// Неизмененная end_date сохраняет свое напоминание.
+func (r *SubscriptionRepository) UpsertSubscription(ctx context.Context, sub *models.Subscription) (bool, error) {
+	db, err := r.conn(ctx)
+	if err != nil {
+		return false, err
+	}
+	tenantID, _ := tenancy.FromContext(ctx)
+	sub.TenantID = tenantID
+	sub.Upserted = true
+
+	var created bool
+	err = r.withRetry(ctx, db, func(tx *gorm.DB) error {
+		// the insert waits for a concurrent upsert of the key and skips when it exists
+		// вставка ожидает параллельный upsert ключа и пропускается, если он уже существует
+		sub.ID = 0
+		insert := tx.Clauses(clause.OnConflict{
+			Columns:     upsertKeyColumns,
+			TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "upserted"}}},
+			DoNothing:   true,
+		}).Create(sub)
+		if insert.Error != nil {
+			return insert.Error
+		}
+		if created = insert.RowsAffected > 0; created {
+			return nil
+		}
+
+		// the end_date compared is the stored one, SET expressions read the row before the update
+		// сравнивается сохраненная end_date, выражения SET читают строку до обновления
+		reminder := gorm.Expr("CASE WHEN end_date IS NULL THEN reminder_sent_at END")
+		if sub.EndDate != nil {
+			reminder = gorm.Expr("CASE WHEN end_date = ? THEN reminder_sent_at END", *sub.EndDate)
+		}
+		key := tx.Model(&models.Subscription{}).Where("upserted AND tenant_id = ? AND user_id = ? AND LOWER(service_name) = ? AND start_date = ?",
+			tenantID, sub.UserID, validations.ServiceNameKey(sub.ServiceName), sub.StartDate)
+		err := key.Session(&gorm.Session{}).Updates(map[string]any{
+			"reminder_sent_at": reminder,
+			"service_name":     sub.ServiceName,
+			"price":            sub.Price,
+			"end_date":         sub.EndDate,
+			"parent_id":        sub.ParentID,
+			"description":      sub.Description,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return key.Session(&gorm.Session{}).Take(sub).Error
+	})
+	if err != nil {
+		r.Logger.WithError(err).Error(validations.ErrUpsertSubscriptionFailed)
+		return false, validations.ErrUpsertSubscriptionFailed
+	}
+
+	r.Logger.Infof("subscription %+v has been upserted, created: %+v", sub.ID, created)
+	return created, nil
+}
+
 // GetSubscriptionByID retrieves a subscription by its ID.
 // Функция GetBGetSubscriptionByIDyID извлекает подписку по ее идентификатору.
 func (r *SubscriptionRepository) GetSubscriptionByID(ctx context.Context, id uint) (*models.Subscription, error) {
+	db, err := r.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
 	var sub models.Subscription
-	if err := r.DB.WithContext(ctx).First(&sub, id).Error; err != nil {
+	if err := db.First(&sub, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
@@ -75,36 +255,135 @@ func (r *SubscriptionRepository) GetSubscriptionByID(ctx context.Context, id uin
 	return &sub, nil
 }
 
+// GetSubscriptionsByIDs fetches the subscriptions with the given IDs in one query, ordered by ID.
+// GetSubscriptionsByIDs получает подписки с указанными ID одним запросом, упорядоченные по ID.
+func (r *SubscriptionRepository) GetSubscriptionsByIDs(ctx context.Context, ids []uint) ([]models.Subscription, error) {
+	db, err := r.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	subs := make([]models.Subscription, 0, len(ids))
+	if err := db.Where("id IN ?", ids).Order("id asc").Find(&subs).Error; err != nil {
+		r.Logger.WithError(err).Error(validations.ErrGetSubscriptionByIDFailed)
+		return nil, validations.ErrGetSubscriptionByIDFailed
+	}
+	return subs, nil
+}
+
+// GetSubscriptionsByPublicIDs fetches the subscriptions with the given public UUIDs in one query, ordered by ID.
+// GetSubscriptionsByPublicIDs получает подписки с указанными публичными UUID одним запросом, упорядоченные по ID.
+func (r *SubscriptionRepository) GetSubscriptionsByPublicIDs(ctx context.Context, publicIDs []string) ([]models.Subscription, error) {
+	db, err := r.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	subs := make([]models.Subscription, 0, len(publicIDs))
+	if err := db.Where("public_id IN ?", publicIDs).Order("id asc").Find(&subs).Error; err != nil {
+		r.Logger.WithError(err).Error(validations.ErrGetSubscriptionByIDFailed)
+		return nil, validations.ErrGetSubscriptionByIDFailed
+	}
+	return subs, nil
+}
+
 // ListSubscription fetches all subscriptions.
 // ListSubscription получает все подписки.
 func (r *SubscriptionRepository) ListSubscription(ctx context.Context, req *models.ListSubscriptionRequest) (int64, []models.Subscription, error) {
-	var total int64
-	var subs []models.Subscription
+	db, err := r.conn(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	subs := make([]models.Subscription, 0)
 	orderClause := req.SortBy + " " + req.Order
 
-	// count all subscriptions
-	// подсчитать все подписки
-	if err := r.DB.WithContext(ctx).Model(&models.Subscription{}).Count(&total).Error; err != nil {
-		r.Logger.WithError(err).Error(validations.ErrListSubscriptionFailed)
-		return total, nil, validations.ErrListSubscriptionFailed
+	// count all subscriptions matching the filters
+	// подсчитать все подписки, соответствующие фильтрам
+	total, err := r.CountSubscriptions(ctx, req.Status, req.Search)
+	if err != nil {
+		return total, nil, err
 	}
 
 	//retrieves user's subscriptions with filtering, pagination, and sorting
 	//Получает подписки пользователей с фильтрацией, пагинацией и сортировкой.
-	if err := r.DB.WithContext(ctx).Limit(req.Limit).Offset(req.Offset).Order(orderClause).Find(&subs).Error; err != nil {
+	if err := db.Scopes(statusFilter(req.Status), searchFilter(req.Search)).Limit(req.Limit).Offset(req.Offset).Order(orderClause).Find(&subs).Error; err != nil {
 		r.Logger.WithError(err).Error(validations.ErrListSubscriptionFailed)
 		return total, nil, validations.ErrListSubscriptionFailed
 	}
 	return total, subs, nil
 }
 
+// ListPaginated returns up to limit subscriptions with an id above afterID in ascending id order,
+// keyset pagination that seeks by the primary key instead of skipping rows.
+// ListPaginated возвращает до limit подписок с id больше afterID в порядке возрастания id,
+// keyset-пагинация, переходящая по первичному ключу вместо пропуска строк.
+func (r *SubscriptionRepository) ListPaginated(ctx context.Context, limit int, afterID uint) ([]models.Subscription, error) {
+	db, err := r.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	subs := make([]models.Subscription, 0, limit)
+	if err := db.Where("id > ?", afterID).Order("id ASC").Limit(limit).Find(&subs).Error; err != nil {
+		r.Logger.WithError(err).Error(validations.ErrListSubscriptionFailed)
+		return nil, validations.ErrListSubscriptionFailed
+	}
+	return subs, nil
+}
+
+// CountSubscriptions counts the subscriptions matching the status and search filters shared with ListSubscription,
+// an empty status and search count every subscription.
+// CountSubscriptions подсчитывает подписки по фильтрам статуса и поиска, общим с ListSubscription,
+// пустые статус и поиск подсчитывают все подписки.
+func (r *SubscriptionRepository) CountSubscriptions(ctx context.Context, status, search string) (int64, error) {
+	db, err := r.conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	if err := db.Model(&models.Subscription{}).Scopes(statusFilter(status), searchFilter(search)).Count(&total).Error; err != nil {
+		r.Logger.WithError(err).Error(validations.ErrListSubscriptionFailed)
+		return 0, validations.ErrListSubscriptionFailed
+	}
+	return total, nil
+}
+
+// SumSubscriptionPrices sums the monthly prices of the subscriptions matching the status and search filters shared with ListSubscription.
+// SumSubscriptionPrices суммирует месячные цены подписок по фильтрам статуса и поиска, общим с ListSubscription.
+func (r *SubscriptionRepository) SumSubscriptionPrices(ctx context.Context, status, search string) (int64, error) {
+	db, err := r.conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var sum int64
+	if err := db.Model(&models.Subscription{}).Scopes(statusFilter(status), searchFilter(search)).Select("COALESCE(SUM(price), 0)").Scan(&sum).Error; err != nil {
+		r.Logger.WithError(err).Error(validations.ErrSumPricesFailed)
+		return 0, validations.ErrSumPricesFailed
+	}
+	return sum, nil
+}
+
 // UpdateSubscription updates given subscription by its ID
 // Функция UpdateSubscription обновляет указанную подписку по ее идентификатору.
 func (r *SubscriptionRepository) UpdateSubscriptionByID(ctx context.Context, sub *models.Subscription) error {
-	if err := r.DB.WithContext(ctx).Save(sub).Error; err != nil {
+	db, err := r.conn(ctx)
+	if err != nil {
+		return err
+	}
+	// retry the update on serialization failures and deadlocks. Unlike Save, an update matching no row
+	// does not fall back to an insert, which would overwrite the row of another tenant
+	// повторить обновление при ошибках сериализации и взаимоблокировках. В отличие от Save, обновление без
+	// совпавшей строки не переходит к вставке, которая перезаписала бы строку другого арендатора
+	var updated int64
+	err = r.withRetry(ctx, db, func(tx *gorm.DB) error {
+		result := tx.Model(sub).Select("*").Updates(sub)
+		updated = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
 		r.Logger.WithError(err).Error(validations.ErrUpdateSubscriptionFailed)
 		return validations.ErrUpdateSubscriptionFailed
 	}
+	if updated == 0 {
+		return validations.ErrSubscriptionNotFound
+	}
 	r.Logger.Infof("subscription %+v has been updated successfully: ", sub.ID)
 	return nil
 }
@@ -112,7 +391,11 @@ func (r *SubscriptionRepository) UpdateSubscriptionByID(ctx context.Context, sub
 // DeleteSubscription removes a subscription by ID.
 // Функция DeleteSubscription удаляет подписку по ID.
 func (r *SubscriptionRepository) DeleteSubscriptionByID(ctx context.Context, id uint) error {
-	if err := r.DB.WithContext(ctx).Delete(&models.Subscription{}, id).Error; err != nil {
+	db, err := r.conn(ctx)
+	if err != nil {
+		return err
+	}
+	if err := db.Delete(&models.Subscription{}, id).Error; err != nil {
 		r.Logger.WithError(err).Error(validations.ErrDeleteSubscriptionFailed)
 		return validations.ErrDeleteSubscriptionFailed
 	}
@@ -120,22 +403,375 @@ func (r *SubscriptionRepository) DeleteSubscriptionByID(ctx context.Context, id
 	return nil
 }
 
+// DeleteSubscriptionsByUserID removes every subscription of a user in one transaction and returns how many were removed.
+// Members of a removed family plan are detached by the foreign key.
+// DeleteSubscriptionsByUserID удаляет все подписки пользователя в одной транзакции и возвращает их количество.
+// Участники удаленного семейного плана отвязываются внешним ключом.
+func (r *SubscriptionRepository) DeleteSubscriptionsByUserID(ctx context.Context, userID string) (int64, error) {
+	db, err := r.conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var deleted int64
+	err = r.withRetry(ctx, db, func(tx *gorm.DB) error {
+		result := tx.Scopes(userServiceFilter(userID, "", false)).Delete(&models.Subscription{})
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		r.Logger.WithError(err).Error(validations.ErrDeleteSubscriptionFailed)
+		return 0, validations.ErrDeleteSubscriptionFailed
+	}
+	return deleted, nil
+}
+
 // FindSubscriptionsByUserIDandServiceName Get subscriptions filtered by user and service_name
+// An empty serviceName returns all of the user's subscriptions. The service name matches
+// case-insensitively unless exact is set. The result is never nil.
 // FindSubscriptionsByUserIDandServiceName Получает подписки, отфильтрованные по пользователю и имени сервиса.
+// Пустой serviceName возвращает все подписки пользователя. Имя сервиса сравнивается
+// без учета регистра, если не задан exact. Результат никогда не равен nil.
 func (r *SubscriptionRepository) FindSubscriptionsByUserIDandServiceName(
 	ctx context.Context,
 	userID string,
 	serviceName string,
+	exact bool,
 ) ([]models.Subscription, error) {
-	query := r.DB.WithContext(ctx).Model(&models.Subscription{}).
-		Where("user_id = ? AND service_name = ?", userID, serviceName)
-
-	var subscriptions []models.Subscription
-	if err := query.Find(&subscriptions).Error; err != nil {
+	db, err := r.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	subscriptions := make([]models.Subscription, 0)
+	if err := db.Scopes(userServiceFilter(userID, serviceName, exact), rowCap(r.MaxRows)).Find(&subscriptions).Error; err != nil {
 		r.Logger.WithError(err).Error(validations.ErrFindSubscriptionByPeriodFailed)
 		return nil, err
 	}
+	if err := r.checkRowCap(len(subscriptions)); err != nil {
+		return nil, err
+	}
 
 	r.Logger.Infof("subscriptions for user %+v has been fetched: %+v", userID, subscriptions)
 	return subscriptions, nil
 }
+
+// FindSubscriptionsByUserIDs fetches the subscriptions of several users in one query. The result is never nil.
+// FindSubscriptionsByUserIDs получает подписки нескольких пользователей одним запросом. Результат никогда не равен nil.
+func (r *SubscriptionRepository) FindSubscriptionsByUserIDs(ctx context.Context, userIDs []string) ([]models.Subscription, error) {
+	db, err := r.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	subscriptions := make([]models.Subscription, 0)
+	if err := db.Where("user_id IN ?", userIDs).Scopes(rowCap(r.MaxRows)).Find(&subscriptions).Error; err != nil {
+		r.Logger.WithError(err).Error(validations.ErrFindSubscriptionByPeriodFailed)
+		return nil, validations.ErrFindSubscriptionByPeriodFailed
+	}
+	if err := r.checkRowCap(len(subscriptions)); err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+// FindSubscriptionsByUserIDInBatches walks every subscription of a user in ID order, batchSize rows at a time,
+// so exports aren't bound by MaxRows nor load the whole table in one query.
+// FindSubscriptionsByUserIDInBatches обходит все подписки пользователя в порядке ID по batchSize строк,
+// чтобы экспорт не ограничивался MaxRows и не загружал всю таблицу одним запросом.
+func (r *SubscriptionRepository) FindSubscriptionsByUserIDInBatches(ctx context.Context, userID string, batchSize int, fn func(batch []models.Subscription) error) error {
+	db, err := r.conn(ctx)
+	if err != nil {
+		return err
+	}
+	var batch []models.Subscription
+	result := db.Scopes(userServiceFilter(userID, "", false)).FindInBatches(&batch, batchSize, func(tx *gorm.DB, _ int) error {
+		return fn(batch)
+	})
+	if result.Error != nil {
+		r.Logger.WithError(result.Error).Error(validations.ErrFindSubscriptionByPeriodFailed)
+		return validations.ErrFindSubscriptionByPeriodFailed
+	}
+	return nil
+}
+
+// ExplainSubscriptionsByUserIDandServiceName runs EXPLAIN (ANALYZE, FORMAT JSON) on the query behind
+// the summaries and returns the JSON plan. The query is built by the same scopes, with bound parameters.
+// ExplainSubscriptionsByUserIDandServiceName выполняет EXPLAIN (ANALYZE, FORMAT JSON) для запроса сводок
+// и возвращает план в формате JSON. Запрос строится теми же scope с привязанными параметрами.
+func (r *SubscriptionRepository) ExplainSubscriptionsByUserIDandServiceName(ctx context.Context, userID string, serviceName string, exact bool) (string, error) {
+	db, err := r.conn(ctx)
+	if err != nil {
+		return "", err
+	}
+	stmt := db.Session(&gorm.Session{DryRun: true}).
+		Scopes(userServiceFilter(userID, serviceName, exact), rowCap(r.MaxRows)).
+		Find(&[]models.Subscription{}).Statement
+
+	var plan string
+	if err := db.Raw("EXPLAIN (ANALYZE, FORMAT JSON) "+stmt.SQL.String(), stmt.Vars...).Row().Scan(&plan); err != nil {
+		r.Logger.WithError(err).Error(validations.ErrExplainFailed)
+		return "", validations.ErrExplainFailed
+	}
+	return plan, nil
+}
+
+// CountDistinctUsers counts distinct user_ids owning at least one subscription.
+// When activeAt is set, only subscriptions active in the month of activeAt are considered.
+// CountDistinctUsers подсчитывает уникальные user_id, имеющие хотя бы одну подписку.
+// Если задан activeAt, учитываются только подписки, активные в месяце activeAt.
+func (r *SubscriptionRepository) CountDistinctUsers(ctx context.Context, activeAt *time.Time) (int64, error) {
+	db, err := r.conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var count int64
+	query := db.Model(&models.Subscription{})
+
+	if activeAt != nil {
+		// the same predicate as status=active, evaluated for the month of activeAt
+		// тот же предикат, что и status=active, вычисляемый для месяца activeAt
+		query = query.Scopes(statusFilterAt(models.StatusActive, *activeAt))
+	}
+
+	if err := query.Distinct("user_id").Count(&count).Error; err != nil {
+		r.Logger.WithError(err).Error(validations.ErrCountUsersFailed)
+		return 0, validations.ErrCountUsersFailed
+	}
+	return count, nil
+}
+
+// FindSubscriptionsByUserIDPrefix returns a page of subscriptions whose user_id starts with the given prefix.
+// FindSubscriptionsByUserIDPrefix возвращает страницу подписок, user_id которых начинается с указанного префикса.
+func (r *SubscriptionRepository) FindSubscriptionsByUserIDPrefix(ctx context.Context, prefix string, limit, offset int) (int64, []models.Subscription, error) {
+	db, err := r.conn(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	var total int64
+	subs := make([]models.Subscription, 0)
+	// CAST rather than ::text keeps the lookup portable to the SQLite test database
+	// CAST вместо ::text сохраняет переносимость запроса на тестовую базу SQLite
+	query := db.Model(&models.Subscription{}).Where("CAST(user_id AS TEXT) LIKE ?", prefix+"%")
+
+	// count all matching subscriptions
+	// подсчитать все подходящие подписки
+	if err := query.Count(&total).Error; err != nil {
+		r.Logger.WithError(err).Error(validations.ErrFindSubscriptionByPrefixFailed)
+		return 0, nil, validations.ErrFindSubscriptionByPrefixFailed
+	}
+
+	if err := query.Order("user_id asc, id asc").Limit(limit).Offset(offset).Find(&subs).Error; err != nil {
+		r.Logger.WithError(err).Error(validations.ErrFindSubscriptionByPrefixFailed)
+		return 0, nil, validations.ErrFindSubscriptionByPrefixFailed
+	}
+	return total, subs, nil
+}
+
+// AveragePriceByServiceName returns the average price and the number of subscriptions for a service,
+// matched case-insensitively, ignoring the subscription with excludeID (0 ignores none).
+// AveragePriceByServiceName возвращает среднюю цену и количество подписок на сервис,
+// без учета регистра, не учитывая подписку с excludeID (0 — учитываются все).
+func (r *SubscriptionRepository) AveragePriceByServiceName(ctx context.Context, serviceName string, excludeID uint) (float64, int64, error) {
+	db, err := r.conn(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	var result struct {
+		Average float64
+		Count   int64
+	}
+	query := db.Model(&models.Subscription{}).
+		Select("COALESCE(AVG(price), 0) AS average, COUNT(*) AS count").
+		Where("LOWER(service_name) = ?", validations.ServiceNameKey(serviceName))
+	if excludeID != 0 {
+		query = query.Where("id <> ?", excludeID)
+	}
+
+	if err := query.Scan(&result).Error; err != nil {
+		r.Logger.WithError(err).Error(validations.ErrAveragePriceFailed)
+		return 0, 0, validations.ErrAveragePriceFailed
+	}
+	return result.Average, result.Count, nil
+}
+
+// PriceStatsByServiceName returns the count, average, median, min and max price of the subscriptions of a service,
+// matched case-insensitively, computed by the database. The median averages the middle row or two of the prices
+// ranked by ROW_NUMBER. Every aggregate is 0 when the service has none.
+// PriceStatsByServiceName возвращает количество, среднюю, медианную, минимальную и максимальную цену подписок сервиса
+// без учета регистра, вычисленные базой данных. Медиана усредняет одну или две средние строки цен,
+// упорядоченных ROW_NUMBER. Все агрегаты равны 0, если подписок нет.
+func (r *SubscriptionRepository) PriceStatsByServiceName(ctx context.Context, serviceName string) (*PriceStats, error) {
+	db, err := r.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ranked := db.Model(&models.Subscription{}).
+		Select("price, ROW_NUMBER() OVER (ORDER BY price) AS position, COUNT(*) OVER () AS total").
+		Where("LOWER(service_name) = ?", validations.ServiceNameKey(serviceName))
+
+	// the tenant condition applies to the ranked subquery, the outer query reads only its columns
+	// условие арендатора применяется к подзапросу ranked, внешний запрос читает только его столбцы
+	var stats PriceStats
+	err = r.DB.WithContext(ctx).Table("(?) AS ranked", ranked).
+		Select("COUNT(*) AS count, COALESCE(AVG(price), 0) AS average, " +
+			"COALESCE(AVG(CASE WHEN position IN ((total + 1) / 2, (total + 2) / 2) THEN price END), 0) AS median, " +
+			"COALESCE(MIN(price), 0) AS min, COALESCE(MAX(price), 0) AS max").
+		Scan(&stats).Error
+	if err != nil {
+		r.Logger.WithError(err).Error(validations.ErrPriceStatsFailed)
+		return nil, validations.ErrPriceStatsFailed
+	}
+	return &stats, nil
+}
+
+// FindSubscriptionsByParentIDs returns the family plan members linked to any of the given subscriptions.
+// FindSubscriptionsByParentIDs возвращает участников семейного плана, связанных с любой из указанных подписок.
+func (r *SubscriptionRepository) FindSubscriptionsByParentIDs(ctx context.Context, parentIDs []uint) ([]models.Subscription, error) {
+	members := make([]models.Subscription, 0)
+	if len(parentIDs) == 0 {
+		return members, nil
+	}
+
+	db, err := r.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Where("parent_id IN ?", parentIDs).Order("id asc").Scopes(rowCap(r.MaxRows)).Find(&members).Error; err != nil {
+		r.Logger.WithError(err).Error(validations.ErrFindSubscriptionByParentFailed)
+		return nil, validations.ErrFindSubscriptionByParentFailed
+	}
+	if err := r.checkRowCap(len(members)); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// FindExpiring returns up to limit subscriptions ending between the months from and to, inclusive,
+// whose reminder has not been sent yet. Results are ordered by ID and start after afterID, for keyset pagination.
+// FindExpiring возвращает до limit подписок, заканчивающихся между месяцами from и to включительно,
+// напоминание о которых еще не отправлено. Результаты упорядочены по ID и начинаются после afterID для keyset-пагинации.
+func (r *SubscriptionRepository) FindExpiring(ctx context.Context, from, to time.Time, afterID uint, limit int) ([]models.Subscription, error) {
+	db, err := r.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	subs := make([]models.Subscription, 0)
+	if err := db.Where("end_date BETWEEN ? AND ? AND reminder_sent_at IS NULL AND id > ?", from, to, afterID).
+		Order("id").Limit(limit).Find(&subs).Error; err != nil {
+		r.Logger.WithError(err).Error(validations.ErrFindExpiringFailed)
+		return nil, validations.ErrFindExpiringFailed
+	}
+	return subs, nil
+}
+
+// ClaimReminder marks the reminder of a subscription as sent at sentAt, only if it wasn't already.
+// It reports whether this call claimed it, so concurrent schedulers never send the same reminder twice.
+// ClaimReminder отмечает напоминание подписки как отправленное в sentAt, только если оно еще не отмечено.
+// Возвращает, удалось ли этому вызову его занять, чтобы параллельные планировщики не отправили напоминание дважды.
+func (r *SubscriptionRepository) ClaimReminder(ctx context.Context, id uint, sentAt time.Time) (bool, error) {
+	db, err := r.conn(ctx)
+	if err != nil {
+		return false, err
+	}
+	// UpdateColumn leaves updated_at alone, sending a reminder doesn't modify the subscription
+	// UpdateColumn не трогает updated_at, отправка напоминания не изменяет подписку
+	result := db.Model(&models.Subscription{}).Where("id = ? AND reminder_sent_at IS NULL", id).UpdateColumn("reminder_sent_at", sentAt)
+	if result.Error != nil {
+		r.Logger.WithError(result.Error).Error(validations.ErrReminderUpdateFailed)
+		return false, validations.ErrReminderUpdateFailed
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ReleaseReminder clears the reminder mark of a subscription, so a failed reminder is retried later.
+// ReleaseReminder снимает отметку напоминания подписки, чтобы неудачное напоминание было повторено позже.
+func (r *SubscriptionRepository) ReleaseReminder(ctx context.Context, id uint) error {
+	db, err := r.conn(ctx)
+	if err != nil {
+		return err
+	}
+	if err := db.Model(&models.Subscription{}).Where("id = ?", id).UpdateColumn("reminder_sent_at", nil).Error; err != nil {
+		r.Logger.WithError(err).Error(validations.ErrReminderUpdateFailed)
+		return validations.ErrReminderUpdateFailed
+	}
+	return nil
+}
+
+// RevenueByMonth sums, for every month between from and to inclusive, the prices of the subscriptions
+// matching filter active in it. The months are generated and matched against the subscription intervals
+// by the database, so nothing but one row per month is loaded.
+// RevenueByMonth суммирует для каждого месяца между from и to включительно цены подписок, подходящих под
+// filter и активных в нем. Месяцы генерируются и сопоставляются с интервалами подписок базой данных,
+// поэтому загружается только одна строка на месяц.
+func (r *SubscriptionRepository) RevenueByMonth(ctx context.Context, from, to time.Time, filter RevenueFilter) ([]MonthlyRevenue, error) {
+	db, err := r.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// the tenant scope of conn applies to the subscriptions only, months without any keep a 0 row
+	// область арендатора из conn применяется только к подпискам, месяцы без них сохраняют строку с 0
+	subs := db.Model(&models.Subscription{}).Select("start_date, end_date, price")
+	if filter.UserID != "" {
+		subs = subs.Where("user_id = ?", filter.UserID)
+	}
+	if filter.SubscriptionID != 0 {
+		subs = subs.Where("id = ?", filter.SubscriptionID)
+	}
+
+	revenue := make([]MonthlyRevenue, 0)
+	err = r.DB.WithContext(ctx).
+		Table("generate_series(?::date, ?::date, interval '1 month') AS m(month)", from, to).
+		Select("m.month::date AS month, COALESCE(SUM(s.price), 0) AS revenue").
+		Joins("LEFT JOIN (?) AS s ON s.start_date <= m.month AND (s.end_date IS NULL OR s.end_date >= m.month)", subs).
+		Group("m.month").
+		Order("m.month asc").
+		Scan(&revenue).Error
+	if err != nil {
+		r.Logger.WithError(err).Error(validations.ErrRevenueByMonthFailed)
+		return nil, validations.ErrRevenueByMonthFailed
+	}
+	return revenue, nil
+}
+
+// NormalizeDates rewrites the start and end dates not on the first of their month to it, across every tenant.
+// It reads batchSize rows at a time in ascending id order and updates the misaligned ones, all in one transaction,
+// leaving updated_at alone. It returns the number of rows rewritten.
+// NormalizeDates переписывает даты начала и окончания, не приходящиеся на первое число месяца, на первое число
+// для всех арендаторов. Читает по batchSize строк в порядке возрастания id и обновляет невыровненные в одной
+// транзакции, не изменяя updated_at. Возвращает количество переписанных строк.
+func (r *SubscriptionRepository) NormalizeDates(ctx context.Context, batchSize int) (int64, error) {
+	if r.DB == nil {
+		r.Logger.Error(validations.ErrDbInitializationFailed)
+		return 0, validations.ErrDbInitializationFailed
+	}
+	// unscoped on purpose: the backfill is an admin operation over every tenant
+	// намеренно без области арендатора: заполнение — операция администратора над всеми арендаторами
+	var total int64
+	err := r.withRetry(ctx, r.DB.WithContext(ctx), func(tx *gorm.DB) error {
+		total = 0
+		var batch []models.Subscription
+		return tx.Select("id", "start_date", "end_date").FindInBatches(&batch, batchSize, func(_ *gorm.DB, _ int) error {
+			for _, sub := range batch {
+				start := utils.StartOfMonth(sub.StartDate)
+				end := sub.EndDate
+				if end != nil {
+					aligned := utils.StartOfMonth(*end)
+					end = &aligned
+				}
+				if start.Equal(sub.StartDate) && (end == nil || end.Equal(*sub.EndDate)) {
+					continue
+				}
+				err := tx.Model(&models.Subscription{}).Where("id = ?", sub.ID).
+					UpdateColumns(map[string]any{"start_date": start, "end_date": end, "updated_at": time.Now()}).Error
+				if err != nil {
+					return err
+				}
+				total++
+			}
+			return nil
+		}).Error
+	})
+	if err != nil {
+		r.Logger.WithError(err).Error(validations.ErrNormalizeDatesFailed)
+		return 0, validations.ErrNormalizeDatesFailed
+	}
+	return total, nil
+}
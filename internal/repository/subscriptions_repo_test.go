@@ -0,0 +1,762 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/models"
+	"github.com/cyb3rkh4l1d/subsapi/internal/tenancy"
+	"github.com/cyb3rkh4l1d/subsapi/internal/validations"
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+)
+
+const testUserID = "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+
+// month returns the first of a month in UTC, the way dates are stored.
+// month возвращает первое число месяца в UTC, как хранятся даты.
+func month(year int, m time.Month) time.Time {
+	return time.Date(year, m, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func TestNilDatabase(t *testing.T) {
+	logger, _ := logtest.NewNullLogger()
+	repo := NewSubscriptionRepository(nil, logrus.NewEntry(logger), 0, 0)
+	ctx := context.Background()
+
+	calls := map[string]func() error{
+		"CreateSubscription": func() error { return repo.CreateSubscription(ctx, &models.Subscription{}) },
+		"GetSubscriptionByID": func() error {
+			_, err := repo.GetSubscriptionByID(ctx, 1)
+			return err
+		},
+		"ListSubscription": func() error {
+			_, _, err := repo.ListSubscription(ctx, &models.ListSubscriptionRequest{})
+			return err
+		},
+		"UpdateSubscriptionByID": func() error { return repo.UpdateSubscriptionByID(ctx, &models.Subscription{ID: 1}) },
+		"DeleteSubscriptionByID": func() error { return repo.DeleteSubscriptionByID(ctx, 1) },
+		"FindSubscriptionsByUserIDandServiceName": func() error {
+			_, err := repo.FindSubscriptionsByUserIDandServiceName(ctx, "60601fee-2bf1-4721-ae6f-7636e79a0cba", "Netflix", false)
+			return err
+		},
+		"CountDistinctUsers": func() error {
+			_, err := repo.CountDistinctUsers(ctx, nil)
+			return err
+		},
+		"FindSubscriptionsByUserIDPrefix": func() error {
+			_, _, err := repo.FindSubscriptionsByUserIDPrefix(ctx, "60601fee", 10, 0)
+			return err
+		},
+		"AveragePriceByServiceName": func() error {
+			_, _, err := repo.AveragePriceByServiceName(ctx, "Netflix", 0)
+			return err
+		},
+	}
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			if err := call(); !errors.Is(err, validations.ErrDbInitializationFailed) {
+				t.Errorf("err = %v, want ErrDbInitializationFailed", err)
+			}
+		})
+	}
+}
+
+func TestFindSubscriptionsByUserIDandServiceName(t *testing.T) {
+	repo, sql := newDryRunRepository(t)
+	ctx := context.Background()
+
+	t.Run("all services", func(t *testing.T) {
+		if _, err := repo.FindSubscriptionsByUserIDandServiceName(ctx, "60601fee-2bf1-4721-ae6f-7636e79a0cba", "", false); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(*sql, "user_id = $1") || strings.Contains(*sql, "service_name") {
+			t.Errorf("ran %q, want the user filter only", *sql)
+		}
+	})
+
+	t.Run("single service", func(t *testing.T) {
+		if _, err := repo.FindSubscriptionsByUserIDandServiceName(ctx, "60601fee-2bf1-4721-ae6f-7636e79a0cba", "Netflix", false); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(*sql, "user_id = $1") || !strings.Contains(*sql, "LOWER(service_name) = $2") {
+			t.Errorf("ran %q, want the user and case-insensitive service filters", *sql)
+		}
+	})
+
+	t.Run("exact service", func(t *testing.T) {
+		if _, err := repo.FindSubscriptionsByUserIDandServiceName(ctx, "60601fee-2bf1-4721-ae6f-7636e79a0cba", "Netflix", true); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(*sql, "user_id = $1") || !strings.Contains(*sql, "service_name = $2") || strings.Contains(*sql, "LOWER") {
+			t.Errorf("ran %q, want the user and exact service filters", *sql)
+		}
+	})
+}
+
+func TestServiceNameMatching(t *testing.T) {
+	repo := NewTestDB(t)
+	ctx := context.Background()
+
+	for _, name := range []string{"Netflix", "netflix", "NETFLIX", "Netflix Kids"} {
+		if err := repo.CreateSubscription(ctx, &models.Subscription{UserID: testUserID, ServiceName: name, Price: 999, StartDate: month(2025, time.January)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		exact bool
+		want  []string
+	}{
+		{"normalized", false, []string{"NETFLIX", "Netflix", "netflix"}},
+		{"exact", true, []string{"netflix"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, err := repo.FindSubscriptionsByUserIDandServiceName(ctx, testUserID, "netflix", tt.exact)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := slices.Sorted(slices.Values(serviceNames(found))); !slices.Equal(got, tt.want) {
+				t.Errorf("found %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateAndGetSubscription(t *testing.T) {
+	repo := NewTestDB(t)
+	ctx := context.Background()
+
+	end := month(2025, time.December)
+	sub := &models.Subscription{UserID: testUserID, ServiceName: "Netflix", Price: 999, StartDate: month(2025, time.January), EndDate: &end}
+	if err := repo.CreateSubscription(ctx, sub); err != nil {
+		t.Fatal(err)
+	}
+	if sub.ID == 0 {
+		t.Fatal("created subscription has no id")
+	}
+
+	got, err := repo.GetSubscriptionByID(ctx, sub.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil {
+		t.Fatal("created subscription not found")
+	}
+	if got.UserID != testUserID || got.ServiceName != "Netflix" || got.Price != 999 || !got.StartDate.Equal(sub.StartDate) || got.EndDate == nil || !got.EndDate.Equal(end) {
+		t.Errorf("got %+v, want %+v", got, sub)
+	}
+
+	missing, err := repo.GetSubscriptionByID(ctx, sub.ID+1)
+	if err != nil || missing != nil {
+		t.Errorf("GetSubscriptionByID(missing) = %v, %v, want nil, nil", missing, err)
+	}
+}
+
+func TestGetSubscriptionsByPublicIDs(t *testing.T) {
+	repo := NewTestDB(t)
+	acme := tenancy.WithTenant(context.Background(), "acme")
+	globex := tenancy.WithTenant(context.Background(), "globex")
+
+	subs := []*models.Subscription{
+		{UserID: testUserID, ServiceName: "Netflix", Price: 999, StartDate: month(2025, time.January)},
+		{UserID: testUserID, ServiceName: "Spotify", Price: 299, StartDate: month(2025, time.January)},
+	}
+	for _, sub := range subs {
+		if err := repo.CreateSubscription(acme, sub); err != nil {
+			t.Fatal(err)
+		}
+	}
+	other := &models.Subscription{UserID: testUserID, ServiceName: "Netflix", Price: 999, StartDate: month(2025, time.January)}
+	if err := repo.CreateSubscription(globex, other); err != nil {
+		t.Fatal(err)
+	}
+	if subs[0].PublicID == "" || subs[0].PublicID == subs[1].PublicID {
+		t.Fatalf("public ids %q and %q, want two generated ones", subs[0].PublicID, subs[1].PublicID)
+	}
+
+	// the public ID of another tenant resolves to nothing
+	// публичный ID другого арендатора ни во что не разрешается
+	got, err := repo.GetSubscriptionsByPublicIDs(acme, []string{subs[1].PublicID, other.PublicID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].ID != subs[1].ID || got[0].PublicID != subs[1].PublicID {
+		t.Errorf("got %+v, want only %+v", got, subs[1])
+	}
+}
+
+func TestFindSubscriptionsByUserIDPrefix(t *testing.T) {
+	repo := NewTestDB(t)
+	ctx := context.Background()
+
+	for _, userID := range []string{testUserID, "a0eebc99-0000-4ef8-bb6d-6bb9bd380a11", "b0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"} {
+		if err := repo.CreateSubscription(ctx, &models.Subscription{UserID: userID, ServiceName: "Netflix", Price: 999, StartDate: month(2025, time.January)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	total, subs, err := repo.FindSubscriptionsByUserIDPrefix(ctx, "a0eebc99", 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 || len(subs) != 1 || subs[0].UserID != testUserID {
+		t.Errorf("got total %d and %+v, want 2 matches with %s on the second page", total, subs, testUserID)
+	}
+}
+
+func TestAveragePriceByServiceNameIgnoresCase(t *testing.T) {
+	repo := NewTestDB(t)
+	ctx := context.Background()
+
+	for _, sub := range []models.Subscription{
+		{UserID: testUserID, ServiceName: "Netflix", Price: 100, StartDate: month(2025, time.January)},
+		{UserID: testUserID, ServiceName: "netflix", Price: 300, StartDate: month(2025, time.January)},
+		{UserID: testUserID, ServiceName: "Spotify", Price: 1000, StartDate: month(2025, time.January)},
+	} {
+		if err := repo.CreateSubscription(ctx, &sub); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	average, count, err := repo.AveragePriceByServiceName(ctx, "NETFLIX", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if average != 200 || count != 2 {
+		t.Errorf("average = %v over %d, want 200 over 2", average, count)
+	}
+}
+
+func TestFindSubscriptionsByUserIDInBatches(t *testing.T) {
+	repo := NewTestDB(t)
+	ctx := context.Background()
+
+	for _, userID := range []string{testUserID, "b0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", testUserID, testUserID} {
+		if err := repo.CreateSubscription(ctx, &models.Subscription{UserID: userID, ServiceName: "Netflix", Price: 999, StartDate: month(2025, time.January)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var sizes []int
+	var ids []uint
+	err := repo.FindSubscriptionsByUserIDInBatches(ctx, testUserID, 2, func(batch []models.Subscription) error {
+		sizes = append(sizes, len(batch))
+		for _, sub := range batch {
+			ids = append(ids, sub.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(sizes, []int{2, 1}) || !slices.Equal(ids, []uint{1, 3, 4}) {
+		t.Errorf("batches of %v with ids %v, want [2 1] with [1 3 4]", sizes, ids)
+	}
+}
+
+func TestDeleteSubscriptionsByUserID(t *testing.T) {
+	repo := NewTestDB(t)
+	ctx := context.Background()
+
+	const otherUserID = "b0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+	for _, userID := range []string{testUserID, otherUserID, testUserID, testUserID} {
+		if err := repo.CreateSubscription(ctx, &models.Subscription{UserID: userID, ServiceName: "Netflix", Price: 999, StartDate: month(2025, time.January)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deleted, err := repo.DeleteSubscriptionsByUserID(ctx, testUserID)
+	if err != nil || deleted != 3 {
+		t.Fatalf("deleted %d, %v, want 3", deleted, err)
+	}
+	if left, _ := repo.FindSubscriptionsByUserIDandServiceName(ctx, testUserID, "", false); len(left) != 0 {
+		t.Errorf("%d subscriptions left for the erased user", len(left))
+	}
+	if kept, _ := repo.FindSubscriptionsByUserIDandServiceName(ctx, otherUserID, "", false); len(kept) != 1 {
+		t.Errorf("%d subscriptions kept for another user, want 1", len(kept))
+	}
+
+	deleted, err = repo.DeleteSubscriptionsByUserID(ctx, "c0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11")
+	if err != nil || deleted != 0 {
+		t.Errorf("unknown user: deleted %d, %v, want 0", deleted, err)
+	}
+}
+
+func TestEmptyResultsAreNotNil(t *testing.T) {
+	repo := NewTestDB(t)
+	ctx := context.Background()
+
+	_, listed, err := repo.ListSubscription(ctx, &models.ListSubscriptionRequest{Pagination: models.Pagination{Limit: 10}, SortBy: "id", Order: "asc"})
+	if err != nil || listed == nil {
+		t.Errorf("ListSubscription = %v, %v, want an empty slice", listed, err)
+	}
+	_, matched, err := repo.FindSubscriptionsByUserIDPrefix(ctx, "a0eebc99", 10, 0)
+	if err != nil || matched == nil {
+		t.Errorf("FindSubscriptionsByUserIDPrefix = %v, %v, want an empty slice", matched, err)
+	}
+	found, err := repo.FindSubscriptionsByUserIDandServiceName(ctx, testUserID, "", false)
+	if err != nil || found == nil {
+		t.Errorf("FindSubscriptionsByUserIDandServiceName = %v, %v, want an empty slice", found, err)
+	}
+}
+
+func TestFindExpiringAndClaimReminder(t *testing.T) {
+	repo := NewTestDB(t)
+	ctx := context.Background()
+
+	ids := map[string]uint{}
+	for _, row := range []struct {
+		name string
+		end  *time.Time
+	}{
+		{"due", ptr(month(2025, time.March))},
+		{"ended", ptr(month(2025, time.February))},
+		{"due at last", ptr(month(2025, time.April))},
+		{"too late", ptr(month(2025, time.May))},
+		{"open-ended", nil},
+	} {
+		sub := &models.Subscription{UserID: testUserID, ServiceName: row.name, Price: 999, StartDate: month(2025, time.January), EndDate: row.end}
+		if err := repo.CreateSubscription(ctx, sub); err != nil {
+			t.Fatal(err)
+		}
+		ids[row.name] = sub.ID
+	}
+	from, to := month(2025, time.March), month(2025, time.April)
+
+	due, err := repo.FindExpiring(ctx, from, to, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := serviceNames(due); !slices.Equal(got, []string{"due", "due at last"}) {
+		t.Fatalf("due = %q, want due and due at last", got)
+	}
+	if next, _ := repo.FindExpiring(ctx, from, to, ids["due"], 10); !slices.Equal(serviceNames(next), []string{"due at last"}) {
+		t.Errorf("due after %d = %q, want due at last", ids["due"], serviceNames(next))
+	}
+
+	// a claimed reminder is claimed once and no longer due
+	// занятое напоминание занимается один раз и больше не ожидает отправки
+	sentAt := time.Date(2025, time.February, 20, 9, 0, 0, 0, time.UTC)
+	if claimed, err := repo.ClaimReminder(ctx, ids["due"], sentAt); err != nil || !claimed {
+		t.Fatalf("first claim = %v, %v, want claimed", claimed, err)
+	}
+	if claimed, err := repo.ClaimReminder(ctx, ids["due"], sentAt); err != nil || claimed {
+		t.Fatalf("second claim = %v, %v, want already claimed", claimed, err)
+	}
+	if due, _ := repo.FindExpiring(ctx, from, to, 0, 10); !slices.Equal(serviceNames(due), []string{"due at last"}) {
+		t.Errorf("due after claim = %q, want due at last", serviceNames(due))
+	}
+
+	// a released reminder is due again
+	// освобожденное напоминание снова ожидает отправки
+	if err := repo.ReleaseReminder(ctx, ids["due"]); err != nil {
+		t.Fatal(err)
+	}
+	if claimed, err := repo.ClaimReminder(ctx, ids["due"], sentAt); err != nil || !claimed {
+		t.Errorf("claim after release = %v, %v, want claimed", claimed, err)
+	}
+}
+
+func TestSubscriptionsAreScopedToTheirTenant(t *testing.T) {
+	repo := NewTestDB(t)
+	acme := tenancy.WithTenant(context.Background(), "acme")
+	globex := tenancy.WithTenant(context.Background(), "globex")
+
+	sub := &models.Subscription{UserID: testUserID, ServiceName: "Spotify", Price: 299, StartDate: month(2025, time.March)}
+	if err := repo.CreateSubscription(acme, sub); err != nil {
+		t.Fatal(err)
+	}
+	if sub.TenantID != "acme" {
+		t.Fatalf("created subscription of tenant %q, want acme", sub.TenantID)
+	}
+
+	t.Run("get", func(t *testing.T) {
+		if got, err := repo.GetSubscriptionByID(globex, sub.ID); err != nil || got != nil {
+			t.Errorf("other tenant got %v, %v, want nil, nil", got, err)
+		}
+		if got, err := repo.GetSubscriptionByID(acme, sub.ID); err != nil || got == nil || got.TenantID != "acme" {
+			t.Errorf("own tenant got %+v, %v, want the subscription of acme", got, err)
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		req := &models.ListSubscriptionRequest{Pagination: models.Pagination{Limit: 10}, SortBy: "id", Order: "asc"}
+		if total, subs, err := repo.ListSubscription(globex, req); err != nil || total != 0 || len(subs) != 0 {
+			t.Errorf("other tenant listed %d of %d, %v, want none", len(subs), total, err)
+		}
+		if total, subs, err := repo.ListSubscription(acme, req); err != nil || total != 1 || len(subs) != 1 {
+			t.Errorf("own tenant listed %d of %d, %v, want 1", len(subs), total, err)
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		changed := *sub
+		changed.Price = 1
+		if err := repo.UpdateSubscriptionByID(globex, &changed); !errors.Is(err, validations.ErrSubscriptionNotFound) {
+			t.Errorf("update by another tenant: err = %v, want ErrSubscriptionNotFound", err)
+		}
+		if got, _ := repo.GetSubscriptionByID(acme, sub.ID); got == nil || got.Price != 299 || got.TenantID != "acme" {
+			t.Errorf("after an update by another tenant got %+v, want the unchanged subscription of acme", got)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		if err := repo.DeleteSubscriptionByID(globex, sub.ID); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := repo.GetSubscriptionByID(acme, sub.ID); got == nil {
+			t.Error("another tenant deleted the subscription of acme")
+		}
+		if err := repo.DeleteSubscriptionByID(acme, sub.ID); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := repo.GetSubscriptionByID(acme, sub.ID); got != nil {
+			t.Errorf("own tenant left %+v after deleting it", got)
+		}
+	})
+}
+
+func ptr[T any](v T) *T { return &v }
+
+func serviceNames(subs []models.Subscription) []string {
+	names := make([]string, len(subs))
+	for i, sub := range subs {
+		names[i] = sub.ServiceName
+	}
+	return names
+}
+
+func TestListSearch(t *testing.T) {
+	repo := NewTestDB(t)
+	ctx := context.Background()
+	for _, sub := range []models.Subscription{
+		{UserID: testUserID, ServiceName: "Netflix", Price: 100, StartDate: month(2025, time.January), Description: "Family movies"},
+		{UserID: testUserID, ServiceName: "Spotify", Price: 200, StartDate: month(2025, time.January), Description: "100% music"},
+		{UserID: testUserID, ServiceName: "Yandex_Plus", Price: 300, StartDate: month(2025, time.January)},
+	} {
+		if err := repo.CreateSubscription(ctx, &sub); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		search string
+		want   []string
+	}{
+		{"", []string{"Netflix", "Spotify", "Yandex_Plus"}},
+		{"NET", []string{"Netflix"}},
+		{"movies", []string{"Netflix"}},
+		{"0%", []string{"Spotify"}},
+		{"_", []string{"Yandex_Plus"}},
+		{"%", []string{"Spotify"}},
+		{"prime", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.search, func(t *testing.T) {
+			req := &models.ListSubscriptionRequest{Pagination: models.Pagination{Limit: 10}, SortBy: "id", Order: "asc", Search: tt.search}
+			total, subs, err := repo.ListSubscription(ctx, req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, sub := range subs {
+				names = append(names, sub.ServiceName)
+			}
+			if !slices.Equal(names, tt.want) || total != int64(len(tt.want)) {
+				t.Errorf("search %q = %v (total %d), want %v", tt.search, names, total, tt.want)
+			}
+			sum, err := repo.SumSubscriptionPrices(ctx, "", tt.search)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want int64
+			for _, sub := range subs {
+				want += int64(sub.Price)
+			}
+			if sum != want {
+				t.Errorf("search %q sums %d, want %d", tt.search, sum, want)
+			}
+		})
+	}
+}
+
+func TestUpsertSubscription(t *testing.T) {
+	repo := NewTestDB(t)
+	ctx := context.Background()
+	start := month(2025, time.January)
+
+	sub := &models.Subscription{UserID: testUserID, ServiceName: "Netflix", Price: 400, StartDate: start, EndDate: ptr(month(2025, time.June))}
+	if created, err := repo.UpsertSubscription(ctx, sub); err != nil || !created {
+		t.Fatalf("first upsert = %v, %v, want created", created, err)
+	}
+	id, publicID := sub.ID, sub.PublicID
+	first, err := repo.GetSubscriptionByID(ctx, id)
+	if err != nil || first == nil {
+		t.Fatalf("GetSubscriptionByID = %v, %v", first, err)
+	}
+
+	// a plain create of the same key is a separate subscription the upsert leaves alone
+	// обычное создание того же ключа — отдельная подписка, которую upsert не трогает
+	plain := &models.Subscription{UserID: testUserID, ServiceName: "Netflix", Price: 100, StartDate: start}
+	if err := repo.CreateSubscription(ctx, plain); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.ClaimReminder(ctx, id, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	// the conflict updates the upserted subscription, keeping the reminder of the kept end_date
+	// конфликт обновляет подписку upsert, сохраняя напоминание неизмененной end_date
+	sub = &models.Subscription{UserID: testUserID, ServiceName: "NETFLIX", Price: 500, StartDate: start, EndDate: ptr(month(2025, time.June)), Description: "family"}
+	if created, err := repo.UpsertSubscription(ctx, sub); err != nil || created {
+		t.Fatalf("second upsert = %v, %v, want updated", created, err)
+	}
+	if sub.ID != id || sub.PublicID != publicID || sub.ServiceName != "NETFLIX" || sub.Price != 500 || sub.Description != "family" || sub.ReminderSentAt == nil {
+		t.Errorf("updated = %+v, want id %d and public id %s replaced with the reminder kept", sub, id, publicID)
+	}
+	if !sub.CreatedAt.Equal(first.CreatedAt) {
+		t.Errorf("upsert changed created_at from %v to %v", first.CreatedAt, sub.CreatedAt)
+	}
+
+	// a changed end_date clears the reminder sent for the old one
+	// измененная end_date сбрасывает напоминание, отправленное для старой
+	sub = &models.Subscription{UserID: testUserID, ServiceName: "netflix", Price: 500, StartDate: start}
+	if created, err := repo.UpsertSubscription(ctx, sub); err != nil || created {
+		t.Fatalf("third upsert = %v, %v, want updated", created, err)
+	}
+	if sub.ID != id || sub.EndDate != nil || sub.ReminderSentAt != nil {
+		t.Errorf("updated = %+v, want id %d open-ended with the reminder cleared", sub, id)
+	}
+
+	if stored, err := repo.GetSubscriptionByID(ctx, plain.ID); err != nil || stored.Price != 100 || stored.Upserted {
+		t.Errorf("plain subscription = %+v, %v, want it untouched", stored, err)
+	}
+
+	// another start month is another key
+	// другой месяц начала — другой ключ
+	sub = &models.Subscription{UserID: testUserID, ServiceName: "Netflix", Price: 400, StartDate: month(2025, time.February)}
+	if created, err := repo.UpsertSubscription(ctx, sub); err != nil || !created || sub.ID == id {
+		t.Errorf("upsert of another month = %v, %v with id %d, want a new subscription", created, err, sub.ID)
+	}
+}
+
+func TestPriceStatsByServiceName(t *testing.T) {
+	repo := NewTestDB(t)
+	ctx := context.Background()
+
+	for i, price := range []int{100, 200, 250, 450} {
+		name := []string{"Netflix", "netflix", "NETFLIX", "Netflix"}[i]
+		sub := &models.Subscription{UserID: testUserID, ServiceName: name, Price: price, StartDate: month(2025, time.January)}
+		if err := repo.CreateSubscription(ctx, sub); err != nil {
+			t.Fatal(err)
+		}
+	}
+	other := &models.Subscription{UserID: testUserID, ServiceName: "Spotify", Price: 999, StartDate: month(2025, time.January)}
+	if err := repo.CreateSubscription(ctx, other); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := repo.PriceStatsByServiceName(ctx, "Netflix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := PriceStats{Count: 4, Average: 250, Median: 225, Min: 100, Max: 450}
+	if *got != want {
+		t.Errorf("got %+v, want %+v", *got, want)
+	}
+
+	// an odd count takes the middle price itself
+	// при нечетном количестве берется сама средняя цена
+	odd := &models.Subscription{UserID: testUserID, ServiceName: "Netflix", Price: 300, StartDate: month(2025, time.January)}
+	if err := repo.CreateSubscription(ctx, odd); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := repo.PriceStatsByServiceName(ctx, "netflix"); err != nil || got.Count != 5 || got.Median != 250 {
+		t.Errorf("odd count got %+v, %v, want 5 subscriptions with median 250", got, err)
+	}
+
+	none, err := repo.PriceStatsByServiceName(ctx, "Hulu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *none != (PriceStats{}) {
+		t.Errorf("unknown service got %+v, want zero stats", *none)
+	}
+}
+
+func TestNormalizeDates(t *testing.T) {
+	repo := NewTestDB(t)
+	acme := tenancy.WithTenant(context.Background(), "acme")
+	globex := tenancy.WithTenant(context.Background(), "globex")
+
+	midEnd := time.Date(2025, time.June, 30, 0, 0, 0, 0, time.UTC)
+	alignedEnd := month(2025, time.December)
+	subs := []struct {
+		ctx context.Context
+		sub *models.Subscription
+	}{
+		{acme, &models.Subscription{UserID: testUserID, ServiceName: "Netflix", Price: 1, StartDate: time.Date(2025, time.January, 15, 0, 0, 0, 0, time.UTC), EndDate: &midEnd}},
+		{acme, &models.Subscription{UserID: testUserID, ServiceName: "Spotify", Price: 1, StartDate: month(2025, time.February), EndDate: &midEnd}},
+		{globex, &models.Subscription{UserID: testUserID, ServiceName: "iCloud", Price: 1, StartDate: time.Date(2025, time.March, 31, 0, 0, 0, 0, time.UTC)}},
+		{globex, &models.Subscription{UserID: testUserID, ServiceName: "Kinopoisk", Price: 1, StartDate: month(2025, time.April), EndDate: &alignedEnd}},
+	}
+	for _, s := range subs {
+		if err := repo.CreateSubscription(s.ctx, s.sub); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a batch smaller than the number of rows to rewrite continues past the first batch
+	// пакет меньше числа переписываемых строк продолжается после первого пакета
+	normalized, err := repo.NormalizeDates(context.Background(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if normalized != 3 {
+		t.Errorf("normalized %d subscriptions, want 3", normalized)
+	}
+
+	want := []struct {
+		start time.Time
+		end   *time.Time
+	}{
+		{month(2025, time.January), ptr(month(2025, time.June))},
+		{month(2025, time.February), ptr(month(2025, time.June))},
+		{month(2025, time.March), nil},
+		{month(2025, time.April), &alignedEnd},
+	}
+	for i, s := range subs {
+		got, err := repo.GetSubscriptionByID(s.ctx, s.sub.ID)
+		if err != nil || got == nil {
+			t.Fatalf("subscription %d: %v, %v", s.sub.ID, got, err)
+		}
+		if !got.StartDate.Equal(want[i].start) {
+			t.Errorf("subscription %d start %v, want %v", got.ID, got.StartDate, want[i].start)
+		}
+		if (got.EndDate == nil) != (want[i].end == nil) || got.EndDate != nil && !got.EndDate.Equal(*want[i].end) {
+			t.Errorf("subscription %d end %v, want %v", got.ID, got.EndDate, want[i].end)
+		}
+	}
+
+	if again, err := repo.NormalizeDates(context.Background(), 2); err != nil || again != 0 {
+		t.Errorf("second run normalized %d, %v, want 0, nil", again, err)
+	}
+}
+
+func TestListPaginated(t *testing.T) {
+	repo := NewTestDB(t)
+	ctx := context.Background()
+
+	var ids []uint
+	for _, name := range []string{"Netflix", "Spotify", "iCloud"} {
+		sub := &models.Subscription{UserID: testUserID, ServiceName: name, Price: 1, StartDate: month(2025, time.January)}
+		if err := repo.CreateSubscription(ctx, sub); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, sub.ID)
+	}
+
+	first, err := repo.ListPaginated(ctx, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first) != 2 || first[0].ID != ids[0] || first[1].ID != ids[1] {
+		t.Fatalf("first page %+v, want ids %v", first, ids[:2])
+	}
+	rest, err := repo.ListPaginated(ctx, 2, first[1].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rest) != 1 || rest[0].ID != ids[2] {
+		t.Errorf("second page %+v, want id %d", rest, ids[2])
+	}
+}
+
+func TestTimestamps(t *testing.T) {
+	repo := NewTestDB(t)
+	ctx := context.Background()
+
+	sub := &models.Subscription{UserID: testUserID, ServiceName: "Netflix", Price: 999, StartDate: month(2025, time.January)}
+	if err := repo.CreateSubscription(ctx, sub); err != nil {
+		t.Fatal(err)
+	}
+	created, err := repo.GetSubscriptionByID(ctx, sub.ID)
+	if err != nil || created == nil {
+		t.Fatalf("GetSubscriptionByID = %v, %v", created, err)
+	}
+	if created.CreatedAt.IsZero() || created.UpdatedAt.IsZero() {
+		t.Fatalf("create set created_at %v and updated_at %v, want both", created.CreatedAt, created.UpdatedAt)
+	}
+	createdAt, updatedAt := created.CreatedAt, created.UpdatedAt
+
+	time.Sleep(10 * time.Millisecond)
+	created.Price = 1299
+	if err := repo.UpdateSubscriptionByID(ctx, created); err != nil {
+		t.Fatal(err)
+	}
+	updated, err := repo.GetSubscriptionByID(ctx, sub.ID)
+	if err != nil || updated == nil {
+		t.Fatalf("GetSubscriptionByID = %v, %v", updated, err)
+	}
+	if !updated.CreatedAt.Equal(createdAt) {
+		t.Errorf("update changed created_at from %v to %v", createdAt, updated.CreatedAt)
+	}
+	if !updated.UpdatedAt.After(updatedAt) {
+		t.Errorf("update left updated_at at %v, want after %v", updated.UpdatedAt, updatedAt)
+	}
+}
+
+func TestRemindersKeepUpdatedAt(t *testing.T) {
+	repo := NewTestDB(t)
+	ctx := context.Background()
+
+	sub := &models.Subscription{UserID: testUserID, ServiceName: "Netflix", Price: 999, StartDate: month(2025, time.January), EndDate: ptr(month(2025, time.June))}
+	if err := repo.CreateSubscription(ctx, sub); err != nil {
+		t.Fatal(err)
+	}
+	before, err := repo.GetSubscriptionByID(ctx, sub.ID)
+	if err != nil || before == nil {
+		t.Fatalf("GetSubscriptionByID = %v, %v", before, err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if claimed, err := repo.ClaimReminder(ctx, sub.ID, time.Now()); err != nil || !claimed {
+		t.Fatalf("ClaimReminder = %v, %v, want true, nil", claimed, err)
+	}
+	if err := repo.ReleaseReminder(ctx, sub.ID); err != nil {
+		t.Fatal(err)
+	}
+	after, err := repo.GetSubscriptionByID(ctx, sub.ID)
+	if err != nil || after == nil {
+		t.Fatalf("GetSubscriptionByID = %v, %v", after, err)
+	}
+	if !after.UpdatedAt.Equal(before.UpdatedAt) {
+		t.Errorf("reminders changed updated_at from %v to %v", before.UpdatedAt, after.UpdatedAt)
+	}
+}
+
+func TestRemindersDoNotSetUpdatedAt(t *testing.T) {
+	repo, sql := newDryRunRepository(t)
+	ctx := context.Background()
+
+	if _, err := repo.ClaimReminder(ctx, 1, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(*sql, "reminder_sent_at") || strings.Contains(*sql, "updated_at") {
+		t.Errorf("ClaimReminder ran %q, want reminder_sent_at set without updated_at", *sql)
+	}
+
+	if err := repo.ReleaseReminder(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(*sql, "reminder_sent_at") || strings.Contains(*sql, "updated_at") {
+		t.Errorf("ReleaseReminder ran %q, want reminder_sent_at cleared without updated_at", *sql)
+	}
+}
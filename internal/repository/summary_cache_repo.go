@@ -0,0 +1,213 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/models"
+	"github.com/cyb3rkh4l1d/subsapi/internal/tenancy"
+	"github.com/cyb3rkh4l1d/subsapi/internal/validations"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// summaryCacheBatchSize is the number of summary services or months inserted per statement.
+// summaryCacheBatchSize — количество сервисов или месяцев сводки, вставляемых одним запросом.
+const summaryCacheBatchSize = 500
+
+// SummaryCacheKey identifies the summary months of one user of one tenant.
+// SummaryCacheKey идентифицирует месяцы сводки одного пользователя одного арендатора.
+type SummaryCacheKey struct {
+	TenantID string
+	UserID   string
+}
+
+/*.....................................................................
+
+					Functions/Methods Definations
+
+........................................................................*/
+
+// GetSummaryCacheState returns the summary cache state of a user, nil when it was never refreshed nor invalidated.
+// GetSummaryCacheState возвращает состояние кеша сводки пользователя, nil, если он ни разу не обновлялся и не инвалидировался.
+func (r *SubscriptionRepository) GetSummaryCacheState(ctx context.Context, userID string) (*models.SummaryCacheState, error) {
+	db, err := r.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var states []models.SummaryCacheState
+	if err := db.Where("user_id = ?", userID).Limit(1).Find(&states).Error; err != nil {
+		r.Logger.WithError(err).Error(validations.ErrSummaryCacheFailed)
+		return nil, validations.ErrSummaryCacheFailed
+	}
+	if len(states) == 0 {
+		return nil, nil
+	}
+	return &states[0], nil
+}
+
+// FindSummaryMonths returns the summary months of a user between the months from and to, inclusive.
+// FindSummaryMonths возвращает месяцы сводки пользователя между месяцами from и to включительно.
+func (r *SubscriptionRepository) FindSummaryMonths(ctx context.Context, userID string, from, to time.Time) ([]models.SummaryMonth, error) {
+	db, err := r.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	months := make([]models.SummaryMonth, 0)
+	if err := db.Where("user_id = ? AND month >= ? AND month <= ?", userID, from, to).Find(&months).Error; err != nil {
+		r.Logger.WithError(err).Error(validations.ErrSummaryCacheFailed)
+		return nil, validations.ErrSummaryCacheFailed
+	}
+	return months, nil
+}
+
+// FindSummaryServices returns the summary services of a user.
+// FindSummaryServices возвращает сервисы сводки пользователя.
+func (r *SubscriptionRepository) FindSummaryServices(ctx context.Context, userID string) ([]models.SummaryService, error) {
+	db, err := r.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	services := make([]models.SummaryService, 0)
+	if err := db.Where("user_id = ?", userID).Find(&services).Error; err != nil {
+		r.Logger.WithError(err).Error(validations.ErrSummaryCacheFailed)
+		return nil, validations.ErrSummaryCacheFailed
+	}
+	return services, nil
+}
+
+// ReplaceSummaryCache swaps the summary services and months of a user for the given ones in one transaction
+// and records them as refreshed at refreshedAt up to horizon. A pending invalidation is kept, so an
+// invalidation made while they were computed still marks them stale.
+// ReplaceSummaryCache заменяет сервисы и месяцы сводки пользователя указанными в одной транзакции и отмечает
+// их обновленными в refreshedAt до horizon. Инвалидация сохраняется, поэтому инвалидация во время их
+// вычисления по-прежнему помечает их устаревшими.
+func (r *SubscriptionRepository) ReplaceSummaryCache(
+	ctx context.Context,
+	userID string,
+	services []models.SummaryService,
+	months []models.SummaryMonth,
+	refreshedAt, horizon time.Time,
+) error {
+	db, err := r.conn(ctx)
+	if err != nil {
+		return err
+	}
+	tenantID, _ := tenancy.FromContext(ctx)
+	for i := range services {
+		services[i].TenantID = tenantID
+		services[i].UserID = userID
+	}
+	for i := range months {
+		months[i].TenantID = tenantID
+		months[i].UserID = userID
+	}
+	state := &models.SummaryCacheState{TenantID: tenantID, UserID: userID, RefreshedAt: &refreshedAt, Horizon: &horizon}
+
+	err = r.withRetry(ctx, db, func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.SummaryService{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.SummaryMonth{}).Error; err != nil {
+			return err
+		}
+		if len(services) > 0 {
+			if err := tx.CreateInBatches(services, summaryCacheBatchSize).Error; err != nil {
+				return err
+			}
+		}
+		if len(months) > 0 {
+			if err := tx.CreateInBatches(months, summaryCacheBatchSize).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"refreshed_at", "horizon"}),
+		}).Create(state).Error
+	})
+	if err != nil {
+		r.Logger.WithError(err).Error(validations.ErrSummaryCacheFailed)
+		return validations.ErrSummaryCacheFailed
+	}
+	return nil
+}
+
+// InvalidateSummaryCache marks the summary months of a user as stale from at, until they are refreshed again.
+// InvalidateSummaryCache помечает месяцы сводки пользователя устаревшими с момента at до следующего обновления.
+func (r *SubscriptionRepository) InvalidateSummaryCache(ctx context.Context, userID string, at time.Time) error {
+	db, err := r.conn(ctx)
+	if err != nil {
+		return err
+	}
+	tenantID, _ := tenancy.FromContext(ctx)
+	state := &models.SummaryCacheState{TenantID: tenantID, UserID: userID, InvalidatedAt: &at}
+	err = db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"invalidated_at"}),
+	}).Create(state).Error
+	if err != nil {
+		r.Logger.WithError(err).Error(validations.ErrSummaryCacheFailed)
+		return validations.ErrSummaryCacheFailed
+	}
+	return nil
+}
+
+// DeleteSummaryCache removes the summary services, months and cache state of a user.
+// DeleteSummaryCache удаляет сервисы, месяцы сводки и состояние кеша пользователя.
+func (r *SubscriptionRepository) DeleteSummaryCache(ctx context.Context, userID string) error {
+	db, err := r.conn(ctx)
+	if err != nil {
+		return err
+	}
+	err = r.withRetry(ctx, db, func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.SummaryService{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.SummaryMonth{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.SummaryCacheState{}).Error
+	})
+	if err != nil {
+		r.Logger.WithError(err).Error(validations.ErrSummaryCacheFailed)
+		return validations.ErrSummaryCacheFailed
+	}
+	return nil
+}
+
+// FindStaleSummaryUsers returns up to limit users, across every tenant, owning subscriptions whose summary
+// months were never refreshed, were refreshed before refreshedBefore, or were invalidated since.
+// Results are ordered by tenant and user and start after the after key, for keyset pagination.
+// FindStaleSummaryUsers возвращает до limit пользователей всех арендаторов с подписками, месяцы сводки которых
+// ни разу не обновлялись, обновлены до refreshedBefore или инвалидированы после обновления.
+// Результаты упорядочены по арендатору и пользователю и начинаются после ключа after для keyset-пагинации.
+func (r *SubscriptionRepository) FindStaleSummaryUsers(ctx context.Context, refreshedBefore time.Time, after SummaryCacheKey, limit int) ([]SummaryCacheKey, error) {
+	if r.DB == nil {
+		r.Logger.Error(validations.ErrDbInitializationFailed)
+		return nil, validations.ErrDbInitializationFailed
+	}
+	// unscoped on purpose: the refresh job covers every tenant
+	// намеренно без области арендатора: задача обновления охватывает всех арендаторов
+	keys := make([]SummaryCacheKey, 0)
+	query := r.DB.WithContext(ctx).
+		Table("subscriptions AS s").
+		Select("s.tenant_id, s.user_id").
+		Joins("LEFT JOIN summary_cache_states AS c ON c.tenant_id = s.tenant_id AND c.user_id = s.user_id").
+		Where("c.refreshed_at IS NULL OR c.refreshed_at < ? OR c.invalidated_at >= c.refreshed_at", refreshedBefore)
+	// the zero key starts from the first user, an empty user_id would not be a valid uuid
+	// нулевой ключ начинает с первого пользователя, пустой user_id не был бы корректным uuid
+	if after != (SummaryCacheKey{}) {
+		query = query.Where("s.tenant_id > ? OR (s.tenant_id = ? AND s.user_id > ?)", after.TenantID, after.TenantID, after.UserID)
+	}
+	err := query.
+		Group("s.tenant_id, s.user_id").
+		Order("s.tenant_id asc, s.user_id asc").
+		Limit(limit).
+		Scan(&keys).Error
+	if err != nil {
+		r.Logger.WithError(err).Error(validations.ErrSummaryCacheFailed)
+		return nil, validations.ErrSummaryCacheFailed
+	}
+	return keys, nil
+}
@@ -0,0 +1,190 @@
+package repository_test
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/config"
+	"github.com/cyb3rkh4l1d/subsapi/internal/models"
+	"github.com/cyb3rkh4l1d/subsapi/internal/repository"
+	"github.com/cyb3rkh4l1d/subsapi/internal/service"
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+)
+
+// newSummaryServices returns a service reading the summary cache and one always computing live, over repo.
+// newSummaryServices возвращает сервис, читающий кеш сводки, и сервис, всегда вычисляющий на лету, поверх repo.
+func newSummaryServices(repo repository.Repository) (cached, live *service.SubscriptionService) {
+	logger, _ := logtest.NewNullLogger()
+	cached = service.NewSubscriptionService(repo, &config.Config{SummaryCacheEnabled: true, SummaryCacheTTL: 60}, logrus.NewEntry(logger))
+	live = service.NewSubscriptionService(repo, &config.Config{}, logrus.NewEntry(logger))
+	return cached, live
+}
+
+// assertCachedMatchesLive compares the summary, per-service, quarterly and period comparison stats of every user
+// computed by both services.
+// assertCachedMatchesLive сравнивает сводку, статистику по сервисам, поквартальную статистику и сравнение периодов
+// каждого пользователя, вычисленные обоими сервисами.
+func assertCachedMatchesLive(t *testing.T, cached, live *service.SubscriptionService) {
+	t.Helper()
+	ctx := context.Background()
+	periods := []models.DateRange{{To: "06-2025"}, {From: "01-2024", To: "12-2024"}, {From: "03-2025", To: "12-2027"}}
+	for _, userID := range userIDs {
+		for _, period := range periods {
+			summary := &models.UserSubscriptionSummaryRequest{UserID: userID, DateRange: period}
+			cachedUnit, cachedCost, cachedMonths, _, err := cached.GetUserSubscriptionSummary(ctx, summary)
+			if err != nil {
+				t.Fatal(err)
+			}
+			liveUnit, liveCost, liveMonths, _, err := live.GetUserSubscriptionSummary(ctx, summary)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cachedUnit != liveUnit || cachedCost != liveCost || cachedMonths != liveMonths {
+				t.Errorf("summary %s %+v: cached %d/%d/%d, live %d/%d/%d", userID, period,
+					cachedUnit, cachedCost, cachedMonths, liveUnit, liveCost, liveMonths)
+			}
+
+			stats := &models.ServiceStatsRequest{UserID: userID, DateRange: period}
+			cachedServices, err := cached.GetServiceStats(ctx, stats)
+			if err != nil {
+				t.Fatal(err)
+			}
+			liveServices, err := live.GetServiceStats(ctx, stats)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(cachedServices, liveServices) {
+				t.Errorf("services %s %+v: cached %+v, live %+v", userID, period, cachedServices, liveServices)
+			}
+		}
+
+		compare := &models.StatsCompareRequest{
+			UserID:      userID,
+			PeriodAFrom: "01-2024", PeriodATo: "12-2024",
+			PeriodBFrom: "01-2025", PeriodBTo: "12-2025",
+		}
+		for _, year := range []int{2024, 2025} {
+			quarterly := &models.QuarterlyStatsRequest{UserID: userID, Year: year}
+			cachedQuarterly, err := cached.GetQuarterlyStats(ctx, quarterly)
+			if err != nil {
+				t.Fatal(err)
+			}
+			liveQuarterly, err := live.GetQuarterlyStats(ctx, quarterly)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(cachedQuarterly, liveQuarterly) {
+				t.Errorf("quarterly %s %d: cached %+v, live %+v", userID, year, cachedQuarterly, liveQuarterly)
+			}
+		}
+
+		cachedCompare, err := cached.CompareStatsPeriods(ctx, compare)
+		if err != nil {
+			t.Fatal(err)
+		}
+		liveCompare, err := live.CompareStatsPeriods(ctx, compare)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(cachedCompare, liveCompare) {
+			t.Errorf("compare %s: cached %+v, live %+v", userID, cachedCompare, liveCompare)
+		}
+	}
+}
+
+func TestSummaryCacheMatchesLiveStats(t *testing.T) {
+	repo := repository.NewTestDB(t)
+	seedRevenue(t, repo, 60)
+	cached, live := newSummaryServices(repo)
+	ctx := context.Background()
+
+	cached.RefreshSummaryCache(ctx)
+	for _, userID := range userIDs {
+		state, err := repo.GetSummaryCacheState(ctx, userID)
+		if err != nil || state == nil || state.RefreshedAt == nil {
+			t.Fatalf("user %s not refreshed: %+v, %v", userID, state, err)
+		}
+	}
+	assertCachedMatchesLive(t, cached, live)
+
+	// a change invalidates the cache of its user, who is computed live until refreshed again
+	// изменение инвалидирует кеш пользователя, который вычисляется на лету до следующего обновления
+	if _, _, err := cached.CreateSubscription(ctx, &models.CreateSubscriptionRequest{
+		UserID: userIDs[0], ServiceName: "Service 1", Price: 999, StartDate: "01-2024", EndDate: "12-2027",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	assertCachedMatchesLive(t, cached, live)
+
+	cached.RefreshSummaryCache(ctx)
+	assertCachedMatchesLive(t, cached, live)
+
+	// rows removed behind the service's back are only seen live, proving the stats are read from the cache
+	// строки, удаленные в обход сервиса, видны только на лету, что доказывает чтение статистики из кеша
+	if _, err := repo.DeleteSubscriptionsByUserID(ctx, userIDs[1]); err != nil {
+		t.Fatal(err)
+	}
+	summary := &models.UserSubscriptionSummaryRequest{UserID: userIDs[1], DateRange: models.DateRange{To: "06-2025"}}
+	_, cachedCost, _, _, err := cached.GetUserSubscriptionSummary(ctx, summary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, liveCost, _, _, err := live.GetUserSubscriptionSummary(ctx, summary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cachedCost == 0 || liveCost != 0 {
+		t.Errorf("after deleting the rows directly: cached cost %d, live cost %d, want the cached one kept", cachedCost, liveCost)
+	}
+	quarterly := &models.QuarterlyStatsRequest{UserID: userIDs[1], Year: 2025}
+	cachedQuarterly, err := cached.GetQuarterlyStats(ctx, quarterly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	liveQuarterly, err := live.GetQuarterlyStats(ctx, quarterly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cachedQuarterly.TotalCost == 0 || liveQuarterly.TotalCost != 0 {
+		t.Errorf("after deleting the rows directly: cached quarterly cost %d, live %d, want the cached one kept",
+			cachedQuarterly.TotalCost, liveQuarterly.TotalCost)
+	}
+}
+
+func TestFindStaleSummaryUsersPagesThroughEveryUser(t *testing.T) {
+	repo := repository.NewTestDB(t)
+	seedRevenue(t, repo, 6)
+	ctx := context.Background()
+
+	var got []string
+	var after repository.SummaryCacheKey
+	for range len(userIDs) + 1 {
+		keys, err := repo.FindStaleSummaryUsers(ctx, time.Now(), after, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(keys) == 0 {
+			break
+		}
+		got = append(got, keys[0].UserID)
+		after = keys[0]
+	}
+	if !slices.Equal(got, userIDs) {
+		t.Errorf("stale users = %v, want %v", got, userIDs)
+	}
+
+	if err := repo.ReplaceSummaryCache(ctx, userIDs[0], nil, nil, time.Now(), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := repo.FindStaleSummaryUsers(ctx, time.Now().Add(-time.Hour), repository.SummaryCacheKey{}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0].UserID != userIDs[1] {
+		t.Errorf("stale users after refreshing %s = %+v, want only %s", userIDs[0], keys, userIDs[1])
+	}
+}
@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/models"
+	"github.com/glebarez/sqlite"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+// testDatabaseURLEnv names the PostgreSQL connection string repository tests run against instead of SQLite,
+// as a URL or key=value DSN.
+// testDatabaseURLEnv задает строку подключения PostgreSQL, на которой тесты репозитория выполняются вместо SQLite,
+// в виде URL или DSN key=value.
+const testDatabaseURLEnv = "TEST_DATABASE_URL"
+
+// expressionIndexes are the indexes of the migrations AutoMigrate can't express: the partial upsert key
+// of migration 00007 and the case-insensitive lookup index of migration 00008.
+// expressionIndexes — индексы миграций, которые AutoMigrate не может выразить: частичный ключ upsert
+// миграции 00007 и индекс регистронезависимого поиска миграции 00008.
+var expressionIndexes = []string{
+	"CREATE UNIQUE INDEX idx_subscriptions_upsert_key ON subscriptions (tenant_id, user_id, LOWER(service_name), start_date) WHERE upserted",
+	"CREATE INDEX idx_subscriptions_user_service_start ON subscriptions (user_id, LOWER(service_name), start_date)",
+}
+
+// NewTestDB returns a repository over a fresh database created with AutoMigrate, so tests neither see nor
+// leave each other's rows. The database is an in-memory SQLite, or a schema of the TEST_DATABASE_URL
+// PostgreSQL database, dropped when the test ends, when it is set.
+// NewTestDB возвращает репозиторий поверх новой базы данных, созданной AutoMigrate, чтобы тесты не видели
+// и не оставляли строк друг друга. База данных — SQLite в памяти или, если задан TEST_DATABASE_URL, схема
+// базы данных PostgreSQL, удаляемая по окончании теста.
+func NewTestDB(t testing.TB) *SubscriptionRepository {
+	t.Helper()
+	db := openTestDB(t)
+	if err := db.AutoMigrate(&models.Subscription{}, &models.SummaryService{}, &models.SummaryMonth{}, &models.SummaryCacheState{}); err != nil {
+		t.Fatalf("migrating test database: %v", err)
+	}
+	for _, index := range expressionIndexes {
+		if err := db.Exec(index).Error; err != nil {
+			t.Fatalf("creating index: %v", err)
+		}
+	}
+
+	log, _ := logtest.NewNullLogger()
+	return NewSubscriptionRepository(db, logrus.NewEntry(log), 0, 0)
+}
+
+// SkipUnlessPostgres skips a test relying on PostgreSQL-only SQL, such as generate_series, when
+// TEST_DATABASE_URL is not set and NewTestDB falls back to SQLite.
+// SkipUnlessPostgres пропускает тест, использующий SQL только для PostgreSQL, например generate_series,
+// если TEST_DATABASE_URL не задан и NewTestDB использует SQLite.
+func SkipUnlessPostgres(t testing.TB) {
+	t.Helper()
+	if os.Getenv(testDatabaseURLEnv) == "" {
+		t.Skipf("%s is not set, PostgreSQL-only query skipped", testDatabaseURLEnv)
+	}
+}
+
+// openTestDB opens the database of NewTestDB with the dialector TEST_DATABASE_URL selects.
+// openTestDB открывает базу данных NewTestDB с диалектом, выбранным TEST_DATABASE_URL.
+func openTestDB(t testing.TB) *gorm.DB {
+	t.Helper()
+	config := &gorm.Config{Logger: logger.Discard}
+	if dsn := os.Getenv(testDatabaseURLEnv); dsn != "" {
+		return openPostgresTestDB(t, dsn, config)
+	}
+
+	db, err := gorm.Open(sqliteDialector{sqlite.Open(":memory:")}, config)
+	if err != nil {
+		t.Fatalf("opening sqlite: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("opening sqlite: %v", err)
+	}
+	// every connection to :memory: opens its own empty database, so the pool keeps a single one
+	// каждое подключение к :memory: открывает свою пустую базу, поэтому пул хранит только одно
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+// openPostgresTestDB creates a schema in the dsn database and connects to it through search_path.
+// openPostgresTestDB создает схему в базе данных dsn и подключается к ней через search_path.
+func openPostgresTestDB(t testing.TB, dsn string, config *gorm.Config) *gorm.DB {
+	t.Helper()
+	admin, err := gorm.Open(postgres.Open(dsn), config)
+	if err != nil {
+		t.Fatalf("connecting to %s: %v", testDatabaseURLEnv, err)
+	}
+	schemaName := "test_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	if err := admin.Exec("CREATE SCHEMA " + schemaName).Error; err != nil {
+		t.Fatalf("creating schema %s: %v", schemaName, err)
+	}
+
+	db, err := gorm.Open(postgres.Open(withSearchPath(dsn, schemaName)), config)
+	if err != nil {
+		t.Fatalf("connecting to schema %s: %v", schemaName, err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+		admin.Exec("DROP SCHEMA " + schemaName + " CASCADE")
+		if sqlDB, err := admin.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// sqliteDialector maps the PostgreSQL column types of the models to SQLite ones: uuid columns are stored
+// as text, timestamptz columns as datetime, date columns keep their date type, which the driver reads
+// back as time.Time.
+// sqliteDialector сопоставляет типы столбцов PostgreSQL моделей типам SQLite: столбцы uuid хранятся
+// как текст, столбцы timestamptz — как datetime, столбцы date сохраняют тип date, который драйвер
+// считывает как time.Time.
+type sqliteDialector struct {
+	gorm.Dialector
+}
+
+// Migrator hands the migrator this dialector, so AutoMigrate creates the columns with the mapped types.
+// Migrator передает мигратору этот диалект, чтобы AutoMigrate создавал столбцы с сопоставленными типами.
+func (d sqliteDialector) Migrator(db *gorm.DB) gorm.Migrator {
+	m := d.Dialector.Migrator(db).(sqlite.Migrator)
+	m.Dialector = d
+	return m
+}
+
+func (d sqliteDialector) DataTypeOf(field *schema.Field) string {
+	switch field.DataType {
+	case "uuid":
+		return "text"
+	case "timestamptz":
+		return "datetime"
+	}
+	return d.Dialector.DataTypeOf(field)
+}
+
+// withSearchPath adds a search_path runtime parameter to a URL or key=value connection string.
+// withSearchPath добавляет параметр search_path в строку подключения в виде URL или key=value.
+func withSearchPath(dsn, schema string) string {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		separator := "?"
+		if strings.Contains(dsn, "?") {
+			separator = "&"
+		}
+		return dsn + separator + "search_path=" + schema
+	}
+	return dsn + " search_path=" + schema
+}
+
+func TestWithSearchPath(t *testing.T) {
+	tests := []struct{ dsn, want string }{
+		{"postgres://u:p@localhost:5432/db", "postgres://u:p@localhost:5432/db?search_path=s"},
+		{"postgresql://localhost/db?sslmode=disable", "postgresql://localhost/db?sslmode=disable&search_path=s"},
+		{"host=localhost dbname=db", "host=localhost dbname=db search_path=s"},
+	}
+	for _, tt := range tests {
+		if got := withSearchPath(tt.dsn, "s"); got != tt.want {
+			t.Errorf("withSearchPath(%q) = %q, want %q", tt.dsn, got, tt.want)
+		}
+	}
+}
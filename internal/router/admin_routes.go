@@ -0,0 +1,31 @@
+package router
+
+import (
+	"github.com/cyb3rkh4l1d/subsapi/internal/handlers"
+	"github.com/cyb3rkh4l1d/subsapi/internal/middleware"
+	"github.com/cyb3rkh4l1d/subsapi/internal/models"
+)
+
+// AdminRoutes configures the admin-only endpoints guarded by the admin API key
+// AdminRoutes настраивает эндпоинты только для администратора, защищенные ключом API администратора.
+func AdminRoutes(router *Router) {
+
+	admin := router.GinEngine.Group("/api/v1/admin", middleware.AdminAuth(router.config.AdminAPIKey))
+
+	admin.GET("/stats/users", router.allowQuery(), router.Handler.GetUserStats)
+	admin.GET("/stats/inflight", router.allowQuery(), handlers.InFlightHandler(router.inFlight))
+	admin.GET("/subscriptions", router.allowQuery(models.UserPrefixSearchRequest{}), router.Handler.FindSubscriptionsByUserPrefix)
+	admin.POST("/subscriptions/normalize-dates", router.allowQuery(), router.Handler.NormalizeDates)
+
+	// the explain endpoint executes queries, it stays unregistered unless explicitly enabled
+	// эндпоинт explain выполняет запросы, он не регистрируется без явного включения
+	if router.config.EnableExplain {
+		admin.GET("/stats/explain", router.allowQuery(models.UserSubscriptionSummaryRequest{}), router.Handler.ExplainSummary)
+	}
+
+	if router.config.AdminAPIKey == "" {
+		router.Logger.Warn("/api/v1/admin: ADMIN_API_KEY is not set, admin api is disabled")
+		return
+	}
+	router.Logger.Info("/api/v1/admin: admin api has been added")
+}
@@ -0,0 +1,13 @@
+package router
+
+// HealthRoutes configures the liveness and readiness probe endpoints
+// HealthRoutes настраивает эндпоинты проб живости и готовности
+func HealthRoutes(router *Router) {
+
+	health := router.GinEngine.Group("/api/v1")
+
+	health.GET("/healthz", router.HealthHandler.Healthz)
+	health.GET("/readyz", router.HealthHandler.Readyz)
+
+	router.Logger.Info("/api/v1/healthz, /api/v1/readyz: health api has been added")
+}
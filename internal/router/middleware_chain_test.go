@@ -0,0 +1,62 @@
+package router
+
+import (
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/config"
+	"github.com/cyb3rkh4l1d/subsapi/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// middlewareNames names the constructor of every handler of chain, such as "middleware.RequestID".
+// middlewareNames возвращает имя конструктора каждого обработчика chain, например "middleware.RequestID".
+func middlewareNames(chain gin.HandlersChain) []string {
+	names := make([]string, 0, len(chain))
+	for _, handler := range chain {
+		name := runtime.FuncForPC(reflect.ValueOf(handler).Pointer()).Name()
+		name = name[strings.LastIndex(name, "/")+1:]
+		names = append(names, strings.TrimSuffix(name, ".func1"))
+	}
+	return names
+}
+
+func TestBuildMiddlewareChain(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.MiddlewareConfig
+		want []string
+	}{
+		{
+			name: "everything enabled runs in order",
+			cfg:  config.MiddlewareConfig{AccessLog: true, MaxQueryLength: 2048, HSTSEnabled: true, HSTSMaxAge: 60},
+			want: []string{
+				"gin.CustomRecoveryWithWriter", "middleware.RequestID", "gin.LoggerWithConfig",
+				"middleware.MaxQueryLength", "middleware.(*InFlightLimiter).Handler", "middleware.EnforceHTTPS",
+			},
+		},
+		{
+			name: "disabled middlewares are absent",
+			cfg:  config.MiddlewareConfig{},
+			want: []string{"gin.CustomRecoveryWithWriter", "middleware.RequestID", "middleware.(*InFlightLimiter).Handler"},
+		},
+		{
+			name: "redirect alone enforces https",
+			cfg:  config.MiddlewareConfig{HTTPSRedirect: true},
+			want: []string{
+				"gin.CustomRecoveryWithWriter", "middleware.RequestID", "middleware.(*InFlightLimiter).Handler", "middleware.EnforceHTTPS",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := buildMiddlewareChain(tt.cfg, middleware.NewInFlightLimiter(tt.cfg.MaxInFlight), nil)
+			if got := middlewareNames(chain); !slices.Equal(got, tt.want) {
+				t.Errorf("chain = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
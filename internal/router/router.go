@@ -2,10 +2,13 @@ package router
 
 import (
 	"context"
+	"net/netip"
 	"slices"
 
+	"github.com/cyb3rkh4l1d/subsapi/api/docs"
 	"github.com/cyb3rkh4l1d/subsapi/internal/config"
 	"github.com/cyb3rkh4l1d/subsapi/internal/handlers"
+	"github.com/cyb3rkh4l1d/subsapi/internal/middleware"
 	"github.com/cyb3rkh4l1d/subsapi/internal/validations"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
@@ -20,16 +23,19 @@ type RouteRegistrationFunc func(a *Router)
 // Маршрутизатор представляет собой основной контейнер приложения.
 // Он содержит общий контекст, конфигурацию, логгер, HTTP-движок и обработчики.
 type Router struct {
-	ctx       context.Context
-	GinEngine *gin.Engine
-	Logger    *logrus.Entry
-	config    *config.Config
-	Handler   *handlers.SubscriptionHandler
+	ctx           context.Context
+	GinEngine     *gin.Engine
+	Logger        *logrus.Entry
+	config        *config.Config
+	Handler       *handlers.SubscriptionHandler
+	HealthHandler *handlers.HealthHandler
+	tenant        gin.HandlersChain
+	inFlight      *middleware.InFlightLimiter
 }
 
 // NewApiRouter creates and configures the router instance.
 // NewApiRouter создает и настраивает экземпляр маршрутизатора.
-func NewApiRouter(ctx context.Context, config *config.Config, logger *logrus.Entry, handler *handlers.SubscriptionHandler) *Router {
+func NewApiRouter(ctx context.Context, config *config.Config, logger *logrus.Entry, handler *handlers.SubscriptionHandler, healthHandler *handlers.HealthHandler) *Router {
 
 	// Validate against allowed Gin modes
 	// Проверка на соответствие разрешенным режимам Gin
@@ -46,15 +52,50 @@ func NewApiRouter(ctx context.Context, config *config.Config, logger *logrus.Ent
 	gin.SetMode(ginMode)
 	logger.Infof("GinMode set to : %+v", ginMode)
 	router := gin.New()
-	router.Use(gin.Recovery())
-	router.Use(gin.Logger())
+
+	// Trust forwarded headers only from the configured proxies
+	// Доверять перенаправленным заголовкам только от настроенных прокси
+	trustedProxies, err := middleware.ParseTrustedProxies(config.Middleware.TrustedProxies)
+	if err != nil {
+		logger.Warnf("%+v: TRUSTED_PROXIES=%+v, no proxy is trusted", validations.ErrInvalidConfigValue, config.Middleware.TrustedProxies)
+		trustedProxies = nil
+	}
+	trusted := make([]string, len(trustedProxies))
+	for i, prefix := range trustedProxies {
+		trusted[i] = prefix.String()
+	}
+	if err := router.SetTrustedProxies(trusted); err != nil {
+		logger.WithError(err).Warn(validations.ErrInvalidConfigValue)
+	}
+
+	// Install the enabled global middlewares in order
+	// Установить включенные глобальные промежуточные обработчики по порядку
+	inFlight := middleware.NewInFlightLimiter(config.Middleware.MaxInFlight)
+	router.Use(buildMiddlewareChain(config.Middleware, inFlight, trustedProxies)...)
+	router.GET("/", handlers.RootHandler(docs.SwaggerInfo.Title, docs.SwaggerInfo.Version, config.EnableSwagger))
+	router.NoRoute(handlers.NotFoundHandler())
+
+	// Scope tenant data endpoints to the tenant of each request, refusing to start half-isolated
+	// Ограничить эндпоинты данных арендатором каждого запроса, не запускаясь с неполной изоляцией
+	var tenant gin.HandlersChain
+	if config.TenancyEnabled {
+		apiKeys, err := middleware.ParseTenantAPIKeys(config.TenantAPIKeys)
+		if err != nil {
+			logger.WithError(err).Fatal("TENANT_API_KEYS must be comma separated key:tenant pairs")
+		}
+		tenant = gin.HandlersChain{middleware.Tenant(apiKeys)}
+		logger.Infof("multi-tenancy enabled, %+v tenant API keys", len(apiKeys))
+	}
 
 	return &Router{
-		GinEngine: router,
-		config:    config,
-		Handler:   handler,
-		Logger:    logger,
-		ctx:       ctx,
+		GinEngine:     router,
+		tenant:        tenant,
+		inFlight:      inFlight,
+		config:        config,
+		Handler:       handler,
+		HealthHandler: healthHandler,
+		Logger:        logger,
+		ctx:           ctx,
 	}
 }
 
@@ -64,6 +105,31 @@ func NewApiRouter(ctx context.Context, config *config.Config, logger *logrus.Ent
 
 ........................................................................*/
 
+// buildMiddlewareChain assembles the global middlewares enabled by cfg in the order they run:
+// recovery first so that a panic anywhere is answered, then the request ID every later log line carries,
+// the access log, the cheap query length check, the in-flight limit, and HTTPS enforcement.
+// The in-flight limiter always counts requests for its gauge, its limit only applies with MAX_INFLIGHT set.
+// buildMiddlewareChain собирает глобальные промежуточные обработчики, включенные в cfg, в порядке выполнения:
+// сначала восстановление, чтобы ответить на панику в любом месте, затем ID запроса для всех последующих строк журнала,
+// журнал доступа, дешевая проверка длины запроса, ограничение одновременных запросов и принудительный HTTPS.
+// Ограничитель всегда считает запросы для счетчика, его лимит применяется только при заданном MAX_INFLIGHT.
+func buildMiddlewareChain(cfg config.MiddlewareConfig, inFlight *middleware.InFlightLimiter, trustedProxies []netip.Prefix) gin.HandlersChain {
+	chain := gin.HandlersChain{middleware.Recovery(), middleware.RequestID()}
+	if cfg.AccessLog {
+		chain = append(chain, gin.Logger())
+	}
+	if cfg.MaxQueryLength > 0 {
+		chain = append(chain, middleware.MaxQueryLength(cfg.MaxQueryLength))
+	}
+	// the probes stay answerable under load
+	// пробы остаются доступными под нагрузкой
+	chain = append(chain, inFlight.Handler("/api/v1/healthz", "/api/v1/readyz"))
+	if cfg.HSTSEnabled || cfg.HTTPSRedirect {
+		chain = append(chain, middleware.EnforceHTTPS(cfg.HSTSEnabled, cfg.HSTSMaxAge, cfg.HTTPSRedirect, trustedProxies))
+	}
+	return chain
+}
+
 // RegisterRoutes registers all route modules into the router instance.
 // Функция RegisterRoutes регистрирует все модули маршрутизации в экземпляре маршрутизатора.
 func (r *Router) RegisterRoutes(registerFuncs ...RouteRegistrationFunc) {
@@ -71,3 +137,14 @@ func (r *Router) RegisterRoutes(registerFuncs ...RouteRegistrationFunc) {
 		registerFunc(r)
 	}
 }
+
+// allowQuery returns the handler rejecting the query parameters not bound by the given request structs when
+// STRICT_QUERY_PARAMS is set, and a no-op otherwise. Routes binding no query pass no request.
+// allowQuery возвращает обработчик, отклоняющий параметры запроса, не привязываемые указанными структурами,
+// при включенном STRICT_QUERY_PARAMS, иначе пустой обработчик. Маршруты без параметров не передают структур.
+func (router *Router) allowQuery(requests ...any) gin.HandlerFunc {
+	if !router.config.StrictQueryParams {
+		return func(*gin.Context) {}
+	}
+	return middleware.AllowQueryParams(middleware.QueryParams(requests...)...)
+}
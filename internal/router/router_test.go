@@ -0,0 +1,1046 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/config"
+	"github.com/cyb3rkh4l1d/subsapi/internal/handlers"
+	"github.com/cyb3rkh4l1d/subsapi/internal/models"
+	"github.com/cyb3rkh4l1d/subsapi/internal/repository"
+	"github.com/cyb3rkh4l1d/subsapi/internal/service"
+	"github.com/cyb3rkh4l1d/subsapi/internal/validations"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+)
+
+// fakeRepository is an in-memory Repository for router tests. Methods a test doesn't use panic through
+// the embedded nil interface.
+// fakeRepository — Repository в памяти для тестов маршрутизатора. Методы, не используемые тестом, паникуют
+// через встроенный nil-интерфейс.
+type fakeRepository struct {
+	repository.Repository
+	subs []models.Subscription
+}
+
+// statusOf derives the status of sub the way the status filter does, relative to the current time.
+// statusOf определяет статус sub так же, как фильтр статуса, относительно текущего времени.
+func statusOf(sub models.Subscription) string {
+	now := time.Now()
+	switch {
+	case sub.StartDate.After(now):
+		return models.StatusUpcoming
+	case sub.EndDate != nil && sub.EndDate.Before(now):
+		return models.StatusExpired
+	default:
+		return models.StatusActive
+	}
+}
+
+// matchesList reports whether sub passes the status and search filters of the list.
+// matchesList сообщает, проходит ли sub фильтры статуса и поиска списка.
+func matchesList(sub models.Subscription, status, search string) bool {
+	search = strings.ToLower(search)
+	return (status == "" || statusOf(sub) == status) &&
+		(strings.Contains(strings.ToLower(sub.ServiceName), search) || strings.Contains(strings.ToLower(sub.Description), search))
+}
+
+func (r *fakeRepository) ListSubscription(_ context.Context, req *models.ListSubscriptionRequest) (int64, []models.Subscription, error) {
+	var subs []models.Subscription
+	for _, sub := range r.subs {
+		if matchesList(sub, req.Status, req.Search) {
+			subs = append(subs, sub)
+		}
+	}
+	total := int64(len(subs))
+	if req.Offset < len(subs) {
+		subs = subs[req.Offset:min(req.Offset+req.Limit, len(subs))]
+	} else {
+		subs = nil
+	}
+	return total, subs, nil
+}
+
+func (r *fakeRepository) SumSubscriptionPrices(ctx context.Context, status, search string) (int64, error) {
+	var sum int64
+	for _, sub := range r.subs {
+		if matchesList(sub, status, search) {
+			sum += int64(sub.Price)
+		}
+	}
+	return sum, nil
+}
+
+func (r *fakeRepository) CountSubscriptions(ctx context.Context, status, search string) (int64, error) {
+	var total int64
+	for _, sub := range r.subs {
+		if matchesList(sub, status, search) {
+			total++
+		}
+	}
+	return total, nil
+}
+
+func (r *fakeRepository) FindSubscriptionsByUserIDandServiceName(_ context.Context, userID, serviceName string, exact bool) ([]models.Subscription, error) {
+	var found []models.Subscription
+	for _, sub := range r.subs {
+		if sub.UserID == userID && (serviceName == "" || sub.ServiceName == serviceName || !exact && validations.ServiceNameKey(sub.ServiceName) == validations.ServiceNameKey(serviceName)) {
+			found = append(found, sub)
+		}
+	}
+	return found, nil
+}
+
+func (r *fakeRepository) FindSubscriptionsByUserIDInBatches(ctx context.Context, userID string, batchSize int, fn func(batch []models.Subscription) error) error {
+	subs, _ := r.FindSubscriptionsByUserIDandServiceName(ctx, userID, "", false)
+	for batch := range slices.Chunk(subs, batchSize) {
+		if err := fn(batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *fakeRepository) AveragePriceByServiceName(context.Context, string, uint) (float64, int64, error) {
+	return 0, 0, nil
+}
+
+func (r *fakeRepository) GetSubscriptionByID(_ context.Context, id uint) (*models.Subscription, error) {
+	for i := range r.subs {
+		if r.subs[i].ID == id {
+			sub := r.subs[i]
+			return &sub, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeRepository) GetSubscriptionsByIDs(_ context.Context, ids []uint) ([]models.Subscription, error) {
+	var found []models.Subscription
+	for _, sub := range r.subs {
+		if slices.Contains(ids, sub.ID) {
+			found = append(found, sub)
+		}
+	}
+	return found, nil
+}
+
+func (r *fakeRepository) GetSubscriptionsByPublicIDs(_ context.Context, publicIDs []string) ([]models.Subscription, error) {
+	var found []models.Subscription
+	for _, sub := range r.subs {
+		if slices.Contains(publicIDs, sub.PublicID) {
+			found = append(found, sub)
+		}
+	}
+	return found, nil
+}
+
+func (r *fakeRepository) CreateSubscription(_ context.Context, sub *models.Subscription) error {
+	// gorm runs the hook generating the public ID on create
+	// gorm вызывает хук, генерирующий публичный ID, при создании
+	if err := sub.BeforeCreate(nil); err != nil {
+		return err
+	}
+	sub.ID = uint(len(r.subs) + 1)
+	r.subs = append(r.subs, *sub)
+	return nil
+}
+
+func (r *fakeRepository) UpsertSubscription(_ context.Context, sub *models.Subscription) (bool, error) {
+	sub.Upserted = true
+	for i := range r.subs {
+		if r.subs[i].Upserted && r.subs[i].UserID == sub.UserID && strings.EqualFold(r.subs[i].ServiceName, sub.ServiceName) && r.subs[i].StartDate.Equal(sub.StartDate) {
+			sub.ID = r.subs[i].ID
+			r.subs[i] = *sub
+			return false, nil
+		}
+	}
+	return true, r.CreateSubscription(context.Background(), sub)
+}
+
+func (r *fakeRepository) UpdateSubscriptionByID(_ context.Context, sub *models.Subscription) error {
+	for i := range r.subs {
+		if r.subs[i].ID == sub.ID {
+			r.subs[i] = *sub
+		}
+	}
+	return nil
+}
+
+func (r *fakeRepository) DeleteSubscriptionByID(_ context.Context, id uint) error {
+	r.subs = slices.DeleteFunc(r.subs, func(sub models.Subscription) bool { return sub.ID == id })
+	return nil
+}
+
+func (r *fakeRepository) FindSubscriptionsByParentIDs(_ context.Context, parentIDs []uint) ([]models.Subscription, error) {
+	var members []models.Subscription
+	for _, sub := range r.subs {
+		if sub.ParentID != nil && slices.Contains(parentIDs, *sub.ParentID) {
+			members = append(members, sub)
+		}
+	}
+	return members, nil
+}
+
+func (r *fakeRepository) ListPaginated(_ context.Context, limit int, afterID uint) ([]models.Subscription, error) {
+	subs := slices.Clone(r.subs)
+	slices.SortFunc(subs, func(a, b models.Subscription) int { return int(a.ID) - int(b.ID) })
+	subs = slices.DeleteFunc(subs, func(sub models.Subscription) bool { return sub.ID <= afterID })
+	return subs[:min(limit, len(subs))], nil
+}
+
+// newTestRouter builds the API router over repo with the subscription routes registered.
+// newTestRouter создает маршрутизатор API поверх repo с зарегистрированными маршрутами подписок.
+func newTestRouter(cfg *config.Config, repo repository.Repository) *Router {
+	cfg.GinMode = gin.TestMode
+	logger, _ := logtest.NewNullLogger()
+	entry := logrus.NewEntry(logger)
+	handler := handlers.NewSubscriptionHandlers(context.Background(), entry, service.NewSubscriptionService(repo, cfg, entry))
+	router := NewApiRouter(context.Background(), cfg, entry, handler, nil)
+	router.RegisterRoutes(SubscriptionRoutes, UserRoutes)
+	return router
+}
+
+// serve runs a request through the router and returns the recorded response.
+// serve выполняет запрос через маршрутизатор и возвращает записанный ответ.
+func serve(router *Router, method, target string, body io.Reader) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.GinEngine.ServeHTTP(w, httptest.NewRequest(method, target, body))
+	return w
+}
+
+func TestSwaggerRoute(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		status  int
+	}{
+		{"enabled", true, http.StatusOK},
+		{"disabled", false, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTestRouter(&config.Config{EnableSwagger: tt.enabled}, &fakeRepository{})
+			router.RegisterRoutes(SwaggerRoute)
+
+			if w := serve(router, http.MethodGet, "/api/v1/swagger/index.html", nil); w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			registered := slices.ContainsFunc(router.GinEngine.Routes(), func(route gin.RouteInfo) bool {
+				return strings.HasPrefix(route.Path, "/api/v1/swagger")
+			})
+			if registered != tt.enabled {
+				t.Errorf("swagger route registered = %v, want %v", registered, tt.enabled)
+			}
+		})
+	}
+}
+
+func TestListIncludeCounts(t *testing.T) {
+	ended := time.Date(2020, time.December, 1, 0, 0, 0, 0, time.UTC)
+	repo := &fakeRepository{subs: []models.Subscription{
+		{ID: 1, UserID: "60601fee-2bf1-4721-ae6f-7636e79a0cba", ServiceName: "Netflix", Price: 100, StartDate: time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{ID: 2, UserID: "60601fee-2bf1-4721-ae6f-7636e79a0cba", ServiceName: "Spotify", Price: 200, StartDate: time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC), EndDate: &ended},
+		{ID: 3, UserID: "60601fee-2bf1-4721-ae6f-7636e79a0cba", ServiceName: "Yandex Plus", Price: 300, StartDate: time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC), EndDate: &ended},
+	}}
+	router := newTestRouter(&config.Config{}, repo)
+
+	tests := []struct {
+		name  string
+		query string
+		want  map[string]any
+	}{
+		{"without counts", "status=active", map[string]any{"total": 1.0}},
+		{"with counts", "status=active&include_counts=true", map[string]any{"total": 1.0, "unfiltered_total": 3.0, "filtered_out": 2.0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(router, http.MethodGet, "/api/v1/subscriptions/?"+tt.query, nil)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, body %s", w.Code, w.Body)
+			}
+			var res struct{ Meta map[string]any }
+			if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+				t.Fatal(err)
+			}
+			for _, key := range []string{"total", "unfiltered_total", "filtered_out"} {
+				if res.Meta[key] != tt.want[key] {
+					t.Errorf("meta %s = %v, want %v", key, res.Meta[key], tt.want[key])
+				}
+			}
+		})
+	}
+}
+
+func TestMaxQueryLength(t *testing.T) {
+	tests := []struct {
+		name   string
+		limit  int
+		query  string
+		status int
+	}{
+		{"within the limit", 32, "status=active", http.StatusOK},
+		{"over the limit", 32, "status=active&service_name=" + strings.Repeat("x", 32), http.StatusRequestURITooLong},
+		{"limit disabled", 0, "status=active&service_name=" + strings.Repeat("x", 4096), http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTestRouter(&config.Config{Middleware: config.MiddlewareConfig{MaxQueryLength: tt.limit}}, &fakeRepository{})
+			if w := serve(router, http.MethodGet, "/api/v1/subscriptions/?"+tt.query, nil); w.Code != tt.status {
+				t.Errorf("status = %d, want %d, body %s", w.Code, tt.status, w.Body)
+			}
+		})
+	}
+}
+
+func TestSubscriptionIDValidation(t *testing.T) {
+	router := newTestRouter(&config.Config{}, &fakeRepository{})
+
+	for _, route := range router.GinEngine.Routes() {
+		if !strings.Contains(route.Path, ":id") {
+			continue
+		}
+		for _, id := range []string{"abc", "0", "-1"} {
+			target := strings.Replace(route.Path, ":id", id, 1)
+			t.Run(route.Method+" "+target, func(t *testing.T) {
+				if w := serve(router, route.Method, target, nil); w.Code != http.StatusBadRequest {
+					t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+				}
+			})
+		}
+	}
+}
+
+func TestRoot(t *testing.T) {
+	router := newTestRouter(&config.Config{EnableSwagger: true}, &fakeRepository{})
+
+	w := serve(router, http.MethodGet, "/", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if got := w.Header().Get("Content-Type"); !strings.HasPrefix(got, "application/json") {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+	var res models.RootResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Service == "" || res.Links["healthz"] == "" || res.Links["swagger"] == "" {
+		t.Errorf("root = %s, want the service name and its links", w.Body)
+	}
+}
+
+func TestSummaryBudget(t *testing.T) {
+	end := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+	repo := &fakeRepository{subs: []models.Subscription{
+		{ID: 1, UserID: "60601fee-2bf1-4721-ae6f-7636e79a0cba", ServiceName: "Netflix", Price: 100, StartDate: time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC), EndDate: &end},
+	}}
+	router := newTestRouter(&config.Config{}, repo)
+
+	tests := []struct {
+		name       string
+		budget     string
+		overBudget any
+		overage    any
+	}{
+		{"no budget", "", nil, nil},
+		{"under budget", "400", false, 0.0},
+		{"at budget", "300", false, 0.0},
+		{"over budget", "250", true, 50.0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := "/api/v1/subscriptions/summary?user_id=60601fee-2bf1-4721-ae6f-7636e79a0cba&from=01-2025&to=03-2025"
+			if tt.budget != "" {
+				target += "&budget=" + tt.budget
+			}
+			w := serve(router, http.MethodGet, target, nil)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, body %s", w.Code, w.Body)
+			}
+			var res map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+				t.Fatal(err)
+			}
+			if res["over_budget"] != tt.overBudget || res["overage"] != tt.overage {
+				t.Errorf("summary = %s, want over_budget %v and overage %v", w.Body, tt.overBudget, tt.overage)
+			}
+		})
+	}
+}
+
+func TestExportUserData(t *testing.T) {
+	const userID = "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+	repo := &fakeRepository{subs: []models.Subscription{
+		{ID: 1, UserID: userID, ServiceName: "Netflix", Price: 100, StartDate: time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{ID: 2, UserID: "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", ServiceName: "Netflix", Price: 100, StartDate: time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{ID: 3, UserID: userID, ServiceName: "Spotify", Price: 200, StartDate: time.Date(2025, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{ID: 4, UserID: userID, ServiceName: "Yandex Plus", Price: 300, StartDate: time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)},
+	}}
+	router := newTestRouter(&config.Config{}, repo)
+
+	w := serve(router, http.MethodGet, "/api/v1/users/"+userID+"/export", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+	if got, want := w.Header().Get("Content-Disposition"), `attachment; filename="subscriptions-`+userID+`.json"`; got != want {
+		t.Errorf("Content-Disposition = %q, want %q", got, want)
+	}
+	var res models.UserExportResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	var ids []models.SubscriptionID
+	for _, sub := range res.Subscriptions {
+		ids = append(ids, sub.ID)
+	}
+	if res.UserID != userID || res.ExportedAt.IsZero() || !slices.Equal(ids, []models.SubscriptionID{"1", "3", "4"}) {
+		t.Errorf("export = %s, want subscriptions 1, 3 and 4 of %s", w.Body, userID)
+	}
+
+	if w := serve(router, http.MethodGet, "/api/v1/users/not-a-uuid/export", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid user id: status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestValidateBatchDuplicateWarning(t *testing.T) {
+	const userID = "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+	repo := &fakeRepository{subs: []models.Subscription{
+		{ID: 7, UserID: userID, ServiceName: "Netflix", Price: 100, StartDate: time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)},
+	}}
+	router := newTestRouter(&config.Config{}, repo)
+
+	body := `{"subscriptions": [
+		{"service_name": "Netflix", "price": 100, "user_id": "` + userID + `", "start_date": "01-2025"},
+		{"service_name": "Netflix", "price": 100, "user_id": "` + userID + `", "start_date": "02-2025"}
+	]}`
+	w := serve(router, http.MethodPost, "/api/v1/subscriptions/validate-batch", strings.NewReader(body))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+	var res models.ValidateBatchResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Valid != 2 || len(res.Results) != 2 {
+		t.Fatalf("batch = %s, want 2 valid rows", w.Body)
+	}
+	if warnings := res.Results[0].Warnings; len(warnings) != 1 || !strings.Contains(warnings[0], "duplicates subscription 7") {
+		t.Errorf("duplicate row warnings = %q, want one naming subscription 7", warnings)
+	}
+	if warnings := res.Results[1].Warnings; len(warnings) != 0 {
+		t.Errorf("distinct row warnings = %q, want none", warnings)
+	}
+}
+
+func TestUpdateEndDate(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		endDate any
+	}{
+		{"omitted is left unchanged", `{"price": 200}`, "12-2025"},
+		{"null is left unchanged", `{"price": 200, "end_date": null}`, "12-2025"},
+		{"empty clears it", `{"price": 200, "end_date": ""}`, nil},
+		{"value replaces it", `{"price": 200, "end_date": "06-2025"}`, "06-2025"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			end := time.Date(2025, time.December, 1, 0, 0, 0, 0, time.UTC)
+			repo := &fakeRepository{subs: []models.Subscription{
+				{ID: 1, UserID: "60601fee-2bf1-4721-ae6f-7636e79a0cba", ServiceName: "Netflix", Price: 100, StartDate: time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC), EndDate: &end},
+			}}
+			router := newTestRouter(&config.Config{}, repo)
+
+			w := serve(router, http.MethodPut, "/api/v1/subscriptions/1", strings.NewReader(tt.body))
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, body %s", w.Code, w.Body)
+			}
+			var res map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+				t.Fatal(err)
+			}
+			if res["end_date"] != tt.endDate || res["price"] != 200.0 {
+				t.Errorf("updated = %s, want end_date %v and price 200", w.Body, tt.endDate)
+			}
+			// the stored row agrees with the response
+			// сохраненная строка совпадает с ответом
+			if stored := repo.subs[0].EndDate; (stored == nil) != (tt.endDate == nil) {
+				t.Errorf("stored end_date = %v, want %v", stored, tt.endDate)
+			}
+		})
+	}
+}
+
+func TestEmptyListsAreArrays(t *testing.T) {
+	const userID = "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+	repo := &fakeRepository{subs: []models.Subscription{
+		{ID: 1, UserID: "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", ServiceName: "Netflix", Price: 100, StartDate: time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)},
+	}}
+	router := newTestRouter(&config.Config{}, repo)
+
+	tests := []struct {
+		target string
+		field  string
+	}{
+		{"/api/v1/subscriptions/?status=upcoming", "subscriptions"},
+		{"/api/v1/subscriptions/stats/services?user_id=" + userID, "services"},
+		{"/api/v1/subscriptions/1/members", "members"},
+		{"/api/v1/users/" + userID + "/export", "subscriptions"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			w := serve(router, http.MethodGet, tt.target, nil)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, body %s", w.Code, w.Body)
+			}
+			var res map[string]json.RawMessage
+			if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+				t.Fatal(err)
+			}
+			if got := string(res[tt.field]); got != "[]" {
+				t.Errorf("%s = %s, want []", tt.field, got)
+			}
+		})
+	}
+}
+
+func TestListWithTotals(t *testing.T) {
+	start := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	repo := &fakeRepository{}
+	for i, price := range []int{100, 250, 400} {
+		repo.subs = append(repo.subs, models.Subscription{ID: uint(i + 1), UserID: "60601fee-2bf1-4721-ae6f-7636e79a0cba", ServiceName: "Netflix", Price: price, StartDate: start})
+	}
+	router := newTestRouter(&config.Config{}, repo)
+
+	w := serve(router, http.MethodGet, "/api/v1/subscriptions/?limit=2&with_totals=true", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+	var res models.ListSubscriptionsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Totals == nil {
+		t.Fatalf("list = %s, want a totals footer", w.Body)
+	}
+	var rowSum int64
+	for _, sub := range res.Subscriptions {
+		rowSum += int64(sub.Price)
+	}
+	want := models.ListTotals{PageCount: 2, PagePriceSum: rowSum, Count: 3, PriceSum: 750}
+	if *res.Totals != want || len(res.Subscriptions) != 2 {
+		t.Errorf("totals = %+v over %d rows, want %+v", *res.Totals, len(res.Subscriptions), want)
+	}
+}
+
+func TestMaxOffset(t *testing.T) {
+	tests := []struct {
+		name   string
+		limit  int
+		offset string
+		status int
+	}{
+		{"at the limit", 100, "100", http.StatusOK},
+		{"over the limit", 100, "101", http.StatusBadRequest},
+		{"limit disabled", 0, "1000000", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTestRouter(&config.Config{MaxOffset: tt.limit}, &fakeRepository{})
+			w := serve(router, http.MethodGet, "/api/v1/subscriptions/?offset="+tt.offset, nil)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d, body %s", w.Code, tt.status, w.Body)
+			}
+			if tt.status != http.StatusBadRequest {
+				return
+			}
+			var res models.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+				t.Fatal(err)
+			}
+			if res.Error != validations.ErrOffsetTooLarge.Error() || res.Details != "maximum offset is 100" {
+				t.Errorf("error = %+v, want ErrOffsetTooLarge with the maximum offset", res)
+			}
+		})
+	}
+}
+
+func TestSubscriptionDescription(t *testing.T) {
+	const userID = "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+	tooLong := strings.Repeat("a", 501)
+
+	create := []struct {
+		name        string
+		body        string
+		status      int
+		description any
+	}{
+		{"without description", `{"service_name": "Netflix", "price": 100, "user_id": "` + userID + `", "start_date": "01-2025"}`, http.StatusCreated, nil},
+		{"with description", `{"service_name": "Netflix", "price": 100, "user_id": "` + userID + `", "start_date": "01-2025", "description": "  family movies "}`, http.StatusCreated, "family movies"},
+		{"too long description", `{"service_name": "Netflix", "price": 100, "user_id": "` + userID + `", "start_date": "01-2025", "description": "` + tooLong + `"}`, http.StatusBadRequest, nil},
+	}
+	for _, tt := range create {
+		t.Run("create "+tt.name, func(t *testing.T) {
+			repo := &fakeRepository{}
+			router := newTestRouter(&config.Config{}, repo)
+
+			w := serve(router, http.MethodPost, "/api/v1/subscriptions/", strings.NewReader(tt.body))
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d, body %s", w.Code, tt.status, w.Body)
+			}
+			if tt.status != http.StatusCreated {
+				if len(repo.subs) != 0 {
+					t.Errorf("rejected subscription was stored: %+v", repo.subs)
+				}
+				return
+			}
+			var res map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+				t.Fatal(err)
+			}
+			if res["description"] != tt.description {
+				t.Errorf("description = %v, want %v", res["description"], tt.description)
+			}
+		})
+	}
+
+	update := []struct {
+		name        string
+		body        string
+		status      int
+		description string
+	}{
+		{"omitted is left unchanged", `{"price": 200}`, http.StatusOK, "family movies"},
+		{"null is left unchanged", `{"description": null}`, http.StatusOK, "family movies"},
+		{"empty clears it", `{"description": ""}`, http.StatusOK, ""},
+		{"value replaces it", `{"description": "work account"}`, http.StatusOK, "work account"},
+		{"too long is rejected", `{"description": "` + tooLong + `"}`, http.StatusBadRequest, "family movies"},
+	}
+	for _, tt := range update {
+		t.Run("update "+tt.name, func(t *testing.T) {
+			repo := &fakeRepository{subs: []models.Subscription{
+				{ID: 1, UserID: userID, ServiceName: "Netflix", Price: 100, StartDate: time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC), Description: "family movies"},
+			}}
+			router := newTestRouter(&config.Config{}, repo)
+
+			w := serve(router, http.MethodPut, "/api/v1/subscriptions/1", strings.NewReader(tt.body))
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d, body %s", w.Code, tt.status, w.Body)
+			}
+			if stored := repo.subs[0].Description; stored != tt.description {
+				t.Errorf("stored description = %q, want %q", stored, tt.description)
+			}
+		})
+	}
+}
+
+func TestCreateWithoutEndDate(t *testing.T) {
+	repo := &fakeRepository{}
+	router := newTestRouter(&config.Config{}, repo)
+
+	w := serve(router, http.MethodPost, "/api/v1/subscriptions/", strings.NewReader(
+		`{"service_name": "Netflix", "price": 400, "user_id": "60601fee-2bf1-4721-ae6f-7636e79a0cba", "start_date": "01-2025"}`,
+	))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201: %s", w.Code, w.Body)
+	}
+	if !strings.Contains(w.Body.String(), `"end_date":null`) {
+		t.Errorf("body = %s, want a null end_date", w.Body)
+	}
+	if len(repo.subs) != 1 || repo.subs[0].EndDate != nil {
+		t.Errorf("stored = %+v, want one open-ended subscription", repo.subs)
+	}
+}
+
+func TestCreateUpsert(t *testing.T) {
+	const body = `{"service_name": "%s", "price": %d, "user_id": "60601fee-2bf1-4721-ae6f-7636e79a0cba", "start_date": "01-2025"}`
+	repo := &fakeRepository{}
+	router := newTestRouter(&config.Config{}, repo)
+
+	steps := []struct {
+		name     string
+		target   string
+		service  string
+		price    int
+		status   int
+		header   string
+		location string
+	}{
+		{"upsert inserts", "/api/v1/subscriptions/?upsert=true", "Netflix", 400, http.StatusCreated, "Location", "/api/v1/subscriptions/1"},
+		{"upsert replaces", "/api/v1/subscriptions/?upsert=true", "NETFLIX", 500, http.StatusOK, "Content-Location", "/api/v1/subscriptions/1"},
+		{"plain create duplicates", "/api/v1/subscriptions/", "Netflix", 600, http.StatusCreated, "Location", "/api/v1/subscriptions/2"},
+		{"upsert leaves the plain one", "/api/v1/subscriptions/?upsert=true", "netflix", 700, http.StatusOK, "Content-Location", "/api/v1/subscriptions/1"},
+	}
+	for _, step := range steps {
+		w := serve(router, http.MethodPost, step.target, strings.NewReader(fmt.Sprintf(body, step.service, step.price)))
+		if w.Code != step.status || w.Header().Get(step.header) != step.location {
+			t.Fatalf("%s: got %d with %s %q, want %d with %q, body %s", step.name, w.Code, step.header, w.Header().Get(step.header), step.status, step.location, w.Body)
+		}
+	}
+	if len(repo.subs) != 2 || repo.subs[0].Price != 700 || repo.subs[1].Price != 600 {
+		t.Errorf("stored = %+v, want the upserted one replaced and the plain one kept", repo.subs)
+	}
+}
+
+func TestStrictQueryParams(t *testing.T) {
+	tests := []struct {
+		name   string
+		strict bool
+		target string
+		status int
+	}{
+		{"strict known", true, "/api/v1/subscriptions/?limit=5&order=asc", http.StatusOK},
+		{"strict unknown", true, "/api/v1/subscriptions/?limit=5&user_di=x", http.StatusBadRequest},
+		{"lenient unknown", false, "/api/v1/subscriptions/?limit=5&user_di=x", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTestRouter(&config.Config{StrictQueryParams: tt.strict}, &fakeRepository{})
+			w := serve(router, http.MethodGet, tt.target, nil)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.status != http.StatusBadRequest {
+				return
+			}
+			var res models.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+				t.Fatal(err)
+			}
+			if res.Code != "unknown_query_param" || res.Details != "user_di" {
+				t.Errorf("got %+v, want unknown_query_param naming user_di", res)
+			}
+		})
+	}
+}
+
+func TestResponsesAreJSON(t *testing.T) {
+	repo := &fakeRepository{subs: []models.Subscription{
+		{ID: 1, UserID: "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", ServiceName: "Netflix", Price: 999, StartDate: time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)},
+	}}
+	// keep the stack of the deliberate panic out of the test output
+	// не выводить стек намеренной паники в вывод теста
+	errorWriter := gin.DefaultErrorWriter
+	gin.DefaultErrorWriter = io.Discard
+	t.Cleanup(func() { gin.DefaultErrorWriter = errorWriter })
+	router := newTestRouter(&config.Config{}, repo)
+	router.GinEngine.GET("/panic", func(*gin.Context) { panic("boom") })
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		status int
+		code   string
+	}{
+		{"success", http.MethodGet, "/api/v1/subscriptions/1", http.StatusOK, ""},
+		{"invalid id", http.MethodGet, "/api/v1/subscriptions/abc", http.StatusBadRequest, "invalid_request_input"},
+		{"not found", http.MethodGet, "/api/v1/subscriptions/2", http.StatusNotFound, "subscription_not_found"},
+		{"panic", http.MethodGet, "/panic", http.StatusInternalServerError, "internal_error"},
+		{"unknown route", http.MethodGet, "/api/v1/nope", http.StatusNotFound, "route_not_found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(router, tt.method, tt.target, nil)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if got := w.Header().Get("Content-Type"); got != "application/json; charset=utf-8" {
+				t.Errorf("Content-Type = %q, want application/json; charset=utf-8", got)
+			}
+			var body map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("body is not a JSON object: %v: %s", err, w.Body)
+			}
+			if tt.code == "" {
+				if _, ok := body["service_id"]; !ok {
+					t.Errorf("success body %v has no service_id", body)
+				}
+				return
+			}
+			if body["code"] != tt.code {
+				t.Errorf("code = %v, want %s", body["code"], tt.code)
+			}
+			if message, _ := body["error"].(string); message == "" {
+				t.Errorf("error envelope %v has no error message", body)
+			}
+		})
+	}
+}
+
+func TestJSONAPIContentType(t *testing.T) {
+	repo := &fakeRepository{subs: []models.Subscription{
+		{ID: 1, UserID: "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", ServiceName: "Netflix", Price: 999, StartDate: time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)},
+	}}
+	router := newTestRouter(&config.Config{}, repo)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/subscriptions/1", nil)
+	req.Header.Set("Accept", "application/vnd.api+json")
+	router.GinEngine.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body)
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/vnd.api+json" {
+		t.Errorf("Content-Type = %q, want application/vnd.api+json", got)
+	}
+	var body struct {
+		Data struct {
+			Type string `json:"type"`
+			ID   string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Data.Type != "subscriptions" || body.Data.ID != "1" {
+		t.Errorf("body %s is not a JSON:API subscription resource: %v", w.Body, err)
+	}
+}
+
+func TestNoContentHasNoBody(t *testing.T) {
+	repo := &fakeRepository{subs: []models.Subscription{{ID: 1, UserID: "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", ServiceName: "Netflix", Price: 999}}}
+	router := newTestRouter(&config.Config{}, repo)
+
+	w := serve(router, http.MethodDelete, "/api/v1/subscriptions/1", nil)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want 204: %s", w.Code, w.Body)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("204 body = %q, want empty", w.Body)
+	}
+	if len(repo.subs) != 0 {
+		t.Error("subscription not deleted")
+	}
+}
+
+func TestUUIDSubscriptionIDs(t *testing.T) {
+	models.SetUUIDSubscriptionIDs(true)
+	t.Cleanup(func() { models.SetUUIDSubscriptionIDs(false) })
+	repo := &fakeRepository{}
+	router := newTestRouter(&config.Config{}, repo)
+
+	// create returns the UUID in service_id and Location, and accepts it as a parent
+	// создание возвращает UUID в service_id и Location и принимает его в качестве родителя
+	create := func(body string) (models.SubscriptionResponse, string) {
+		t.Helper()
+		w := serve(router, http.MethodPost, "/api/v1/subscriptions/", strings.NewReader(body))
+		if w.Code != http.StatusCreated {
+			t.Fatalf("create: status = %d, want 201: %s", w.Code, w.Body)
+		}
+		var res models.SubscriptionResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := validations.ValidatePublicSubscriptionID(string(res.ID)); err != nil {
+			t.Fatalf("create: service_id %q is not a UUID: %s", res.ID, w.Body)
+		}
+		if got, want := w.Header().Get("Location"), "/api/v1/subscriptions/"+string(res.ID); got != want {
+			t.Errorf("create: Location = %q, want %q", got, want)
+		}
+		return res, w.Body.String()
+	}
+	parent, _ := create(`{"service_name":"Netflix","price":999,"user_id":"a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11","start_date":"01-2025"}`)
+	member, body := create(`{"service_name":"Netflix","price":1,"user_id":"60601fee-2bf1-4721-ae6f-7636e79a0cba","start_date":"01-2025","parent_id":"` + string(parent.ID) + `"}`)
+	if member.ParentID == nil || *member.ParentID != parent.ID || !strings.Contains(body, `"parent_id":"`+string(parent.ID)+`"`) {
+		t.Errorf("member = %s, want parent_id %s", body, parent.ID)
+	}
+
+	target := "/api/v1/subscriptions/" + string(member.ID)
+	if w := serve(router, http.MethodGet, target, nil); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"service_id":"`+string(member.ID)+`"`) {
+		t.Errorf("get: status = %d: %s", w.Code, w.Body)
+	}
+	if w := serve(router, http.MethodPut, target, strings.NewReader(`{"price":500}`)); w.Code != http.StatusOK || repo.subs[1].Price != 500 {
+		t.Errorf("update: status = %d, price = %d: %s", w.Code, repo.subs[1].Price, w.Body)
+	}
+	if w := serve(router, http.MethodDelete, target, nil); w.Code != http.StatusNoContent || len(repo.subs) != 1 {
+		t.Errorf("delete: status = %d, %d subscriptions left", w.Code, len(repo.subs))
+	}
+	if w := serve(router, http.MethodGet, target, nil); w.Code != http.StatusNotFound {
+		t.Errorf("get deleted: status = %d, want 404", w.Code)
+	}
+
+	// the integer primary key no longer addresses a subscription
+	// целочисленный первичный ключ больше не указывает на подписку
+	if w := serve(router, http.MethodGet, "/api/v1/subscriptions/1", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("get by integer id: status = %d, want 400", w.Code)
+	}
+}
+
+func TestCursorPagination(t *testing.T) {
+	repo := &fakeRepository{}
+	for id := range uint(3) {
+		repo.subs = append(repo.subs, models.Subscription{ID: id + 1, ServiceName: "Netflix", Price: 1})
+	}
+	router := newTestRouter(&config.Config{}, repo)
+
+	var ids []models.SubscriptionID
+	target := "/api/v1/subscriptions/?pagination=cursor&limit=2"
+	for page := 0; page < 2; page++ {
+		w := serve(router, http.MethodGet, target, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("page %d: status = %d: %s", page, w.Code, w.Body)
+		}
+		var res models.CursorSubscriptionsResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Fatal(err)
+		}
+		for _, item := range res.Items {
+			ids = append(ids, item.ID)
+		}
+		if page == 0 && res.NextCursor == "" {
+			t.Fatal("first page has no next_cursor")
+		}
+		if page == 1 && res.NextCursor != "" {
+			t.Errorf("last page next_cursor = %q, want empty", res.NextCursor)
+		}
+		// after alone selects the cursor pagination
+		// after сам по себе выбирает пагинацию курсором
+		target = "/api/v1/subscriptions/?limit=2&after=" + res.NextCursor
+	}
+	if want := []models.SubscriptionID{"1", "2", "3"}; !slices.Equal(ids, want) {
+		t.Errorf("walked %v, want %v", ids, want)
+	}
+}
+
+func TestCursorPaginationLimit(t *testing.T) {
+	repo := &fakeRepository{}
+	for id := range uint(60) {
+		repo.subs = append(repo.subs, models.Subscription{ID: id + 1, ServiceName: "Netflix", Price: 1})
+	}
+	router := newTestRouter(&config.Config{StrictQueryParams: true}, repo)
+
+	tests := []struct {
+		query  string
+		status int
+		items  int
+	}{
+		{"pagination=cursor", http.StatusOK, 50},
+		{"pagination=cursor&limit=200", http.StatusOK, 60},
+		{"pagination=cursor&limit=201", http.StatusBadRequest, 0},
+		{"pagination=cursor&limit=0", http.StatusBadRequest, 0},
+		{"pagination=cursor&limit=-1", http.StatusBadRequest, 0},
+		{"pagination=cursor&limit=ten", http.StatusBadRequest, 0},
+		{"after=bad", http.StatusBadRequest, 0},
+		{"pagination=pages", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			w := serve(router, http.MethodGet, "/api/v1/subscriptions/?"+tt.query, nil)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			var res models.CursorSubscriptionsResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+				t.Fatal(err)
+			}
+			if len(res.Items) != tt.items {
+				t.Errorf("%d items, want %d", len(res.Items), tt.items)
+			}
+		})
+	}
+}
+
+func TestPagePagination(t *testing.T) {
+	repo := &fakeRepository{}
+	for id := range uint(25) {
+		repo.subs = append(repo.subs, models.Subscription{ID: id + 1, ServiceName: "Netflix", Price: 1})
+	}
+	router := newTestRouter(&config.Config{}, repo)
+
+	w := serve(router, http.MethodGet, "/api/v1/subscriptions/?page=3&page_size=10&order=asc", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	var res models.PaginatedSubscriptions
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 25 || res.Page != 3 || res.PageSize != 10 || res.TotalPages != 3 {
+		t.Errorf("got total %d, page %d, page_size %d, total_pages %d, want 25, 3, 10, 3", res.Total, res.Page, res.PageSize, res.TotalPages)
+	}
+	if len(res.Items) != 5 || res.Items[0].ID != "21" {
+		t.Errorf("got %d items starting at %v, want 5 starting at 21", len(res.Items), res.Items)
+	}
+	if res.UnfilteredTotal != nil || res.Totals != nil {
+		t.Errorf("counts or totals set without being requested: %s", w.Body)
+	}
+
+	// the counts and totals options still apply to a page
+	// параметры счетчиков и итогов по-прежнему применяются к странице
+	w = serve(router, http.MethodGet, "/api/v1/subscriptions/?page=2&page_size=20&include_counts=true&with_totals=true", nil)
+	res = models.PaginatedSubscriptions{}
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.UnfilteredTotal == nil || *res.UnfilteredTotal != 25 || res.FilteredOut == nil || *res.FilteredOut != 0 {
+		t.Errorf("counts missing from the page: %s", w.Body)
+	}
+	if res.Totals == nil || res.Totals.PageCount != 5 || res.Totals.Count != 25 || res.Totals.PriceSum != 25 {
+		t.Errorf("totals = %+v, want 5 rows of 25 summing to 25", res.Totals)
+	}
+
+	// without page or page_size the list keeps its meta, with no page fields
+	// без page и page_size список сохраняет свои метаданные без полей страниц
+	w = serve(router, http.MethodGet, "/api/v1/subscriptions/?offset=5&limit=10", nil)
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	var meta map[string]any
+	if err := json.Unmarshal(body["meta"], &meta); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"page", "page_size", "total_pages"} {
+		if _, ok := meta[key]; ok {
+			t.Errorf("meta has %s without page or page_size", key)
+		}
+	}
+	if _, ok := body["items"]; ok {
+		t.Error("offset list answered with the page envelope")
+	}
+}
+
+func TestPagePaginationJSONAPI(t *testing.T) {
+	repo := &fakeRepository{}
+	for id := range uint(3) {
+		repo.subs = append(repo.subs, models.Subscription{ID: id + 1, ServiceName: "Netflix", Price: 1})
+	}
+	router := newTestRouter(&config.Config{}, repo)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/subscriptions/?page=2&page_size=2&with_totals=true", nil)
+	req.Header.Set("Accept", "application/vnd.api+json")
+	w := httptest.NewRecorder()
+	router.GinEngine.ServeHTTP(w, req)
+
+	if got := w.Header().Get("Content-Type"); got != "application/vnd.api+json" {
+		t.Errorf("Content-Type = %q, want application/vnd.api+json", got)
+	}
+	var doc struct {
+		Data []models.JSONAPIResource `json:"data"`
+		Meta struct {
+			models.PageNumberMeta
+			Totals *models.ListTotals `json:"totals"`
+		} `json:"meta"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Data) != 1 || doc.Data[0].ID != "3" {
+		t.Errorf("data = %+v, want subscription 3", doc.Data)
+	}
+	if doc.Meta.Page != 2 || doc.Meta.TotalPages != 2 || doc.Meta.Totals == nil || doc.Meta.Totals.Count != 3 {
+		t.Errorf("meta = %+v, want page 2 of 2 with the totals", doc.Meta)
+	}
+}
@@ -1,17 +1,46 @@
 package router
 
+import (
+	"github.com/cyb3rkh4l1d/subsapi/internal/middleware"
+	"github.com/cyb3rkh4l1d/subsapi/internal/models"
+)
+
 // SubscriptionRoutes configures the subscription-specific CRUD endpoints
 // SubscriptionRoutes настраивает конечные точки CRUD, специфичные для каждой подписки.
 func SubscriptionRoutes(router *Router) {
 
-	subscriptions := router.GinEngine.Group("/api/v1/subscriptions")
+	subscriptions := router.GinEngine.Group("/api/v1/subscriptions", router.tenant...)
+
+	// every route names the request structs its handler binds from the query, for STRICT_QUERY_PARAMS
+	// каждый маршрут указывает структуры, которые его обработчик привязывает из запроса, для STRICT_QUERY_PARAMS
+	subscriptions.POST("/", router.allowQuery(models.CreateSubscriptionQuery{}), router.Handler.CreateSubscription)
+	subscriptions.GET("/", router.allowQuery(models.ListSubscriptionRequest{}, models.CursorListRequest{}), router.Handler.ListSubscriptions)
+	subscriptions.GET("/summary", router.allowQuery(models.UserSubscriptionSummaryRequest{}), router.Handler.GetUserSubscriptionSummary)
+	subscriptions.GET("/periods", router.allowQuery(models.SubscriptionPeriodsRequest{}), router.Handler.GetSubscriptionPeriods)
+	subscriptions.GET("/stats/services", router.allowQuery(models.ServiceStatsRequest{}), router.Handler.GetServiceStats)
+	subscriptions.GET("/stats/compare", router.allowQuery(models.StatsCompareRequest{}), router.Handler.CompareStatsPeriods)
+	subscriptions.GET("/stats/quarterly", router.allowQuery(models.QuarterlyStatsRequest{}), router.Handler.GetQuarterlyStats)
+	subscriptions.GET("/stats/avg-price", router.allowQuery(models.PriceStatsRequest{}), router.Handler.GetPriceStats)
+	subscriptions.GET("/stats/revenue", router.allowQuery(models.RevenueByMonthRequest{}), router.Handler.GetRevenueByMonth)
+	subscriptions.POST("/stats/team", router.allowQuery(), router.Handler.GetTeamStats)
+	subscriptions.POST("/validate-batch", router.allowQuery(), router.Handler.ValidateSubscriptionsBatch)
+	subscriptions.POST("/compare", router.allowQuery(), router.Handler.CompareSubscriptions)
 
-	subscriptions.POST("/", router.Handler.CreateSubscription)
-	subscriptions.GET("/", router.Handler.ListSubscriptions)
-	subscriptions.GET("/:id", router.Handler.GetSubscription)
-	subscriptions.PUT("/:id", router.Handler.UpdateSubscription)
-	subscriptions.DELETE("/:id", router.Handler.DeleteSubscription)
-	subscriptions.GET("/summary", router.Handler.GetUserSubscriptionSummary)
+	// every route below shares the :id validation, resolving UUIDs to primary keys when subscriptions are exposed by UUID
+	// все маршруты ниже используют общую проверку :id, с заменой UUID первичными ключами, когда подписки доступны по UUID
+	subscriptionID := middleware.ValidateSubscriptionID()
+	if models.UUIDSubscriptionIDs() {
+		subscriptionID = middleware.ResolveSubscriptionID(router.Handler.ResolveSubscriptionID)
+	}
+	subscription := subscriptions.Group("/:id", subscriptionID)
+	subscription.GET("", router.allowQuery(), router.Handler.GetSubscription)
+	subscription.PUT("", router.allowQuery(), router.Handler.UpdateSubscription)
+	subscription.DELETE("", router.allowQuery(), router.Handler.DeleteSubscription)
+	subscription.POST("/cancel", router.allowQuery(), router.Handler.CancelSubscription)
+	subscription.POST("/extend", router.allowQuery(), router.Handler.ExtendSubscription)
+	subscription.GET("/members", router.allowQuery(), router.Handler.ListSubscriptionMembers)
+	subscription.GET("/cost-per-month", router.allowQuery(models.CostPerMonthRequest{}), router.Handler.GetCostPerMonth)
+	subscription.GET("/timeline", router.allowQuery(models.SubscriptionTimelineRequest{}), router.Handler.GetSubscriptionTimeline)
 
 	router.Logger.Info("/api/vi/subscriptions: subscriptions api has been added")
 }
@@ -7,8 +7,15 @@ import (
 
 // SwaggerRoute configures the Swagger UI endpoint
 // SwaggerRoute настраивает конечную точку Swagger UI
+// The route isn't registered at all when ENABLE_SWAGGER is false.
+// Маршрут не регистрируется вовсе, если ENABLE_SWAGGER равен false.
 func SwaggerRoute(router *Router) {
 
+	if !router.config.EnableSwagger {
+		router.Logger.Info("/api/v1/swagger: swagger api is disabled")
+		return
+	}
+
 	router.GinEngine.GET("/api/v1/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	router.Logger.Info("/api/v1/swagger: swagger api has been added")
 }
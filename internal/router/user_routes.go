@@ -0,0 +1,15 @@
+package router
+
+import "github.com/cyb3rkh4l1d/subsapi/internal/middleware"
+
+// UserRoutes configures the user-scoped endpoints, erasure is guarded by the admin API key
+// UserRoutes настраивает эндпоинты пользователя, удаление защищено ключом API администратора
+func UserRoutes(router *Router) {
+
+	users := router.GinEngine.Group("/api/v1/users", router.tenant...)
+
+	users.GET("/:user_id/export", router.allowQuery(), router.Handler.ExportUserData)
+	users.DELETE("/:user_id/subscriptions", router.allowQuery(), middleware.AdminAuth(router.config.AdminAPIKey), router.Handler.EraseUserData)
+
+	router.Logger.Info("/api/v1/users: users api has been added")
+}
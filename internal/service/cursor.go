@@ -0,0 +1,34 @@
+package service
+
+import (
+	"encoding/base64"
+	"strconv"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/validations"
+)
+
+/*.....................................................................
+
+					Functions/Methods Definations
+
+........................................................................*/
+
+// EncodeCursor encodes the id of the last subscription of a page into an opaque list cursor.
+// EncodeCursor кодирует id последней подписки страницы в непрозрачный курсор списка.
+func EncodeCursor(id uint) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(uint64(id), 10)))
+}
+
+// DecodeCursor decodes a list cursor made by EncodeCursor, rejecting anything else with ErrInvalidCursor.
+// DecodeCursor декодирует курсор списка, созданный EncodeCursor, отклоняя любой другой с ErrInvalidCursor.
+func DecodeCursor(cursor string) (uint, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, validations.ErrInvalidCursor
+	}
+	id, err := strconv.ParseUint(string(raw), 10, 0)
+	if err != nil || id == 0 {
+		return 0, validations.ErrInvalidCursor
+	}
+	return uint(id), nil
+}
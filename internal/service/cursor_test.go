@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/models"
+	"github.com/cyb3rkh4l1d/subsapi/internal/validations"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	for _, id := range []uint{1, 42, 1<<32 + 7} {
+		got, err := DecodeCursor(EncodeCursor(id))
+		if err != nil || got != id {
+			t.Errorf("DecodeCursor(EncodeCursor(%d)) = %d, %v", id, got, err)
+		}
+	}
+}
+
+func TestDecodeCursorRejectsMalformed(t *testing.T) {
+	for _, cursor := range []string{"!!", EncodeCursor(0), "YWJj", "LTE"} {
+		if _, err := DecodeCursor(cursor); !errors.Is(err, validations.ErrInvalidCursor) {
+			t.Errorf("DecodeCursor(%q) = %v, want ErrInvalidCursor", cursor, err)
+		}
+	}
+}
+
+func TestListSubscriptionsAfterWalksEveryPage(t *testing.T) {
+	repo := &fakeRepository{}
+	for _, id := range []uint{5, 1, 3, 4, 2} {
+		repo.subs = append(repo.subs, models.Subscription{ID: id})
+	}
+	svc := newTestService(repo)
+
+	var seen []uint
+	var cursors []string
+	after := ""
+	for page := 0; ; page++ {
+		if page > len(repo.subs) {
+			t.Fatal("pagination doesn't end")
+		}
+		subs, next, err := svc.ListSubscriptionsAfter(context.Background(), 2, after)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, sub := range subs {
+			seen = append(seen, sub.ID)
+		}
+		cursors = append(cursors, next)
+		if next == "" {
+			break
+		}
+		after = next
+	}
+
+	if want := []uint{1, 2, 3, 4, 5}; !slices.Equal(seen, want) {
+		t.Errorf("walked %v, want %v", seen, want)
+	}
+	if want := []string{EncodeCursor(2), EncodeCursor(4), ""}; !slices.Equal(cursors, want) {
+		t.Errorf("cursors %q, want %q", cursors, want)
+	}
+}
+
+func TestListSubscriptionsAfterLastPageFull(t *testing.T) {
+	repo := &fakeRepository{subs: []models.Subscription{{ID: 1}, {ID: 2}}}
+
+	subs, next, err := newTestService(repo).ListSubscriptionsAfter(context.Background(), 2, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subs) != 2 || next != "" {
+		t.Errorf("got %d subscriptions and cursor %q, want 2 and an empty cursor", len(subs), next)
+	}
+}
+
+func TestListSubscriptionsAfterInvalidCursor(t *testing.T) {
+	_, _, err := newTestService(&fakeRepository{}).ListSubscriptionsAfter(context.Background(), 2, "not a cursor")
+	if !errors.Is(err, validations.ErrInvalidCursor) {
+		t.Errorf("err = %v, want ErrInvalidCursor", err)
+	}
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/config"
+	"github.com/cyb3rkh4l1d/subsapi/internal/models"
+	"github.com/cyb3rkh4l1d/subsapi/internal/repository"
+	"github.com/cyb3rkh4l1d/subsapi/internal/validations"
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+)
+
+// fakeRepository is an in-memory Repository for service tests. Methods a test doesn't use panic through
+// the embedded nil interface.
+// fakeRepository — Repository в памяти для тестов сервиса. Методы, не используемые тестом, паникуют через
+// встроенный nil-интерфейс.
+type fakeRepository struct {
+	repository.Repository
+	subs []models.Subscription
+
+	// activeAt records the month argument of the last CountDistinctUsers call
+	// activeAt запоминает аргумент месяца последнего вызова CountDistinctUsers
+	activeAt *time.Time
+}
+
+// newTestService builds a service over repo with an empty config and a discarding logger.
+// newTestService создает сервис поверх repo с пустой конфигурацией и отбрасывающим логгером.
+func newTestService(repo repository.Repository) *SubscriptionService {
+	logger, _ := logtest.NewNullLogger()
+	return NewSubscriptionService(repo, &config.Config{}, logrus.NewEntry(logger))
+}
+
+func (r *fakeRepository) CountDistinctUsers(_ context.Context, activeAt *time.Time) (int64, error) {
+	r.activeAt = activeAt
+	return 0, nil
+}
+
+func (r *fakeRepository) GetSubscriptionByID(_ context.Context, id uint) (*models.Subscription, error) {
+	for i := range r.subs {
+		if r.subs[i].ID == id {
+			return &r.subs[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeRepository) FindSubscriptionsByUserIDandServiceName(_ context.Context, userID, serviceName string, exact bool) ([]models.Subscription, error) {
+	var found []models.Subscription
+	for _, sub := range r.subs {
+		if sub.UserID == userID && (serviceName == "" || sub.ServiceName == serviceName || !exact && validations.ServiceNameKey(sub.ServiceName) == validations.ServiceNameKey(serviceName)) {
+			found = append(found, sub)
+		}
+	}
+	return found, nil
+}
+
+func (r *fakeRepository) FindSubscriptionsByParentIDs(_ context.Context, parentIDs []uint) ([]models.Subscription, error) {
+	var members []models.Subscription
+	for _, sub := range r.subs {
+		if sub.ParentID != nil && slices.Contains(parentIDs, *sub.ParentID) {
+			members = append(members, sub)
+		}
+	}
+	return members, nil
+}
+
+func (r *fakeRepository) FindSubscriptionsByUserIDs(_ context.Context, userIDs []string) ([]models.Subscription, error) {
+	var found []models.Subscription
+	for _, sub := range r.subs {
+		if slices.Contains(userIDs, sub.UserID) {
+			found = append(found, sub)
+		}
+	}
+	return found, nil
+}
+
+func (r *fakeRepository) UpdateSubscriptionByID(_ context.Context, sub *models.Subscription) error {
+	for i := range r.subs {
+		if r.subs[i].ID == sub.ID {
+			r.subs[i] = *sub
+		}
+	}
+	return nil
+}
+
+func (r *fakeRepository) AveragePriceByServiceName(context.Context, string, uint) (float64, int64, error) {
+	return 0, 0, nil
+}
+
+// RevenueByMonth sums the prices month by month the way the SQL aggregate does.
+// RevenueByMonth суммирует цены по месяцам так же, как агрегат SQL.
+func (r *fakeRepository) RevenueByMonth(_ context.Context, from, to time.Time, filter repository.RevenueFilter) ([]repository.MonthlyRevenue, error) {
+	var revenue []repository.MonthlyRevenue
+	for month := from; !month.After(to); month = month.AddDate(0, 1, 0) {
+		point := repository.MonthlyRevenue{Month: month}
+		for _, sub := range r.subs {
+			if filter.UserID != "" && sub.UserID != filter.UserID || filter.SubscriptionID != 0 && sub.ID != filter.SubscriptionID {
+				continue
+			}
+			if !sub.StartDate.After(month) && (sub.EndDate == nil || !sub.EndDate.Before(month)) {
+				point.Revenue += int64(sub.Price)
+			}
+		}
+		revenue = append(revenue, point)
+	}
+	return revenue, nil
+}
+
+func (r *fakeRepository) PriceStatsByServiceName(_ context.Context, serviceName string) (*repository.PriceStats, error) {
+	var prices []int
+	for _, sub := range r.subs {
+		if validations.ServiceNameKey(sub.ServiceName) == validations.ServiceNameKey(serviceName) {
+			prices = append(prices, sub.Price)
+		}
+	}
+	stats := &repository.PriceStats{Count: int64(len(prices))}
+	if len(prices) == 0 {
+		return stats, nil
+	}
+	slices.Sort(prices)
+	sum := 0
+	for _, price := range prices {
+		sum += price
+	}
+	// the average of the middle price or two, as the SQL median
+	// среднее одной или двух средних цен, как медиана SQL
+	middle := len(prices) / 2
+	stats.Median = float64(prices[middle])
+	if len(prices)%2 == 0 {
+		stats.Median = float64(prices[middle-1]+prices[middle]) / 2
+	}
+	stats.Average = float64(sum) / float64(len(prices))
+	stats.Min, stats.Max = prices[0], prices[len(prices)-1]
+	return stats, nil
+}
+
+func (r *fakeRepository) CreateSubscription(_ context.Context, sub *models.Subscription) error {
+	sub.ID = uint(len(r.subs) + 1)
+	r.subs = append(r.subs, *sub)
+	return nil
+}
+
+func (r *fakeRepository) CountSubscriptions(_ context.Context, _, _ string) (int64, error) {
+	return int64(len(r.subs)), nil
+}
+
+func (r *fakeRepository) ListPaginated(_ context.Context, limit int, afterID uint) ([]models.Subscription, error) {
+	subs := slices.Clone(r.subs)
+	slices.SortFunc(subs, func(a, b models.Subscription) int { return int(a.ID) - int(b.ID) })
+	subs = slices.DeleteFunc(subs, func(sub models.Subscription) bool { return sub.ID <= afterID })
+	return subs[:min(limit, len(subs))], nil
+}
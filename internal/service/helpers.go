@@ -1,11 +1,16 @@
 package service
 
 import (
+	"cmp"
 	"fmt"
+	"math"
+	"slices"
+	"strconv"
 	"time"
 
 	"github.com/cyb3rkh4l1d/subsapi/internal/models"
 	"github.com/cyb3rkh4l1d/subsapi/internal/utils"
+	"github.com/cyb3rkh4l1d/subsapi/internal/validations"
 )
 
 // Calculates total cost: Sum of (monthly price × months active within period)
@@ -57,14 +62,185 @@ func CalculateSubscriptionMetrics(
 		if monthsAdded > 0 {
 			// Calculate cost for these months
 			// Рассчитать стоимость за эти месяцы
-			subscriptionCost := int64(sub.Price) * int64(monthsAdded)
-			totalCost += subscriptionCost
+			subscriptionCost := monthsCost(sub.Price, monthsAdded)
+			totalCost = addCost(totalCost, subscriptionCost)
 		}
 	}
 
 	return unitPrice, totalCost, len(uniqueMonths)
 }
 
+// monthsCost returns price × months, saturating at math.MaxInt64 instead of overflowing.
+// monthsCost возвращает price × months с насыщением на math.MaxInt64 вместо переполнения.
+func monthsCost(price, months int) int64 {
+	if months > 0 && int64(price) > math.MaxInt64/int64(months) {
+		return math.MaxInt64
+	}
+	return int64(price) * int64(months)
+}
+
+// addCost adds two non-negative costs, saturating at math.MaxInt64 so that an overflowing
+// sum is reported as the largest cost rather than wrapping around to a negative one.
+// addCost складывает две неотрицательные стоимости с насыщением на math.MaxInt64, чтобы
+// переполненная сумма возвращалась как наибольшая стоимость, а не становилась отрицательной.
+func addCost(total, cost int64) int64 {
+	if cost > math.MaxInt64-total {
+		return math.MaxInt64
+	}
+	return total + cost
+}
+
+// monthsPerYear converts monthly prices to annualized figures.
+// monthsPerYear переводит месячные цены в годовые.
+const monthsPerYear = 12
+
+// AnnualCost returns the annualized cost of a subscription from its monthly price.
+// AnnualCost возвращает годовую стоимость подписки по ее месячной цене.
+func AnnualCost(sub *models.Subscription) int64 {
+	return int64(sub.Price) * monthsPerYear
+}
+
+// PriceExtremes returns the IDs of the cheapest and most expensive subscriptions by annualized cost.
+// Both are 0 when every subscription costs the same, as there is nothing to tell apart.
+// PriceExtremes возвращает ID самой дешевой и самой дорогой подписки по годовой стоимости.
+// Оба равны 0, если все подписки стоят одинаково, так как различать нечего.
+func PriceExtremes(subscriptions []models.Subscription) (uint, uint) {
+	if len(subscriptions) == 0 {
+		return 0, 0
+	}
+	cheapest, mostExpensive := subscriptions[0], subscriptions[0]
+	for _, sub := range subscriptions[1:] {
+		if AnnualCost(&sub) < AnnualCost(&cheapest) {
+			cheapest = sub
+		}
+		if AnnualCost(&sub) > AnnualCost(&mostExpensive) {
+			mostExpensive = sub
+		}
+	}
+	if AnnualCost(&cheapest) == AnnualCost(&mostExpensive) {
+		return 0, 0
+	}
+	return cheapest.ID, mostExpensive.ID
+}
+
+// GroupByServiceName splits subscriptions per service name, compared case-insensitively.
+// GroupByServiceName разделяет подписки по имени сервиса без учета регистра.
+func GroupByServiceName(subscriptions []models.Subscription) map[string][]models.Subscription {
+	groups := make(map[string][]models.Subscription)
+	for _, sub := range subscriptions {
+		key := validations.ServiceNameKey(sub.ServiceName)
+		groups[key] = append(groups[key], sub)
+	}
+	return groups
+}
+
+// ExcludedSubscriptions lists the subscriptions contributing nothing to a summary of the period and why:
+// starting after it, ending before it, or only covering months already charged by another subscription
+// of the same service (of any service when perService is false), matching the summary deduplication.
+// ExcludedSubscriptions перечисляет подписки, не вносящие вклад в сводку за период, и причину:
+// начало после периода, окончание до него или покрытие только месяцев, уже оплаченных другой подпиской
+// того же сервиса (любого сервиса, если perService равен false), в соответствии с дедупликацией сводки.
+func ExcludedSubscriptions(
+	subscriptions []models.Subscription,
+	periodStart time.Time, periodEnd time.Time,
+	perService bool,
+) []models.ExcludedSubscription {
+	excluded := make([]models.ExcludedSubscription, 0)
+	charged := make(map[string]map[string]bool)
+	for _, sub := range subscriptions {
+		var reason string
+		switch {
+		case utils.StartOfMonth(sub.StartDate).After(utils.StartOfMonth(periodEnd)):
+			reason = models.ExcludedStartsAfterPeriod
+		case sub.EndDate != nil && !sub.EndDate.IsZero() && utils.StartOfMonth(*sub.EndDate).Before(utils.StartOfMonth(periodStart)):
+			reason = models.ExcludedEndedBeforePeriod
+		default:
+			key := ""
+			if perService {
+				key = validations.ServiceNameKey(sub.ServiceName)
+			}
+			if charged[key] == nil {
+				charged[key] = make(map[string]bool)
+			}
+			effectiveEnd := periodEnd
+			if sub.EndDate != nil && !sub.EndDate.IsZero() {
+				effectiveEnd = utils.MinTime(*sub.EndDate, periodEnd)
+			}
+			if AddOverlapMonths(charged[key], utils.MaxTime(sub.StartDate, periodStart), effectiveEnd) > 0 {
+				continue
+			}
+			reason = models.ExcludedMonthsAlreadyCharged
+		}
+		excluded = append(excluded, models.ExcludedSubscription{ID: sub.ExposedID(), ServiceName: sub.ServiceName, Reason: reason})
+	}
+	return excluded
+}
+
+// CalculateAllServicesMetrics computes the metrics of subscriptions spanning several services.
+// Months are deduplicated within each service only, so overlapping services are all charged,
+// while the month count is the number of months covered by any subscription.
+// The unit price is only reported when a single service is involved.
+// CalculateAllServicesMetrics вычисляет метрики подписок на несколько сервисов.
+// Месяцы дедуплицируются только внутри каждого сервиса, поэтому оплачиваются все пересекающиеся сервисы,
+// а количество месяцев — это число месяцев, покрытых хотя бы одной подпиской.
+// Цена за единицу возвращается, только если задействован один сервис.
+func CalculateAllServicesMetrics(
+	subscriptions []models.Subscription,
+	periodStart time.Time, periodEnd time.Time,
+) (int, int64, int) {
+	groups := GroupByServiceName(subscriptions)
+
+	var unitPrice int
+	var totalCost int64
+	for _, group := range groups {
+		groupUnitPrice, groupCost, _ := CalculateSubscriptionMetrics(group, periodStart, periodEnd)
+		if len(groups) == 1 {
+			unitPrice = groupUnitPrice
+		}
+		totalCost = addCost(totalCost, groupCost)
+	}
+
+	// count the months covered by any subscription
+	// подсчитать месяцы, покрытые хотя бы одной подпиской
+	_, _, totalMonths := CalculateSubscriptionMetrics(subscriptions, periodStart, periodEnd)
+
+	return unitPrice, totalCost, totalMonths
+}
+
+// CalculateServiceSummaries computes the metrics of each service separately, ordered by cost descending.
+// CalculateServiceSummaries вычисляет метрики каждого сервиса отдельно, по убыванию стоимости.
+func CalculateServiceSummaries(
+	subscriptions []models.Subscription,
+	periodStart time.Time, periodEnd time.Time,
+) []models.ServiceSummary {
+	groups := GroupByServiceName(subscriptions)
+
+	summaries := make([]models.ServiceSummary, 0, len(groups))
+	for _, group := range groups {
+		_, cost, months := CalculateSubscriptionMetrics(group, periodStart, periodEnd)
+		summaries = append(summaries, models.ServiceSummary{
+			ServiceName:       group[0].ServiceName,
+			TotalCost:         cost,
+			Months:            months,
+			SubscriptionCount: len(group),
+		})
+	}
+
+	sortServiceSummaries(summaries)
+	return summaries
+}
+
+// sortServiceSummaries orders service summaries by cost descending, then by name to keep ties stable.
+// sortServiceSummaries упорядочивает сводки сервисов по убыванию стоимости, затем по имени для стабильного порядка.
+func sortServiceSummaries(summaries []models.ServiceSummary) {
+	slices.SortFunc(summaries, func(a, b models.ServiceSummary) int {
+		if c := cmp.Compare(b.TotalCost, a.TotalCost); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ServiceName, b.ServiceName)
+	})
+}
+
 // Calculates how many months between effectiveStart and effectiveEnd
 // Adds each month to the uniqueMonths map (deduplicates automatically)
 // Вычисляет количество месяцев между effectiveStart и effectiveEnd
@@ -74,8 +250,12 @@ func AddOverlapMonths(
 	start, end time.Time,
 ) int {
 
-	current := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, start.Location())
-	endMonth := time.Date(end.Year(), end.Month(), 1, 0, 0, 0, 0, end.Location())
+	// Normalize both bounds to the first of their month in UTC, which has no DST,
+	// so stepping a month never skips or repeats one whatever the zone of the inputs
+	// Привести обе границы к первому числу месяца в UTC, где нет перехода на летнее время,
+	// чтобы шаг на месяц никогда не пропускал и не повторял месяц независимо от часового пояса входных данных
+	current := utils.StartOfMonth(start)
+	endMonth := utils.StartOfMonth(end)
 
 	monthsAdded := 0
 
@@ -84,9 +264,9 @@ func AddOverlapMonths(
 	// Проходим по каждому месяцу в диапазоне current-endMonth
 	// // Обновляем карту, если ключ отсутствует в карте
 	for !current.After(endMonth) {
-		monthKey := fmt.Sprintf("%d-%02d", current.Year(), current.Month())
-		if !uniqueMonths[monthKey] {
-			uniqueMonths[monthKey] = true
+		key := monthKey(current)
+		if !uniqueMonths[key] {
+			uniqueMonths[key] = true
 			monthsAdded++
 		}
 		current = current.AddDate(0, 1, 0) // Next month. В следующем месяце
@@ -94,3 +274,99 @@ func AddOverlapMonths(
 
 	return monthsAdded
 }
+
+// monthKey returns the key under which AddOverlapMonths records the month of t.
+// monthKey возвращает ключ, под которым AddOverlapMonths сохраняет месяц t.
+func monthKey(t time.Time) string {
+	return fmt.Sprintf("%d-%02d", t.Year(), t.Month())
+}
+
+// SubscriptionStatus derives the status of a subscription for the month of now:
+// upcoming when it starts later, expired when it ended earlier, active otherwise.
+// SubscriptionStatus определяет статус подписки для месяца now:
+// upcoming, если она начинается позже, expired, если закончилась раньше, иначе active.
+func SubscriptionStatus(sub *models.Subscription, now time.Time) string {
+	month := utils.StartOfMonth(now)
+	if sub.StartDate.After(month) {
+		return models.StatusUpcoming
+	}
+	if sub.EndDate != nil && !sub.EndDate.IsZero() && sub.EndDate.Before(month) {
+		return models.StatusExpired
+	}
+	return models.StatusActive
+}
+
+// sameMonth reports whether two optional dates fall in the same month, both nil counting as equal.
+// sameMonth сообщает, приходятся ли две необязательные даты на один месяц, два nil считаются равными.
+func sameMonth(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return utils.StartOfMonth(*a).Equal(utils.StartOfMonth(*b))
+}
+
+// Period is a continuous range of months from the month of Start to the month of End, End is nil while open-ended.
+// Period — непрерывный диапазон месяцев от месяца Start до месяца End; End равен nil для бессрочного.
+type Period struct {
+	Start time.Time
+	End   *time.Time
+}
+
+// MergePeriods merges the periods covered by subscriptions into continuous ranges, in chronological order.
+// Overlapping periods and periods starting the month after another ends are merged, an open-ended
+// subscription absorbs every later one.
+// MergePeriods объединяет периоды, покрытые подписками, в непрерывные диапазоны в хронологическом порядке.
+// Пересекающиеся периоды и периоды, начинающиеся в месяц после окончания другого, объединяются,
+// бессрочная подписка поглощает все последующие.
+func MergePeriods(subscriptions []models.Subscription) []Period {
+	periods := make([]Period, len(subscriptions))
+	for i, sub := range subscriptions {
+		periods[i] = Period{Start: utils.StartOfMonth(sub.StartDate)}
+		if sub.EndDate != nil && !sub.EndDate.IsZero() {
+			end := utils.StartOfMonth(*sub.EndDate)
+			periods[i].End = &end
+		}
+	}
+	slices.SortFunc(periods, func(a, b Period) int { return a.Start.Compare(b.Start) })
+
+	merged := make([]Period, 0, len(periods))
+	for _, period := range periods {
+		if len(merged) > 0 {
+			last := &merged[len(merged)-1]
+			// open-ended, or ending at most the month before this one starts
+			// бессрочный или заканчивающийся не раньше месяца перед началом этого
+			if last.End == nil || !last.End.AddDate(0, 1, 0).Before(period.Start) {
+				if last.End != nil && (period.End == nil || period.End.After(*last.End)) {
+					last.End = period.End
+				}
+				continue
+			}
+		}
+		merged = append(merged, period)
+	}
+	return merged
+}
+
+// MaxPageSize is the largest page_size of a list, larger values are clamped to it
+// MaxPageSize — наибольший page_size списка, большие значения ограничиваются им
+const MaxPageSize = 100
+
+// applyPage translates the page and page_size of a list request into its limit and offset.
+// An invalid page falls back to 1 and an invalid page_size to the limit, which is clamped to MaxPageSize.
+// applyPage преобразует page и page_size запроса списка в его limit и offset.
+// Неверный page заменяется на 1, неверный page_size — на limit, ограниченный MaxPageSize.
+func applyPage(req *models.ListSubscriptionRequest) {
+	pageSize, err := strconv.Atoi(req.PageSize)
+	if err != nil || pageSize < 1 {
+		pageSize = req.Limit
+	}
+	pageSize = min(max(pageSize, 1), MaxPageSize)
+
+	page, err := strconv.Atoi(req.Page)
+	if err != nil || page < 1 {
+		page = 1
+	}
+	page = min(page, math.MaxInt32/pageSize+1)
+
+	req.Limit, req.Offset = pageSize, (page-1)*pageSize
+}
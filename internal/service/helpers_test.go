@@ -0,0 +1,227 @@
+package service
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/models"
+	"github.com/cyb3rkh4l1d/subsapi/internal/validations"
+)
+
+func TestGroupByServiceNameIgnoresCaseAndSpacing(t *testing.T) {
+	var subs []models.Subscription
+	for _, name := range []string{"Netflix ", "netflix", "  NETFLIX", "Yandex  Plus"} {
+		serviceName, err := validations.ValidateServiceName(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		subs = append(subs, models.Subscription{ServiceName: serviceName, Price: 100})
+	}
+
+	groups := GroupByServiceName(subs)
+	if len(groups) != 2 || len(groups["netflix"]) != 3 || len(groups["yandex plus"]) != 1 {
+		t.Errorf("groups = %v, want 3 netflix and 1 yandex plus", groups)
+	}
+}
+
+func TestAddOverlapMonthsAcrossDST(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	// daylight saving time starts on 9 March 2025 in New York
+	// летнее время в Нью-Йорке начинается 9 марта 2025 года
+	tests := []struct {
+		name       string
+		start, end time.Time
+		want       []string
+	}{
+		{"both bounds in New York", time.Date(2025, time.February, 1, 0, 0, 0, 0, newYork), time.Date(2025, time.April, 1, 0, 0, 0, 0, newYork), []string{"2025-02", "2025-03", "2025-04"}},
+		{"New York start, UTC end", time.Date(2025, time.February, 1, 0, 0, 0, 0, newYork), time.Date(2025, time.April, 1, 0, 0, 0, 0, time.UTC), []string{"2025-02", "2025-03", "2025-04"}},
+		{"mid-month bounds", time.Date(2025, time.March, 8, 23, 30, 0, 0, newYork), time.Date(2025, time.March, 9, 3, 30, 0, 0, newYork), []string{"2025-03"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			months := make(map[string]bool)
+			added := AddOverlapMonths(months, tt.start, tt.end)
+			if got := slices.Sorted(maps.Keys(months)); added != len(tt.want) || !slices.Equal(got, tt.want) {
+				t.Errorf("added %d months %v, want %v", added, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCostSaturation(t *testing.T) {
+	tests := []struct {
+		name string
+		got  int64
+		want int64
+	}{
+		{"months cost", monthsCost(1000, 12), 12000},
+		{"months cost at the limit", monthsCost(math.MaxInt32, 1<<32), math.MaxInt32 << 32},
+		{"months cost overflowing", monthsCost(math.MaxInt32, 1<<33), math.MaxInt64},
+		{"sum", addCost(1, 2), 3},
+		{"sum at the limit", addCost(math.MaxInt64-1, 1), math.MaxInt64},
+		{"sum overflowing", addCost(math.MaxInt64-1, 2), math.MaxInt64},
+		{"sum of saturated costs", addCost(math.MaxInt64, math.MaxInt64), math.MaxInt64},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %d, want %d", tt.name, tt.got, tt.want)
+		}
+	}
+
+	// a summary of subscriptions at the highest price never turns negative
+	// сводка подписок с наибольшей ценой никогда не становится отрицательной
+	subs := []models.Subscription{
+		{ServiceName: "Netflix", Price: math.MaxInt, StartDate: time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{ServiceName: "Spotify", Price: math.MaxInt, StartDate: time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	end := time.Date(2025, time.December, 1, 0, 0, 0, 0, time.UTC)
+	if _, total, _ := CalculateAllServicesMetrics(subs, time.Time{}, end); total != math.MaxInt64 {
+		t.Errorf("total = %d, want math.MaxInt64", total)
+	}
+}
+
+func TestExcludedSubscriptions(t *testing.T) {
+	end := func(year int, m time.Month) *time.Time {
+		date := month(year, m)
+		return &date
+	}
+	subs := []models.Subscription{
+		{ID: 1, ServiceName: "Netflix", Price: 400, StartDate: month(2025, 1)},
+		{ID: 2, ServiceName: "Netflix", Price: 400, StartDate: month(2025, 3), EndDate: end(2025, 5)},
+		{ID: 3, ServiceName: "Spotify", Price: 200, StartDate: month(2026, 1)},
+		{ID: 4, ServiceName: "Spotify", Price: 200, StartDate: month(2023, 1), EndDate: end(2024, 6)},
+		{ID: 5, ServiceName: "Spotify", Price: 200, StartDate: month(2025, 2), EndDate: end(2025, 4)},
+	}
+	periodStart, periodEnd := month(2025, 1), month(2025, 12)
+
+	tests := []struct {
+		name       string
+		perService bool
+		want       []models.ExcludedSubscription
+	}{
+		{"per service", true, []models.ExcludedSubscription{
+			{ID: "2", ServiceName: "Netflix", Reason: models.ExcludedMonthsAlreadyCharged},
+			{ID: "3", ServiceName: "Spotify", Reason: models.ExcludedStartsAfterPeriod},
+			{ID: "4", ServiceName: "Spotify", Reason: models.ExcludedEndedBeforePeriod},
+		}},
+		{"across services", false, []models.ExcludedSubscription{
+			{ID: "2", ServiceName: "Netflix", Reason: models.ExcludedMonthsAlreadyCharged},
+			{ID: "3", ServiceName: "Spotify", Reason: models.ExcludedStartsAfterPeriod},
+			{ID: "4", ServiceName: "Spotify", Reason: models.ExcludedEndedBeforePeriod},
+			{ID: "5", ServiceName: "Spotify", Reason: models.ExcludedMonthsAlreadyCharged},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ExcludedSubscriptions(subs, periodStart, periodEnd, tt.perService)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("excluded = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergePeriods(t *testing.T) {
+	end := func(year int, m time.Month) *time.Time {
+		date := month(year, m)
+		return &date
+	}
+	tests := []struct {
+		name string
+		subs []models.Subscription
+		want []Period
+	}{
+		{
+			name: "adjacent are merged",
+			subs: []models.Subscription{
+				{StartDate: month(2024, 4), EndDate: end(2024, 6)},
+				{StartDate: month(2024, 1), EndDate: end(2024, 3)},
+			},
+			want: []Period{{Start: month(2024, 1), End: end(2024, 6)}},
+		},
+		{
+			name: "overlapping are merged",
+			subs: []models.Subscription{
+				{StartDate: month(2024, 1), EndDate: end(2024, 8)},
+				{StartDate: month(2024, 3), EndDate: end(2024, 5)},
+				{StartDate: month(2024, 7), EndDate: end(2024, 10)},
+			},
+			want: []Period{{Start: month(2024, 1), End: end(2024, 10)}},
+		},
+		{
+			name: "gapped stay apart",
+			subs: []models.Subscription{
+				{StartDate: month(2024, 1), EndDate: end(2024, 3)},
+				{StartDate: month(2024, 5), EndDate: end(2024, 6)},
+			},
+			want: []Period{{Start: month(2024, 1), End: end(2024, 3)}, {Start: month(2024, 5), End: end(2024, 6)}},
+		},
+		{
+			name: "open-ended absorbs later ones",
+			subs: []models.Subscription{
+				{StartDate: month(2024, 1), EndDate: end(2024, 2)},
+				{StartDate: month(2024, 6)},
+				{StartDate: month(2025, 1), EndDate: end(2025, 3)},
+			},
+			want: []Period{{Start: month(2024, 1), End: end(2024, 2)}, {Start: month(2024, 6)}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MergePeriods(tt.subs)
+			if !slices.EqualFunc(got, tt.want, func(a, b Period) bool {
+				return a.Start.Equal(b.Start) && sameMonth(a.End, b.End)
+			}) {
+				t.Errorf("MergePeriods = %s, want %s", describePeriods(got), describePeriods(tt.want))
+			}
+		})
+	}
+}
+
+// describePeriods formats periods as "2024-01..2024-06" ranges, with ".." alone for an open end.
+// describePeriods форматирует периоды как диапазоны "2024-01..2024-06", с одним ".." для открытого конца.
+func describePeriods(periods []Period) []string {
+	described := make([]string, len(periods))
+	for i, period := range periods {
+		described[i] = period.Start.Format("2006-01") + ".."
+		if period.End != nil {
+			described[i] += period.End.Format("2006-01")
+		}
+	}
+	return described
+}
+
+func TestApplyPage(t *testing.T) {
+	tests := []struct {
+		name           string
+		page, pageSize string
+		limit          int
+		wantLimit      int
+		wantOffset     int
+	}{
+		{"third page", "3", "20", 10, 20, 40},
+		{"page without page_size keeps limit", "2", "", 10, 10, 10},
+		{"page_size without page starts at 1", "", "25", 10, 25, 0},
+		{"page_size clamped", "2", "500", 10, MaxPageSize, MaxPageSize},
+		{"invalid page", "zero", "20", 10, 20, 0},
+		{"negative page", "-3", "20", 10, 20, 0},
+		{"invalid page_size keeps limit", "2", "0", 15, 15, 15},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &models.ListSubscriptionRequest{Page: tt.page, PageSize: tt.pageSize}
+			req.Limit, req.Offset = tt.limit, 7
+			applyPage(req)
+			if req.Limit != tt.wantLimit || req.Offset != tt.wantOffset {
+				t.Errorf("limit, offset = %d, %d, want %d, %d", req.Limit, req.Offset, tt.wantLimit, tt.wantOffset)
+			}
+		})
+	}
+}
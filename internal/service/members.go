@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/models"
+	"github.com/cyb3rkh4l1d/subsapi/internal/validations"
+)
+
+// maxParentDepth bounds the walk up the parent chain when looking for cycles.
+// maxParentDepth ограничивает обход цепочки родителей при поиске циклов.
+const maxParentDepth = 32
+
+// validateParent ensures the parent of a subscription exists, is not the subscription itself,
+// and that linking them doesn't create a cycle in the family plan hierarchy.
+// validateParent проверяет, что родительская подписка существует, не является самой подпиской
+// и что их связь не создает цикл в иерархии семейного плана.
+func (s *SubscriptionService) validateParent(ctx context.Context, sub *models.Subscription) error {
+	if sub.ParentID == nil {
+		return nil
+	}
+	if sub.ID != 0 && *sub.ParentID == sub.ID {
+		return validations.ErrParentIsSelf
+	}
+
+	// walk up from the parent: reaching the subscription again means a cycle
+	// обход вверх от родителя: повторное попадание в подписку означает цикл
+	parentID := sub.ParentID
+	for depth := 0; parentID != nil; depth++ {
+		if depth >= maxParentDepth {
+			return validations.ErrParentCycle
+		}
+		parent, err := s.repo.GetSubscriptionByID(ctx, *parentID)
+		if err != nil {
+			return err
+		}
+		if parent == nil {
+			if depth == 0 {
+				return validations.ErrParentNotFound
+			}
+			return nil
+		}
+		if sub.ID != 0 && parent.ParentID != nil && *parent.ParentID == sub.ID {
+			return validations.ErrParentCycle
+		}
+		parentID = parent.ParentID
+	}
+	return nil
+}
+
+// ListMembers returns a subscription with the member subscriptions linked to it.
+// ListMembers возвращает подписку вместе со связанными с ней подписками участников.
+func (s *SubscriptionService) ListMembers(ctx context.Context, id uint) (*models.Subscription, []models.Subscription, error) {
+	sub, err := s.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	members, err := s.repo.FindSubscriptionsByParentIDs(ctx, []uint{sub.ID})
+	if err != nil {
+		return nil, nil, err
+	}
+	return sub, members, nil
+}
+
+// subscriptionIDs returns the IDs of the given subscriptions, used to look up their members.
+// subscriptionIDs возвращает ID указанных подписок, используемые для поиска их участников.
+func subscriptionIDs(subscriptions []models.Subscription) []uint {
+	ids := make([]uint, len(subscriptions))
+	for i, sub := range subscriptions {
+		ids[i] = sub.ID
+	}
+	return ids
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/models"
+	"github.com/cyb3rkh4l1d/subsapi/internal/validations"
+)
+
+const (
+	ownerID  = "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+	memberID = "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+)
+
+// month returns the first of a month in UTC, the way dates are stored.
+// month возвращает первое число месяца в UTC, как хранятся даты.
+func month(year int, m time.Month) time.Time {
+	return time.Date(year, m, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func TestValidateParent(t *testing.T) {
+	parentOf := func(id uint) *uint { return &id }
+	repo := &fakeRepository{subs: []models.Subscription{
+		{ID: 1},
+		{ID: 2, ParentID: parentOf(1)},
+		{ID: 3, ParentID: parentOf(2)},
+	}}
+	svc := newTestService(repo)
+
+	tests := []struct {
+		name string
+		sub  models.Subscription
+		want error
+	}{
+		{"no parent", models.Subscription{ID: 4}, nil},
+		{"new member", models.Subscription{ParentID: parentOf(3)}, nil},
+		{"existing member", models.Subscription{ID: 4, ParentID: parentOf(2)}, nil},
+		{"self parent", models.Subscription{ID: 1, ParentID: parentOf(1)}, validations.ErrParentIsSelf},
+		{"direct cycle", models.Subscription{ID: 2, ParentID: parentOf(3)}, validations.ErrParentCycle},
+		{"indirect cycle", models.Subscription{ID: 1, ParentID: parentOf(3)}, validations.ErrParentCycle},
+		{"missing parent", models.Subscription{ParentID: parentOf(9)}, validations.ErrParentNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := svc.validateParent(context.Background(), &tt.sub); !errors.Is(err, tt.want) {
+				t.Errorf("validateParent = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummaryRollsUpMembers(t *testing.T) {
+	parentID := uint(1)
+	repo := &fakeRepository{subs: []models.Subscription{
+		{ID: 1, UserID: ownerID, ServiceName: "Netflix", Price: 1000, StartDate: month(2025, time.January)},
+		// a member of another user, rolled up only with include_members
+		// участник другого пользователя, учитывается только с include_members
+		{ID: 2, UserID: memberID, ServiceName: "Netflix", Price: 300, StartDate: month(2025, time.February), ParentID: &parentID},
+		// a member of the owner, one of their subscriptions whose months are already counted
+		// участник самого владельца — его собственная подписка, месяцы которой уже учтены
+		{ID: 3, UserID: ownerID, ServiceName: "Netflix", Price: 200, StartDate: month(2025, time.March), ParentID: &parentID},
+	}}
+	svc := newTestService(repo)
+
+	tests := []struct {
+		name           string
+		includeMembers bool
+		want           int64
+	}{
+		{"without members", false, 3 * 1000},
+		{"with members", true, 3*1000 + 2*300},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &models.UserSubscriptionSummaryRequest{UserID: ownerID, ServiceName: "Netflix", DateRange: models.DateRange{From: "01-2025", To: "03-2025"}, IncludeMembers: tt.includeMembers}
+			_, totalCost, months, _, err := svc.GetUserSubscriptionSummary(context.Background(), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if totalCost != tt.want || months != 3 {
+				t.Errorf("total = %d over %d months, want %d over 3", totalCost, months, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/models"
+	"github.com/cyb3rkh4l1d/subsapi/internal/validations"
+)
+
+func TestGetPriceStats(t *testing.T) {
+	repo := &fakeRepository{subs: []models.Subscription{
+		{ServiceName: "Netflix", Price: 100},
+		{ServiceName: "netflix", Price: 200},
+		{ServiceName: "NETFLIX", Price: 250},
+		{ServiceName: "Netflix", Price: 450},
+		{ServiceName: "Spotify", Price: 999},
+	}}
+
+	got, err := newTestService(repo).GetPriceStats(context.Background(), &models.PriceStatsRequest{ServiceName: " Netflix "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := models.PriceStatsResponse{ServiceName: "Netflix", SubscriptionCount: 4, Average: 250, Median: 225, Min: 100, Max: 450}
+	if *got != want {
+		t.Errorf("got %+v, want %+v", *got, want)
+	}
+}
+
+func TestGetPriceStatsRoundsAverage(t *testing.T) {
+	repo := &fakeRepository{subs: []models.Subscription{
+		{ServiceName: "Kinopoisk", Price: 100},
+		{ServiceName: "Kinopoisk", Price: 100},
+		{ServiceName: "Kinopoisk", Price: 101},
+	}}
+
+	got, err := newTestService(repo).GetPriceStats(context.Background(), &models.PriceStatsRequest{ServiceName: "Kinopoisk"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Average != 100.33 || got.Median != 100 {
+		t.Errorf("average %v and median %v, want 100.33 and 100", got.Average, got.Median)
+	}
+}
+
+func TestGetPriceStatsUnknownService(t *testing.T) {
+	repo := &fakeRepository{subs: []models.Subscription{{ServiceName: "Netflix", Price: 100}}}
+
+	_, err := newTestService(repo).GetPriceStats(context.Background(), &models.PriceStatsRequest{ServiceName: "Hulu"})
+	if !errors.Is(err, validations.ErrServiceNotFound) {
+		t.Errorf("err = %v, want ErrServiceNotFound", err)
+	}
+}
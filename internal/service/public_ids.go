@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/models"
+	"github.com/cyb3rkh4l1d/subsapi/internal/validations"
+)
+
+// ResolveSubscriptionID returns the primary key of the subscription exposed under id: a positive integer,
+// or a UUID looked up among the subscriptions of the tenant when UUID subscription IDs are enabled.
+// ResolveSubscriptionID возвращает первичный ключ подписки, доступной под id: положительное целое число
+// или UUID, который ищется среди подписок арендатора при включенных UUID-идентификаторах подписок.
+func (s *SubscriptionService) ResolveSubscriptionID(ctx context.Context, id models.SubscriptionID) (uint, error) {
+	resolved, err := s.resolveSubscriptionIDs(ctx, []models.SubscriptionID{id})
+	if err != nil {
+		return 0, err
+	}
+	internal, ok := resolved[id]
+	if !ok {
+		return 0, validations.ErrSubscriptionNotFound
+	}
+	return internal, nil
+}
+
+// resolveSubscriptionIDs maps the exposed IDs to their primary keys in one query, leaving out unknown UUIDs.
+// It fails with ErrInvalidSubscriptionID on the first ID that is malformed in the current mode.
+// resolveSubscriptionIDs сопоставляет открытые ID с их первичными ключами одним запросом, пропуская неизвестные UUID.
+// Завершается ошибкой ErrInvalidSubscriptionID на первом ID, неверном в текущем режиме.
+func (s *SubscriptionService) resolveSubscriptionIDs(ctx context.Context, ids []models.SubscriptionID) (map[models.SubscriptionID]uint, error) {
+	resolved := make(map[models.SubscriptionID]uint, len(ids))
+	if !models.UUIDSubscriptionIDs() {
+		for _, id := range ids {
+			internal, err := validations.ValidateSubscriptionID(string(id))
+			if err != nil {
+				return nil, err
+			}
+			resolved[id] = internal
+		}
+		return resolved, nil
+	}
+
+	publicIDs := make([]string, len(ids))
+	for i, id := range ids {
+		publicID, err := validations.ValidatePublicSubscriptionID(string(id))
+		if err != nil {
+			return nil, err
+		}
+		publicIDs[i] = publicID
+	}
+	subs, err := s.repo.GetSubscriptionsByPublicIDs(ctx, publicIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i, id := range ids {
+		for _, sub := range subs {
+			if sub.PublicID == publicIDs[i] {
+				resolved[id] = sub.ID
+			}
+		}
+	}
+	return resolved, nil
+}
+
+// resolveParentID returns the primary key of the parent named by a request, reporting unknown parents as such.
+// resolveParentID возвращает первичный ключ родителя, указанного в запросе, сообщая о неизвестных родителях.
+func (s *SubscriptionService) resolveParentID(ctx context.Context, id *models.SubscriptionID) (*uint, error) {
+	if id == nil {
+		return nil, nil
+	}
+	parentID, err := s.ResolveSubscriptionID(ctx, *id)
+	if errors.Is(err, validations.ErrSubscriptionNotFound) {
+		return nil, validations.ErrParentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &parentID, nil
+}
+
+// exposeParents loads the public IDs of the parents of subs when UUID subscription IDs are enabled,
+// so that their parent_id is rendered as a UUID too.
+// exposeParents загружает публичные ID родителей subs при включенных UUID-идентификаторах подписок,
+// чтобы их parent_id также выводился как UUID.
+func (s *SubscriptionService) exposeParents(ctx context.Context, subs ...*models.Subscription) error {
+	if !models.UUIDSubscriptionIDs() {
+		return nil
+	}
+	parentIDs := make([]uint, 0, len(subs))
+	for _, sub := range subs {
+		if sub.ParentID != nil {
+			parentIDs = append(parentIDs, *sub.ParentID)
+		}
+	}
+	if len(parentIDs) == 0 {
+		return nil
+	}
+
+	parents, err := s.repo.GetSubscriptionsByIDs(ctx, parentIDs)
+	if err != nil {
+		return err
+	}
+	for _, sub := range subs {
+		sub.ParentPublicID = nil
+		for _, parent := range parents {
+			if sub.ParentID != nil && *sub.ParentID == parent.ID {
+				sub.ParentPublicID = &parent.PublicID
+			}
+		}
+	}
+	return nil
+}
+
+// exposeParentsOf is exposeParents over a slice of subscriptions.
+// exposeParentsOf — это exposeParents для среза подписок.
+func (s *SubscriptionService) exposeParentsOf(ctx context.Context, subs []models.Subscription) error {
+	pointers := make([]*models.Subscription, len(subs))
+	for i := range subs {
+		pointers[i] = &subs[i]
+	}
+	return s.exposeParents(ctx, pointers...)
+}
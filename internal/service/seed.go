@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/models"
+)
+
+// sampleSubscriptions are the subscriptions seeded into an empty development database, covering active,
+// expired, open-ended and upcoming subscriptions of a couple of users.
+// sampleSubscriptions — подписки, добавляемые в пустую базу данных разработки: активные, истекшие,
+// бессрочные и предстоящие подписки нескольких пользователей.
+var sampleSubscriptions = []models.CreateSubscriptionRequest{
+	{ServiceName: "Yandex Plus", Price: 400, UserID: "60601fee-2bf1-4721-ae6f-7636e79a0cba", StartDate: "07-2025", Description: "Music and movies"},
+	{ServiceName: "Netflix", Price: 999, UserID: "60601fee-2bf1-4721-ae6f-7636e79a0cba", StartDate: "01-2025", EndDate: "12-2025"},
+	{ServiceName: "Spotify", Price: 299, UserID: "60601fee-2bf1-4721-ae6f-7636e79a0cba", StartDate: "03-2024", EndDate: "02-2025"},
+	{ServiceName: "Kinopoisk", Price: 350, UserID: "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", StartDate: "05-2025"},
+	{ServiceName: "Netflix", Price: 1299, UserID: "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", StartDate: "09-2024", EndDate: "08-2026"},
+	{ServiceName: "iCloud", Price: 149, UserID: "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", StartDate: "01-2030"},
+}
+
+/*.....................................................................
+
+					Functions/Methods Definations
+
+........................................................................*/
+
+// SeedSampleData creates the sample subscriptions through the regular create path, so they are validated
+// like any request, when SEED_DATA is enabled with APP_ENV=dev and the subscriptions table is empty.
+// It returns the number of subscriptions created, 0 when seeding is disabled or the table already has rows,
+// which makes it safe to run on every startup.
+// SeedSampleData создает примеры подписок через обычный путь создания, чтобы они проверялись как любой запрос,
+// если SEED_DATA включен при APP_ENV=dev и таблица подписок пуста. Возвращает количество созданных подписок,
+// 0, если заполнение отключено или в таблице уже есть строки, поэтому его безопасно запускать при каждом старте.
+func (s *SubscriptionService) SeedSampleData(ctx context.Context) (int, error) {
+	if !s.config.SeedData {
+		return 0, nil
+	}
+	if s.config.AppEnv != "dev" {
+		s.Logger.Warnf("SEED_DATA is only honoured with APP_ENV=dev, APP_ENV=%+v: seeding skipped", s.config.AppEnv)
+		return 0, nil
+	}
+
+	count, err := s.repo.CountSubscriptions(ctx, "", "")
+	if err != nil {
+		return 0, err
+	}
+	if count > 0 {
+		s.Logger.Infof("seeding skipped, %+v subscriptions already exist", count)
+		return 0, nil
+	}
+
+	for i := range sampleSubscriptions {
+		req := sampleSubscriptions[i]
+		if _, _, err := s.CreateSubscription(ctx, &req); err != nil {
+			return i, err
+		}
+	}
+	s.Logger.Infof("seeded %+v sample subscriptions", len(sampleSubscriptions))
+	return len(sampleSubscriptions), nil
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/config"
+	"github.com/cyb3rkh4l1d/subsapi/internal/models"
+)
+
+func TestSeedSampleData(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   config.Config
+		existing int
+		want     int
+	}{
+		{"enabled on an empty table", config.Config{SeedData: true, AppEnv: "dev"}, 0, len(sampleSubscriptions)},
+		{"enabled on a populated table", config.Config{SeedData: true, AppEnv: "dev"}, 1, 0},
+		{"disabled", config.Config{AppEnv: "dev"}, 0, 0},
+		{"enabled outside dev", config.Config{SeedData: true, AppEnv: "production"}, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRepository{}
+			for range tt.existing {
+				repo.subs = append(repo.subs, models.Subscription{ServiceName: "Existing", Price: 1})
+			}
+			svc := newTestService(repo)
+			svc.config = &tt.config
+
+			seeded, err := svc.SeedSampleData(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if seeded != tt.want {
+				t.Errorf("seeded %d, want %d", seeded, tt.want)
+			}
+			if got := len(repo.subs); got != tt.existing+tt.want {
+				t.Errorf("%d subscriptions stored, want %d", got, tt.existing+tt.want)
+			}
+		})
+	}
+}
+
+func TestSeedSampleDataRunsOnce(t *testing.T) {
+	repo := &fakeRepository{}
+	svc := newTestService(repo)
+	svc.config = &config.Config{SeedData: true, AppEnv: "dev"}
+
+	for range 2 {
+		if _, err := svc.SeedSampleData(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(repo.subs) != len(sampleSubscriptions) {
+		t.Errorf("%d subscriptions after seeding twice, want %d", len(repo.subs), len(sampleSubscriptions))
+	}
+}
@@ -2,10 +2,15 @@ package service
 
 import (
 	"context"
+	"math"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/cyb3rkh4l1d/subsapi/internal/config"
 	"github.com/cyb3rkh4l1d/subsapi/internal/models"
 	"github.com/cyb3rkh4l1d/subsapi/internal/repository"
+	"github.com/cyb3rkh4l1d/subsapi/internal/utils"
 	"github.com/cyb3rkh4l1d/subsapi/internal/validations"
 	"github.com/sirupsen/logrus"
 )
@@ -13,22 +18,101 @@ import (
 // SubscriptionService manages business logic for subscriptions
 // SubscriptionService управляет бизнес-логикой для подписок
 type SubscriptionService struct {
-	repo   repository.Repository
-	Logger *logrus.Entry
+	repo          repository.Repository
+	config        *config.Config
+	warningChecks []WarningCheck
+	Logger        *logrus.Entry
 }
 
 // NewSubscriptionService creates a new subscription service
 // NewSubscriptionService создает новую службу подписки
-func NewSubscriptionService(repo repository.Repository, logger *logrus.Entry) *SubscriptionService {
-	return &SubscriptionService{
+func NewSubscriptionService(repo repository.Repository, config *config.Config, logger *logrus.Entry) *SubscriptionService {
+	s := &SubscriptionService{
 		repo:   repo,
+		config: config,
 		Logger: logger,
 	}
+	// register the default non-blocking warning checks
+	// регистрация неблокирующих проверок по умолчанию
+	s.RegisterWarningCheck(s.priceOutlierCheck)
+	s.RegisterWarningCheck(s.duplicateCheck)
+	return s
 }
 
 // CreateSubscription handles business logic for creating a subscription
 // Функция CreateSubscription обрабатывает бизнес-логику создания подписки
-func (s *SubscriptionService) CreateSubscription(ctx context.Context, req *models.CreateSubscriptionRequest) (*models.Subscription, error) {
+// It returns the created subscription with the non-blocking warnings raised for it.
+// Возвращает созданную подписку вместе с неблокирующими предупреждениями для нее.
+func (s *SubscriptionService) CreateSubscription(ctx context.Context, req *models.CreateSubscriptionRequest) (*models.Subscription, []string, error) {
+
+	// Validate and normalize the request into a subscription object
+	// Проверка и нормализация запроса в объект подписки
+	sub, err := s.ValidateCreateRequest(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Collect warnings before saving so the new price doesn't skew the aggregates
+	// Сбор предупреждений до сохранения, чтобы новая цена не искажала агрегаты
+	warnings := s.CollectWarnings(ctx, sub)
+
+	// Save to database
+	//Сохранить в базу данных
+	if err := s.repo.CreateSubscription(ctx, sub); err != nil {
+		return nil, nil, err
+	}
+	s.invalidateSummaryCache(ctx, sub.UserID)
+
+	return sub, warnings, nil
+}
+
+// UpsertSubscription creates a subscription, or replaces with the request the subscription an earlier upsert
+// created for the same user and service, in any case, starting the same month. It reports whether the subscription was created, and
+// returns it with the non-blocking warnings raised for it.
+// UpsertSubscription создает подписку или заменяет данными запроса подписку, созданную ранее через upsert для
+// того же пользователя и сервиса, в любом регистре, начинающуюся в том же месяце. Сообщает, была ли подписка создана, и
+// возвращает ее вместе с неблокирующими предупреждениями для нее.
+func (s *SubscriptionService) UpsertSubscription(ctx context.Context, req *models.CreateSubscriptionRequest) (*models.Subscription, bool, []string, error) {
+	sub, err := s.ValidateCreateRequest(ctx, req)
+	if err != nil {
+		return nil, false, nil, err
+	}
+
+	// Validate the parent against the subscription the upsert would replace, which can't become its own ancestor
+	// Проверить родителя относительно заменяемой подписки, которая не может стать собственным предком
+	if sub.ParentID != nil {
+		existing, err := s.repo.FindSubscriptionsByUserIDandServiceName(ctx, sub.UserID, sub.ServiceName, false)
+		if err != nil {
+			return nil, false, nil, err
+		}
+		for _, other := range existing {
+			if other.Upserted && other.StartDate.Equal(sub.StartDate) {
+				sub.ID = other.ID
+			}
+		}
+		err = s.validateParent(ctx, sub)
+		sub.ID = 0
+		if err != nil {
+			return nil, false, nil, err
+		}
+	}
+
+	created, err := s.repo.UpsertSubscription(ctx, sub)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	s.invalidateSummaryCache(ctx, sub.UserID)
+
+	// Collect warnings once saved, both checks leave the subscription itself out
+	// Сбор предупреждений после сохранения, обе проверки не учитывают саму подписку
+	return sub, created, s.CollectWarnings(ctx, sub), nil
+}
+
+// ValidateCreateRequest validates a create request and returns the normalized subscription
+// without persisting it. It is the single validation path shared by create and batch validation.
+// ValidateCreateRequest проверяет запрос на создание и возвращает нормализованную подписку
+// без сохранения. Это единый путь проверки, общий для создания и пакетной проверки.
+func (s *SubscriptionService) ValidateCreateRequest(ctx context.Context, req *models.CreateSubscriptionRequest) (*models.Subscription, error) {
 
 	//validate userId
 	//проверить UserID
@@ -52,27 +136,50 @@ func (s *SubscriptionService) CreateSubscription(ctx context.Context, req *model
 
 	//Validate service_name
 	//проверить service_name
-	if err := validations.ValidateServiceName(req.ServiceName); err != nil {
+	serviceName, err := validations.ValidateServiceName(req.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+
+	//Validate price against MAX_PRICE
+	//проверить price относительно MAX_PRICE
+	if err := validations.ValidatePrice(req.Price, s.config.MaxPrice); err != nil {
+		return nil, err
+	}
+
+	//Validate the optional description
+	//проверить необязательное описание
+	description, err := validations.ValidateDescription(req.Description)
+	if err != nil {
+		return nil, err
+	}
+
+	//Resolve the exposed ID of the family plan parent, if any
+	//получить первичный ключ родительской подписки семейного плана, если она указана
+	parentID, err := s.resolveParentID(ctx, req.ParentID)
+	if err != nil {
 		return nil, err
 	}
 
 	// Create a subscription object based on the request data
 	// Создание объекта подписки на основе данных запроса
 	sub := &models.Subscription{
-		ServiceName: req.ServiceName,
+		ServiceName: serviceName,
 		Price:       req.Price,
 		UserID:      req.UserID,
 		StartDate:   startDate,
 		EndDate:     endDate,
+		ParentID:    parentID,
+		Description: description,
 	}
 
-	// Save to database
-	//Сохранить в базу данных
-	if err := s.repo.CreateSubscription(ctx, sub); err != nil {
+	//Validate the family plan parent, if any
+	//проверить родительскую подписку семейного плана, если она указана
+	if err := s.validateParent(ctx, sub); err != nil {
 		return nil, err
 	}
 
-	return sub, nil
+	return sub, s.exposeParents(ctx, sub)
 }
 
 // GetSubscription retrieves a subscription by ID
@@ -88,12 +195,63 @@ func (s *SubscriptionService) GetSubscription(ctx context.Context, id uint) (*mo
 	if sub == nil {
 		return nil, validations.ErrSubscriptionNotFound
 	}
-	return sub, nil
+	return sub, s.exposeParents(ctx, sub)
+}
+
+// CompareSubscriptions fetches the subscriptions to compare in one query.
+// It returns the IDs that don't exist, so callers can report every missing one at once.
+// CompareSubscriptions получает сравниваемые подписки одним запросом.
+// Возвращает несуществующие ID, чтобы вызывающий мог сообщить обо всех отсутствующих сразу.
+func (s *SubscriptionService) CompareSubscriptions(ctx context.Context, ids []models.SubscriptionID) ([]models.Subscription, []models.SubscriptionID, error) {
+	ids = slices.Compact(slices.Sorted(slices.Values(ids)))
+
+	resolved, err := s.resolveSubscriptionIDs(ctx, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	internalIDs := make([]uint, 0, len(resolved))
+	for _, id := range resolved {
+		internalIDs = append(internalIDs, id)
+	}
+	subs, err := s.repo.GetSubscriptionsByIDs(ctx, internalIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	missing := make([]models.SubscriptionID, 0)
+	for _, id := range ids {
+		internal, ok := resolved[id]
+		if !ok || !slices.ContainsFunc(subs, func(sub models.Subscription) bool { return sub.ID == internal }) {
+			missing = append(missing, id)
+		}
+	}
+	return subs, missing, s.exposeParentsOf(ctx, subs)
 }
 
 // ListSubscriptions retrieves user's subscriptions with filtering, pagination, and sorting
 // ListSubscriptions извлекает подписки пользователя с фильтрацией, пагинацией и сортировкой.
 func (s *SubscriptionService) ListSubscriptions(ctx context.Context, req *models.ListSubscriptionRequest) (int64, []models.Subscription, error) {
+	// Page numbers replace limit and offset when given
+	// Номера страниц заменяют limit и offset, если указаны
+	if req.ByPage() {
+		applyPage(req)
+	}
+	if err := s.validateOffset(req.Offset); err != nil {
+		return 0, nil, err
+	}
+
+	// Validate the cost period before querying, keeping its bounds in order for ListPeriodCosts
+	// Проверить период стоимости до запроса, сохранив его границы упорядоченными для ListPeriodCosts
+	if req.WithCost {
+		from, to, err := s.orderPeriod(req.From, req.To)
+		if err != nil {
+			return 0, nil, err
+		}
+		req.From, req.To = from, to
+		if _, _, err := s.summaryPeriod(req.From, req.To); err != nil {
+			return 0, nil, err
+		}
+	}
 
 	// retrieves user's subscriptions
 	//Получить подписки пользователей
@@ -102,26 +260,87 @@ func (s *SubscriptionService) ListSubscriptions(ctx context.Context, req *models
 		return total, nil, err
 	}
 
-	return total, subs, nil
+	return total, subs, s.exposeParentsOf(ctx, subs)
+}
+
+// ListSubscriptionsAfter returns up to limit subscriptions in ascending id order after the opaque cursor after,
+// the first page when it is empty, with the cursor of the next page, empty on the last one.
+// ListSubscriptionsAfter возвращает до limit подписок в порядке возрастания id после непрозрачного курсора after,
+// первую страницу, если он пуст, вместе с курсором следующей страницы, пустым на последней.
+func (s *SubscriptionService) ListSubscriptionsAfter(ctx context.Context, limit int, after string) ([]models.Subscription, string, error) {
+	var afterID uint
+	if after != "" {
+		decoded, err := DecodeCursor(after)
+		if err != nil {
+			return nil, "", err
+		}
+		afterID = decoded
+	}
+
+	// one extra row tells whether a next page exists
+	// одна лишняя строка показывает, есть ли следующая страница
+	subs, err := s.repo.ListPaginated(ctx, limit+1, afterID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var nextCursor string
+	if len(subs) > limit {
+		subs = subs[:limit]
+		nextCursor = EncodeCursor(subs[len(subs)-1].ID)
+	}
+	return subs, nextCursor, s.exposeParentsOf(ctx, subs)
+}
+
+// ListPeriodCosts returns the cost of each listed subscription over the from-to period of the list request,
+// computed the same way as a summary covering that subscription alone.
+// ListPeriodCosts возвращает стоимость каждой подписки списка за период from-to запроса списка,
+// вычисленную так же, как сводка только по этой подписке.
+func (s *SubscriptionService) ListPeriodCosts(req *models.ListSubscriptionRequest, subs []models.Subscription) ([]int64, error) {
+	periodStart, periodEnd, err := s.summaryPeriod(req.From, req.To)
+	if err != nil {
+		return nil, err
+	}
+
+	costs := make([]int64, len(subs))
+	for i := range subs {
+		_, costs[i], _ = CalculateSubscriptionMetrics(subs[i:i+1], periodStart, periodEnd)
+	}
+	return costs, nil
+}
+
+// CountAllSubscriptions counts every subscription regardless of the list filters
+// CountAllSubscriptions подсчитывает все подписки без учета фильтров списка
+func (s *SubscriptionService) CountAllSubscriptions(ctx context.Context) (int64, error) {
+	return s.repo.CountSubscriptions(ctx, "", "")
+}
+
+// SumListedPrices sums the monthly prices of every subscription matching the list filters, across all pages.
+// SumListedPrices суммирует месячные цены всех подписок, подходящих под фильтры списка, на всех страницах.
+func (s *SubscriptionService) SumListedPrices(ctx context.Context, req *models.ListSubscriptionRequest) (int64, error) {
+	return s.repo.SumSubscriptionPrices(ctx, req.Status, req.Search)
 }
 
 // UpdateSubscription handles business logic for updating a subscription
+// It returns the updated subscription with the non-blocking warnings raised for it.
 // Функция UpdateSubscription обрабатывает бизнес-логику обновления подписки
-func (s *SubscriptionService) UpdateSubscriptionByID(ctx context.Context, id uint, req *models.UpdateSubscriptionRequest) (*models.Subscription, error) {
+// Возвращает обновленную подписку вместе с неблокирующими предупреждениями для нее.
+func (s *SubscriptionService) UpdateSubscriptionByID(ctx context.Context, id uint, req *models.UpdateSubscriptionRequest) (*models.Subscription, []string, error) {
 	// check if subscription exists
 	// Проверить, существует ли подписка
 	sub, err := s.GetSubscription(ctx, id)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Update service name if provided
 	// Обновите имя службы, если оно указано
 	if req.ServiceName != "" {
-		if err := validations.ValidateServiceName(req.ServiceName); err != nil {
-			return nil, err
+		serviceName, err := validations.ValidateServiceName(req.ServiceName)
+		if err != nil {
+			return nil, nil, err
 		}
-		sub.ServiceName = req.ServiceName
+		sub.ServiceName = serviceName
 	}
 
 	//update startdate if provided.
@@ -129,35 +348,110 @@ func (s *SubscriptionService) UpdateSubscriptionByID(ctx context.Context, id uin
 	if req.StartDate != "" {
 		startDate, err := validations.ValidateStartDate(req.StartDate)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		sub.StartDate = startDate
 	}
 	//update price if provided.
 	//Обновить цену, если она указана.
 	if req.Price > 0 {
+		if err := validations.ValidatePrice(req.Price, s.config.MaxPrice); err != nil {
+			return nil, nil, err
+		}
 		sub.Price = req.Price
 	}
-	// Update or clear end date and enforce end_date >= start_date
-	// Обновить или очистить конечную дату и установить значение end_date >= start_date
-
-	if req.EndDate == "" {
+	//update or clear description if provided, an empty one clears it.
+	//Обновить или очистить описание, если указано, пустое очищает его.
+	if req.Description != nil {
+		description, err := validations.ValidateDescription(*req.Description)
+		if err != nil {
+			return nil, nil, err
+		}
+		sub.Description = description
+	}
+	previousEnd := sub.EndDate
+	// Update or clear end date and enforce end_date >= start_date:
+	// an omitted end_date is left unchanged, an empty one clears it.
+	// Обновить или очистить конечную дату и установить значение end_date >= start_date:
+	// отсутствующая end_date не меняется, пустая — очищается.
+	switch {
+	case req.EndDate == nil:
+		if sub.EndDate != nil && sub.EndDate.Before(sub.StartDate) {
+			return nil, nil, validations.ErrEndDateBeforeStart
+		}
+	case *req.EndDate == "":
 		sub.EndDate = nil
-	} else {
-		endDate, err := validations.ValidateEndDate(sub.StartDate, req.EndDate)
+	default:
+		endDate, err := validations.ValidateEndDate(sub.StartDate, *req.EndDate)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		sub.EndDate = endDate
 	}
+	// a new end_date gets its own expiry reminder
+	// новая end_date получает собственное напоминание об окончании
+	if !sameMonth(previousEnd, sub.EndDate) {
+		sub.ReminderSentAt = nil
+	}
+
+	//update or detach the family plan parent if provided, 0 detaches.
+	//Обновить или отвязать родительскую подписку, если указана, 0 отвязывает.
+	if req.ParentID != nil {
+		if *req.ParentID == "0" {
+			sub.ParentID = nil
+		} else {
+			parentID, err := s.resolveParentID(ctx, req.ParentID)
+			if err != nil {
+				return nil, nil, err
+			}
+			sub.ParentID = parentID
+			if err := s.validateParent(ctx, sub); err != nil {
+				return nil, nil, err
+			}
+		}
+		if err := s.exposeParents(ctx, sub); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Collect non-blocking warnings for the updated values
+	// Сбор неблокирующих предупреждений для обновленных значений
+	warnings := s.CollectWarnings(ctx, sub)
 
 	// Save updates to the database
 	// Сохранение обновлений в базу данных
 	if err := s.repo.UpdateSubscriptionByID(ctx, sub); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	s.invalidateSummaryCache(ctx, sub.UserID)
 
-	return sub, nil
+	return sub, warnings, nil
+}
+
+// CancelSubscription sets the end_date of a subscription to the given month, the current month by default.
+// Функция CancelSubscription устанавливает end_date подписки на указанный месяц, по умолчанию — текущий.
+func (s *SubscriptionService) CancelSubscription(ctx context.Context, id uint, req *models.CancelSubscriptionRequest) (*models.Subscription, []string, error) {
+	endDate := req.EndDate
+	if endDate == "" {
+		endDate = utils.StartOfMonth(utils.Now()).Format(utils.MonthYearLayout)
+	}
+	return s.UpdateSubscriptionByID(ctx, id, &models.UpdateSubscriptionRequest{EndDate: &endDate})
+}
+
+// ExtendSubscription pushes the end_date of a subscription forward by the given number of monthly periods.
+// Open-ended subscriptions have nothing to extend.
+// Функция ExtendSubscription переносит end_date подписки вперед на указанное число месячных периодов.
+// Бессрочные подписки продлить нельзя.
+func (s *SubscriptionService) ExtendSubscription(ctx context.Context, id uint, req *models.ExtendSubscriptionRequest) (*models.Subscription, []string, error) {
+	sub, err := s.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if sub.EndDate == nil || sub.EndDate.IsZero() {
+		return nil, nil, validations.ErrOpenEndedExtension
+	}
+	endDate := sub.EndDate.AddDate(0, req.Periods, 0).Format(utils.MonthYearLayout)
+	return s.UpdateSubscriptionByID(ctx, id, &models.UpdateSubscriptionRequest{EndDate: &endDate})
 }
 
 // The GetUserSubscriptionSummary function calculates and returns subscription statistics for a user.
@@ -165,69 +459,690 @@ func (s *SubscriptionService) UpdateSubscriptionByID(ctx context.Context, id uin
 func (s *SubscriptionService) GetUserSubscriptionSummary(
 	ctx context.Context,
 	req *models.UserSubscriptionSummaryRequest,
-) (int, int64, int, error) {
-
-	var periodStart time.Time
-	var periodEnd *time.Time
+) (int, int64, int, []models.ExcludedSubscription, error) {
 
 	//validate userId
 	//проверить UserID
 	err := validations.ValidateUserID(req.UserID)
 	if err != nil {
-		return 0, 0, 0, err
+		return 0, 0, 0, nil, err
 	}
 
-	//Validate service_name
+	//Validate service_name when provided, an empty one summarizes all services
+	//проверить service_name, если указан; пустое значение означает сводку по всем сервисам
+	if req.ServiceName != "" {
+		req.ServiceName, err = validations.ValidateServiceName(req.ServiceName)
+		if err != nil {
+			return 0, 0, 0, nil, err
+		}
+	}
+
+	//Resolve the summarized period from query "from" and "to", keeping its bounds in order for StatsPeriod
+	//Определить период сводки по параметрам "from" и "to", сохранив его границы упорядоченными для StatsPeriod
+	req.From, req.To, err = s.orderPeriod(req.From, req.To)
+	if err != nil {
+		return 0, 0, 0, nil, err
+	}
+	periodStart, periodEnd, err := s.summaryPeriod(req.From, req.To)
+	if err != nil {
+		return 0, 0, 0, nil, err
+	}
+
+	// Read the summary of all services from the summary cache when it is fresh for the period,
+	// members and diagnostics need the subscriptions themselves
+	// Прочитать сводку по всем сервисам из кеша сводки, если он актуален для периода,
+	// участникам и диагностике нужны сами подписки
+	if req.ServiceName == "" && !req.IncludeMembers && !req.Diagnostics {
+		if unitPrice, totalCost, totalMonths, ok := s.cachedPeriodStats(ctx, req.UserID, periodStart, periodEnd); ok {
+			s.Logger.Infof("subscription metrics (cached): UserID: %+v, TotalMonths: %+v, TotalCost: %+v", req.UserID, totalMonths, totalCost)
+			return unitPrice, totalCost, totalMonths, nil, nil
+		}
+	}
+
+	// Get all subscriptions for user
+	// Получить все подписки пользователя
+	subscriptions, err := s.repo.FindSubscriptionsByUserIDandServiceName(ctx, req.UserID, req.ServiceName, req.ExactServiceName)
+	if err != nil {
+		return 0, 0, 0, nil, err
+	}
+
+	// Calculate total cost and unique months for user's subscription
+	// Рассчитать общую стоимость и количество уникальных месяцев подписки пользователя
+	var unitPrice, totalUniqueMonths int
+	var totalCost int64
+	if req.ServiceName == "" {
+		unitPrice, totalCost, totalUniqueMonths = CalculateAllServicesMetrics(subscriptions, periodStart, periodEnd)
+	} else {
+		unitPrice, totalCost, totalUniqueMonths = CalculateSubscriptionMetrics(subscriptions, periodStart, periodEnd)
+	}
+
+	//List the subscriptions left out of the summary when diagnostics are requested
+	//Перечислить подписки, не учтенные в сводке, если запрошена диагностика
+	var excluded []models.ExcludedSubscription
+	if req.Diagnostics {
+		excluded = ExcludedSubscriptions(subscriptions, periodStart, periodEnd, req.ServiceName == "")
+	}
+
+	//Roll the cost of family plan members up into their parents
+	//Добавить стоимость участников семейного плана к их родительским подпискам
+	if req.IncludeMembers {
+		ids := subscriptionIDs(subscriptions)
+		members, err := s.repo.FindSubscriptionsByParentIDs(ctx, ids)
+		if err != nil {
+			return 0, 0, 0, nil, err
+		}
+		for _, member := range members {
+			// members owned by the same user are already counted
+			// участники того же пользователя уже учтены
+			if slices.Contains(ids, member.ID) {
+				continue
+			}
+			_, memberCost, _ := CalculateSubscriptionMetrics([]models.Subscription{member}, periodStart, periodEnd)
+			totalCost = addCost(totalCost, memberCost)
+		}
+	}
+
+	s.Logger.Infof("subscription metrics: UserID: %+v, ServiceName: %+v, TotalMonths: %+v, TotalCost: %+v", req.UserID, req.ServiceName, totalUniqueMonths, totalCost)
+
+	return unitPrice, totalCost, totalUniqueMonths, excluded, nil
+}
+
+// GetServiceStats summarizes every service of a user separately in one call.
+// GetServiceStats формирует сводку по каждому сервису пользователя за один вызов.
+func (s *SubscriptionService) GetServiceStats(ctx context.Context, req *models.ServiceStatsRequest) ([]models.ServiceSummary, error) {
+	//validate userId
+	//проверить UserID
+	if err := validations.ValidateUserID(req.UserID); err != nil {
+		return nil, err
+	}
+
+	// Keep the period bounds in order for StatsPeriod
+	// Сохранить границы периода упорядоченными для StatsPeriod
+	from, to, err := s.orderPeriod(req.From, req.To)
+	if err != nil {
+		return nil, err
+	}
+	req.From, req.To = from, to
+	periodStart, periodEnd, err := s.summaryPeriod(req.From, req.To)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read the summaries from the summary cache when it is fresh for the period
+	// Прочитать сводки из кеша сводки, если он актуален для периода
+	if summaries, ok := s.cachedServiceSummaries(ctx, req.UserID, periodStart, periodEnd); ok {
+		return summaries, nil
+	}
+
+	// Get all subscriptions for user
+	// Получить все подписки пользователя
+	subscriptions, err := s.repo.FindSubscriptionsByUserIDandServiceName(ctx, req.UserID, "", false)
+	if err != nil {
+		return nil, err
+	}
+
+	return CalculateServiceSummaries(subscriptions, periodStart, periodEnd), nil
+}
+
+// GetQuarterlyStats computes the cost of a user over each quarter of a year with the summary metrics,
+// every service of the user included. Quarters without activity cost 0.
+// GetQuarterlyStats вычисляет расходы пользователя за каждый квартал года по метрикам сводки
+// с учетом всех сервисов пользователя. Кварталы без активности стоят 0.
+func (s *SubscriptionService) GetQuarterlyStats(ctx context.Context, req *models.QuarterlyStatsRequest) (*models.QuarterlyStatsResponse, error) {
+	//validate userId and year
+	//проверить UserID и год
+	if err := validations.ValidateUserID(req.UserID); err != nil {
+		return nil, err
+	}
+	if err := validations.ValidateYear(req.Year); err != nil {
+		return nil, err
+	}
+
+	// Read the whole year from the summary cache when it is fresh for it, each quarter summing its own months,
+	// otherwise get all subscriptions for user once
+	// Прочитать весь год из кеша сводки, если он актуален для него, каждый квартал суммирует свои месяцы,
+	// иначе получить все подписки пользователя один раз
+	yearStart := time.Date(req.Year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	_, cachedMonths, cached := s.cachedSummary(ctx, req.UserID, yearStart, yearStart.AddDate(0, 11, 0))
+	var subscriptions []models.Subscription
+	if !cached {
+		var err error
+		subscriptions, err = s.repo.FindSubscriptionsByUserIDandServiceName(ctx, req.UserID, "", false)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	res := &models.QuarterlyStatsResponse{UserID: req.UserID, Year: req.Year, Quarters: make([]models.QuarterCost, 0, 4)}
+	for quarter := 1; quarter <= 4; quarter++ {
+		periodStart := yearStart.AddDate(0, 3*(quarter-1), 0)
+		periodEnd := periodStart.AddDate(0, 2, 0)
+		var cost int64
+		var months int
+		if cached {
+			cost, months = sumSummaryMonths(cachedMonths, periodStart, periodEnd)
+		} else {
+			_, cost, months = CalculateAllServicesMetrics(subscriptions, periodStart, periodEnd)
+		}
+		res.Quarters = append(res.Quarters, models.QuarterCost{
+			Quarter:   quarter,
+			From:      utils.FormatMonthYear(periodStart),
+			To:        utils.FormatMonthYear(periodEnd),
+			TotalCost: cost,
+			Months:    months,
+		})
+		res.TotalCost = addCost(res.TotalCost, cost)
+	}
+	return res, nil
+}
+
+// GetPriceStats returns the average, median, min and max price of a service across all users,
+// matched case-insensitively. A service without subscriptions yields ErrServiceNotFound.
+// GetPriceStats возвращает среднюю, медианную, минимальную и максимальную цену сервиса по всем пользователям
+// без учета регистра. Для сервиса без подписок возвращается ErrServiceNotFound.
+func (s *SubscriptionService) GetPriceStats(ctx context.Context, req *models.PriceStatsRequest) (*models.PriceStatsResponse, error) {
+	//validate service_name
 	//проверить service_name
-	if err := validations.ValidateServiceName(req.ServiceName); err != nil {
-		return 0, 0, 0, err
+	serviceName, err := validations.ValidateServiceName(req.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := s.repo.PriceStatsByServiceName(ctx, serviceName)
+	if err != nil {
+		return nil, err
+	}
+	if stats.Count == 0 {
+		return nil, validations.ErrServiceNotFound
+	}
+
+	return &models.PriceStatsResponse{
+		ServiceName:       serviceName,
+		SubscriptionCount: stats.Count,
+		Average:           math.Round(stats.Average*100) / 100,
+		Median:            stats.Median,
+		Min:               stats.Min,
+		Max:               stats.Max,
+	}, nil
+}
+
+// CompareStatsPeriods computes the spend of a user over two periods with the summary metrics
+// and the change from period A to period B.
+// CompareStatsPeriods вычисляет расходы пользователя за два периода по метрикам сводки
+// и изменение от периода A к периоду B.
+func (s *SubscriptionService) CompareStatsPeriods(ctx context.Context, req *models.StatsCompareRequest) (*models.StatsCompareResponse, error) {
+	//validate userId
+	//проверить UserID
+	if err := validations.ValidateUserID(req.UserID); err != nil {
+		return nil, err
+	}
+
+	// Validate both periods before loading anything
+	// Проверить оба периода до загрузки данных
+	aStart, aEnd, err := s.summaryPeriod(req.PeriodAFrom, req.PeriodATo)
+	if err != nil {
+		return nil, err
+	}
+	bStart, bEnd, err := s.summaryPeriod(req.PeriodBFrom, req.PeriodBTo)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read both periods from the summary cache when it is fresh for them
+	// Прочитать оба периода из кеша сводки, если он актуален для них
+	_, aCost, aMonths, aCached := s.cachedPeriodStats(ctx, req.UserID, aStart, aEnd)
+	_, bCost, bMonths, bCached := s.cachedPeriodStats(ctx, req.UserID, bStart, bEnd)
+	if !aCached || !bCached {
+		// Get all subscriptions for user once, both periods are computed from them
+		// Получить все подписки пользователя один раз, оба периода вычисляются по ним
+		subscriptions, err := s.repo.FindSubscriptionsByUserIDandServiceName(ctx, req.UserID, "", false)
+		if err != nil {
+			return nil, err
+		}
+
+		_, aCost, aMonths = CalculateAllServicesMetrics(subscriptions, aStart, aEnd)
+		_, bCost, bMonths = CalculateAllServicesMetrics(subscriptions, bStart, bEnd)
+	}
+	res := &models.StatsCompareResponse{
+		UserID:  req.UserID,
+		PeriodA: models.PeriodStats{From: utils.FormatMonthYear(aStart), To: utils.FormatMonthYear(aEnd), TotalCost: aCost, Months: aMonths},
+		PeriodB: models.PeriodStats{From: utils.FormatMonthYear(bStart), To: utils.FormatMonthYear(bEnd), TotalCost: bCost, Months: bMonths},
+		Delta:   bCost - aCost,
+	}
+	// a relative change from nothing is undefined
+	// относительное изменение от нуля не определено
+	if aCost != 0 {
+		percent := float64(res.Delta) / float64(aCost) * 100
+		res.DeltaPercent = &percent
+	}
+	return res, nil
+}
+
+// ExplainSummary returns the query plan of the lookup behind the summaries,
+// after validating the request the same way as GetUserSubscriptionSummary.
+// ExplainSummary возвращает план запроса, используемого сводками,
+// после проверки запроса так же, как в GetUserSubscriptionSummary.
+func (s *SubscriptionService) ExplainSummary(ctx context.Context, req *models.UserSubscriptionSummaryRequest) (string, error) {
+	if err := validations.ValidateUserID(req.UserID); err != nil {
+		return "", err
+	}
+	if req.ServiceName != "" {
+		serviceName, err := validations.ValidateServiceName(req.ServiceName)
+		if err != nil {
+			return "", err
+		}
+		req.ServiceName = serviceName
+	}
+	if _, _, err := s.summaryPeriod(req.From, req.To); err != nil {
+		return "", err
+	}
+
+	return s.repo.ExplainSubscriptionsByUserIDandServiceName(ctx, req.UserID, req.ServiceName, req.ExactServiceName)
+}
+
+// exportBatchSize is the number of rows read per query when exporting a user's data.
+// exportBatchSize — количество строк, читаемых за один запрос при экспорте данных пользователя.
+const exportBatchSize = 500
+
+// ExportUserData collects every subscription of a user for a data-subject export.
+// ExportUserData собирает все подписки пользователя для экспорта по запросу субъекта данных.
+func (s *SubscriptionService) ExportUserData(ctx context.Context, userID string) ([]models.Subscription, error) {
+	if err := validations.ValidateUserID(userID); err != nil {
+		return nil, err
+	}
+
+	subscriptions := make([]models.Subscription, 0)
+	err := s.repo.FindSubscriptionsByUserIDInBatches(ctx, userID, exportBatchSize, func(batch []models.Subscription) error {
+		subscriptions = append(subscriptions, batch...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return subscriptions, s.exposeParentsOf(ctx, subscriptions)
+}
+
+// EraseUserData deletes every subscription of a user for a data-subject erasure request,
+// recording the erasure in the audit log. A user without subscriptions erases nothing.
+// EraseUserData удаляет все подписки пользователя по запросу субъекта данных на удаление
+// и записывает удаление в журнал аудита. Для пользователя без подписок ничего не удаляется.
+func (s *SubscriptionService) EraseUserData(ctx context.Context, userID string) (int64, error) {
+	if err := validations.ValidateUserID(userID); err != nil {
+		return 0, err
+	}
+
+	deleted, err := s.repo.DeleteSubscriptionsByUserID(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	// the precomputed summary months are user data too
+	// предварительно вычисленные месяцы сводки — тоже данные пользователя
+	if err := s.repo.DeleteSummaryCache(ctx, userID); err != nil {
+		return 0, err
+	}
+
+	s.Logger.WithFields(logrus.Fields{"audit": "user_data_erased", "user_id": userID, "deleted": deleted}).Info("user data has been erased")
+	return deleted, nil
+}
+
+// GetTeamStats computes the combined spend of a team of users. Months are deduplicated within
+// each user the same way as the user summary, never across users, and the team total is their sum.
+// GetTeamStats вычисляет общие расходы команды пользователей. Месяцы дедуплицируются внутри
+// каждого пользователя так же, как в сводке пользователя, но не между пользователями; итог команды — их сумма.
+func (s *SubscriptionService) GetTeamStats(ctx context.Context, req *models.TeamStatsRequest) (*models.TeamStatsResponse, error) {
+	// lowercase the IDs as Postgres renders UUIDs, so they match the fetched rows
+	// привести ID к нижнему регистру, как Postgres отображает UUID, чтобы они совпадали с полученными строками
+	userIDs := make([]string, len(req.UserIDs))
+	for i, userID := range req.UserIDs {
+		if err := validations.ValidateUserID(userID); err != nil {
+			return nil, err
+		}
+		userIDs[i] = strings.ToLower(userID)
+	}
+	userIDs = slices.Compact(slices.Sorted(slices.Values(userIDs)))
+
+	periodStart, periodEnd, err := s.summaryPeriod(req.From, req.To)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the subscriptions of every user at once
+	// Получить подписки всех пользователей за один раз
+	subscriptions, err := s.repo.FindSubscriptionsByUserIDs(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	byUser := make(map[string][]models.Subscription, len(userIDs))
+	for _, sub := range subscriptions {
+		byUser[sub.UserID] = append(byUser[sub.UserID], sub)
+	}
+
+	res := &models.TeamStatsResponse{
+		Period: s.statsPeriod(req.From, req.To, periodStart, periodEnd),
+		Users:  make([]models.UserCostSummary, len(userIDs)),
+	}
+	for i, userID := range userIDs {
+		_, cost, months := CalculateAllServicesMetrics(byUser[userID], periodStart, periodEnd)
+		res.Users[i] = models.UserCostSummary{UserID: userID, TotalCost: cost, TotalMonths: months, SubscriptionCount: len(byUser[userID])}
+		res.TotalCost = addCost(res.TotalCost, cost)
+	}
+	return res, nil
+}
+
+// orderPeriod checks that the "from" bound of a date range is not later than "to", the same way for every
+// date range endpoint: with STRICT_DATE_ORDER a reversed range is rejected, otherwise the bounds are swapped
+// with a warning. Bounds that are missing or malformed are returned as they are for the caller to validate.
+// orderPeriod проверяет, что граница "from" диапазона дат не позже "to", одинаково для всех эндпоинтов
+// с диапазоном дат: при STRICT_DATE_ORDER обратный диапазон отклоняется, иначе границы меняются местами
+// с предупреждением. Отсутствующие или неверные границы возвращаются как есть для проверки вызывающим.
+func (s *SubscriptionService) orderPeriod(from, to string) (string, string, error) {
+	if from == "" || to == "" {
+		return from, to, nil
+	}
+	start, startErr := utils.ParseMonthYear(from)
+	end, endErr := utils.ParseMonthYear(to)
+	if startErr != nil || endErr != nil || !end.Before(start) {
+		return from, to, nil
+	}
+	if s.config.StrictDateOrder {
+		return "", "", validations.ErrPeriodReversed
+	}
+	s.Logger.Warnf("reversed period from %+v to %+v, swapping the bounds", from, to)
+	return to, from, nil
+}
+
+// summaryPeriod validates the "from" and "to" bounds shared by the summaries and resolves their defaults.
+// summaryPeriod проверяет границы "from" и "to", общие для сводок, и определяет их значения по умолчанию.
+func (s *SubscriptionService) summaryPeriod(from, to string) (time.Time, time.Time, error) {
+	var periodStart, periodEnd time.Time
+	from, to, err := s.orderPeriod(from, to)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
 	}
 
 	//Validate query "from"
 	// if query "from" is empty, periodstart default to time.TIme{}, otherwise it validate the query "from" value.
 	//проверить query "from"
 	// Если значение параметра "from" в запросе пустое, periodstart по умолчанию равен time.TIme{}, в противном случае выполняется проверка значения параметра "from" в запросе.
-	if req.From == "" {
+	if from == "" {
 		periodStart = time.Time{}
 	} else {
-		periodStart, err = validations.ValidateStartDate(req.From)
+		periodStart, err = validations.ValidateStartDate(from)
 		if err != nil {
-			return 0, 0, 0, err
+			return time.Time{}, time.Time{}, err
 		}
 	}
 
 	//Validate query "to"
-	//if no query "to" is given in the query, periodEnd default  to current time, otherwise it validate the query "to" value.
+	//if no query "to" is given in the query, periodEnd default to the current month, otherwise it validate the query "to" value.
 	//проверить query "to"
-	//Если в запросе не указан параметр "to", periodEnd по умолчанию принимает текущее время, в противном случае выполняется проверка значения параметра "to" в запросе.
-	if req.To == "" {
-		now := time.Now()
-		periodEnd = &now
+	//Если в запросе не указан параметр "to", periodEnd по умолчанию — текущий месяц, в противном случае выполняется проверка значения параметра "to" в запросе.
+	if to == "" {
+		periodEnd = utils.StartOfMonth(utils.Now())
 	} else {
-		periodEnd, err = validations.ValidateEndDate(periodStart, req.To)
+		end, err := validations.ValidateEndDate(periodStart, to)
 		if err != nil {
-			return 0, 0, 0, err
+			return time.Time{}, time.Time{}, err
 		}
+		periodEnd = *end
 	}
 
-	// Get all subscriptions for user
-	// Получить все подписки пользователя
-	subscriptions, err := s.repo.FindSubscriptionsByUserIDandServiceName(ctx, req.UserID, req.ServiceName)
+	//Apply DEFAULT_STATS_PERIOD when query "from" is omitted.
+	//Применить DEFAULT_STATS_PERIOD, если параметр "from" не указан.
+	if from == "" {
+		periodStart = s.defaultPeriodStart(periodEnd)
+	}
+
+	return periodStart, periodEnd, nil
+}
+
+// defaultPeriodStart returns the start of the DEFAULT_STATS_PERIOD window ending with the month of periodEnd,
+// the zero time when the default period is all_time.
+// defaultPeriodStart возвращает начало окна DEFAULT_STATS_PERIOD, заканчивающегося месяцем periodEnd,
+// нулевое время, если период по умолчанию — all_time.
+func (s *SubscriptionService) defaultPeriodStart(periodEnd time.Time) time.Time {
+	if s.config.DefaultStatsMonths <= 0 {
+		return time.Time{}
+	}
+	return utils.StartOfMonth(periodEnd).AddDate(0, -(s.config.DefaultStatsMonths - 1), 0)
+}
+
+// StatsPeriod describes the period covered by stats requested with from and to, as already validated by the
+// stats call, naming DEFAULT_STATS_PERIOD when the client omitted a bound.
+// StatsPeriod описывает период статистики, запрошенной с from и to, уже проверенными вызовом статистики,
+// и указывает DEFAULT_STATS_PERIOD, если клиент не передал границу.
+func (s *SubscriptionService) StatsPeriod(from, to string) models.StatsPeriod {
+	periodStart, periodEnd, _ := s.summaryPeriod(from, to)
+	return s.statsPeriod(from, to, periodStart, periodEnd)
+}
+
+// statsPeriod builds the period meta of stats resolved to periodStart and periodEnd, from being null when unbounded.
+// statsPeriod формирует метаданные периода статистики, определенного как periodStart и periodEnd; from равен null без ограничения.
+func (s *SubscriptionService) statsPeriod(from, to string, periodStart, periodEnd time.Time) models.StatsPeriod {
+	period := models.StatsPeriod{To: utils.FormatMonthYear(periodEnd)}
+	if !periodStart.IsZero() {
+		start := utils.FormatMonthYear(periodStart)
+		period.From = &start
+	}
+	if from == "" || to == "" {
+		period.Default = s.config.DefaultStatsPeriod
+	}
+	return period
+}
+
+// GetCostPerMonth computes the effective monthly cost of a subscription within a period.
+// The period defaults to the subscription start up to the current month.
+// GetCostPerMonth вычисляет эффективную ежемесячную стоимость подписки за период.
+// По умолчанию период — от начала подписки до текущего месяца.
+func (s *SubscriptionService) GetCostPerMonth(ctx context.Context, id uint, req *models.CostPerMonthRequest) (*models.CostPerMonthResponse, error) {
+	from, to, err := s.orderPeriod(req.From, req.To)
 	if err != nil {
-		return 0, 0, 0, err
+		return nil, err
 	}
+	req.From, req.To = from, to
 
-	// Calculate total cost and unique months for user's subscription
-	// Рассчитать общую стоимость и количество уникальных месяцев подписки пользователя
-	unitPrice, totalCost, totalUniqueMonths := CalculateSubscriptionMetrics(
-		subscriptions,
-		periodStart,
-		*periodEnd,
-	)
+	sub, err := s.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
 
-	s.Logger.Infof("subscription metrics: UserID: %+v, ServiceName: %+v, TotalMonths: %+v, TotalCost: %+v", req.UserID, req.ServiceName, totalUniqueMonths, totalCost)
+	//Validate query "from", defaults to the subscription start
+	//проверить query "from", по умолчанию — начало подписки
+	periodStart := sub.StartDate
+	if req.From != "" {
+		periodStart, err = validations.ValidateStartDate(req.From)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	return unitPrice, totalCost, totalUniqueMonths, nil
+	//Validate query "to", defaults to the current month
+	//проверить query "to", по умолчанию — текущий месяц
+	periodEnd := utils.StartOfMonth(utils.Now())
+	if req.To != "" {
+		end, err := validations.ValidateEndDate(periodStart, req.To)
+		if err != nil {
+			return nil, err
+		}
+		periodEnd = *end
+	}
+
+	// Count the months the subscription is active within the period
+	// Подсчитать месяцы, в которые подписка активна в течение периода
+	activeMonths := 0
+	effectiveStart := utils.MaxTime(sub.StartDate, periodStart)
+	effectiveEnd := periodEnd
+	if sub.EndDate != nil && !sub.EndDate.IsZero() {
+		effectiveEnd = utils.MinTime(*sub.EndDate, periodEnd)
+	}
+	if !effectiveStart.After(effectiveEnd) {
+		activeMonths = AddOverlapMonths(make(map[string]bool), effectiveStart, effectiveEnd)
+	}
+
+	// Amortize the total cost over the active months, zero when it was never active
+	// Распределить общую стоимость по активным месяцам, ноль, если подписка не была активна
+	totalCost := int64(sub.Price) * int64(activeMonths)
+	var costPerMonth float64
+	if activeMonths > 0 {
+		costPerMonth = float64(totalCost) / float64(activeMonths)
+	}
+
+	return &models.CostPerMonthResponse{
+		ID:           sub.ExposedID(),
+		From:         utils.FormatMonthYear(periodStart),
+		To:           utils.FormatMonthYear(periodEnd),
+		ActiveMonths: activeMonths,
+		TotalCost:    totalCost,
+		CostPerMonth: costPerMonth,
+	}, nil
+}
+
+// maxTimelineMonths bounds the number of points of a subscription timeline.
+// maxTimelineMonths ограничивает количество точек временной шкалы подписки.
+const maxTimelineMonths = 1200
+
+// GetSubscriptionTimeline returns the monthly cost contribution of a subscription over a period,
+// by default its own lifespan, open-ended subscriptions running up to the current month.
+// Months of the period in which the subscription isn't active contribute 0.
+// GetSubscriptionTimeline возвращает помесячный вклад подписки в стоимость за период,
+// по умолчанию за срок ее действия, бессрочные подписки учитываются до текущего месяца.
+// Месяцы периода, в которые подписка не активна, дают 0.
+func (s *SubscriptionService) GetSubscriptionTimeline(ctx context.Context, id uint, req *models.SubscriptionTimelineRequest) (*models.SubscriptionTimelineResponse, error) {
+	from, to, err := s.orderPeriod(req.From, req.To)
+	if err != nil {
+		return nil, err
+	}
+	req.From, req.To = from, to
+
+	sub, err := s.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	//Validate query "from", defaults to the subscription start
+	//проверить query "from", по умолчанию — начало подписки
+	periodStart := sub.StartDate
+	if req.From != "" {
+		periodStart, err = validations.ValidateStartDate(req.From)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	//Validate query "to", defaults to the subscription end, or the current month when open-ended
+	//проверить query "to", по умолчанию — конец подписки или текущий месяц для бессрочной
+	periodEnd := utils.StartOfMonth(utils.Now())
+	if sub.EndDate != nil && !sub.EndDate.IsZero() {
+		periodEnd = *sub.EndDate
+	}
+	if req.To != "" {
+		end, err := validations.ValidateEndDate(periodStart, req.To)
+		if err != nil {
+			return nil, err
+		}
+		periodEnd = *end
+	}
+	if periodEnd.Before(periodStart) {
+		// an upcoming open-ended subscription hasn't started by the current month
+		// предстоящая бессрочная подписка еще не началась к текущему месяцу
+		periodEnd = periodStart
+	}
+	if utils.MonthSpan(periodStart, periodEnd) > maxTimelineMonths {
+		return nil, validations.ErrTimelineTooLong
+	}
+
+	// The database sums the subscription over every month of the period, months it isn't active in give 0
+	// База данных суммирует подписку за каждый месяц периода, месяцы без активности дают 0
+	revenue, err := s.repo.RevenueByMonth(ctx, periodStart, periodEnd, repository.RevenueFilter{SubscriptionID: sub.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	// One point per month of the period
+	// Одна точка на каждый месяц периода
+	res := &models.SubscriptionTimelineResponse{
+		ID:     sub.ExposedID(),
+		From:   utils.FormatMonthYear(periodStart),
+		To:     utils.FormatMonthYear(periodEnd),
+		Points: make([]models.TimelinePoint, 0, len(revenue)),
+	}
+	for _, month := range revenue {
+		res.Points = append(res.Points, models.TimelinePoint{Month: utils.FormatMonthYear(month.Month), Cost: month.Revenue})
+		res.TotalCost += month.Revenue
+	}
+	return res, nil
+}
+
+// defaultRevenueMonths is the length of the revenue period when "from" is omitted and DEFAULT_STATS_PERIOD is all_time,
+// as the revenue months are always bounded.
+// defaultRevenueMonths — длина периода выручки, если "from" не указан, а DEFAULT_STATS_PERIOD равен all_time,
+// так как месяцы выручки всегда ограничены.
+const defaultRevenueMonths = 12
+
+// GetRevenueByMonth returns the revenue of every month of a period, of all subscriptions or of one user.
+// The period defaults to DEFAULT_STATS_PERIOD up to and including "to", itself defaulting to the current month.
+// The sums are computed by the database, so the subscriptions are never loaded into memory.
+// GetRevenueByMonth возвращает выручку за каждый месяц периода по всем подпискам или одного пользователя.
+// По умолчанию период — DEFAULT_STATS_PERIOD до "to" включительно, который по умолчанию — текущий месяц.
+// Суммы вычисляются базой данных, поэтому подписки не загружаются в память.
+func (s *SubscriptionService) GetRevenueByMonth(ctx context.Context, req *models.RevenueByMonthRequest) (*models.RevenueByMonthResponse, error) {
+	if req.UserID != "" {
+		if err := validations.ValidateUserID(req.UserID); err != nil {
+			return nil, err
+		}
+	}
+	from, to, err := s.orderPeriod(req.From, req.To)
+	if err != nil {
+		return nil, err
+	}
+
+	//Validate query "to", defaults to the current month
+	//проверить query "to", по умолчанию — текущий месяц
+	periodEnd := utils.StartOfMonth(utils.Now())
+	if to != "" {
+		end, err := validations.ValidateEndDate(time.Time{}, to)
+		if err != nil {
+			return nil, err
+		}
+		periodEnd = *end
+	}
+
+	//Validate query "from", defaults to DEFAULT_STATS_PERIOD, or the last 12 months up to periodEnd when it is all_time
+	//проверить query "from", по умолчанию — DEFAULT_STATS_PERIOD или последние 12 месяцев до periodEnd, если он all_time
+	periodStart := s.defaultPeriodStart(periodEnd)
+	if periodStart.IsZero() {
+		periodStart = periodEnd.AddDate(0, -(defaultRevenueMonths - 1), 0)
+	}
+	if from != "" {
+		periodStart, err = validations.ValidateStartDate(from)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if periodEnd.Before(periodStart) {
+		return nil, validations.ErrEndDateBeforeStart
+	}
+	if utils.MonthSpan(periodStart, periodEnd) > maxTimelineMonths {
+		return nil, validations.ErrTimelineTooLong
+	}
+
+	revenue, err := s.repo.RevenueByMonth(ctx, periodStart, periodEnd, repository.RevenueFilter{UserID: req.UserID})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &models.RevenueByMonthResponse{
+		UserID: req.UserID,
+		From:   utils.FormatMonthYear(periodStart),
+		To:     utils.FormatMonthYear(periodEnd),
+		Period: s.statsPeriod(from, to, periodStart, periodEnd),
+		Points: make([]models.RevenuePoint, 0, len(revenue)),
+	}
+	for _, month := range revenue {
+		res.Points = append(res.Points, models.RevenuePoint{Month: utils.FormatMonthYear(month.Month), Revenue: month.Revenue})
+		res.TotalRevenue += month.Revenue
+	}
+	return res, nil
 }
 
 // DeleteSubscription deletes a subscription by its ID
@@ -244,6 +1159,117 @@ func (s *SubscriptionService) DeleteSubscription(ctx context.Context, id uint) e
 	if err := s.repo.DeleteSubscriptionByID(ctx, sub.ID); err != nil {
 		return err
 	}
+	s.invalidateSummaryCache(ctx, sub.UserID)
+
+	return nil
+}
+
+// GetSubscriptionPeriods returns the continuous ranges of months a user was subscribed to a service,
+// merging adjacent and overlapping subscriptions. The service name is matched case-insensitively.
+// GetSubscriptionPeriods возвращает непрерывные диапазоны месяцев, в которые пользователь был подписан на сервис,
+// объединяя смежные и пересекающиеся подписки. Название сервиса сравнивается без учета регистра.
+func (s *SubscriptionService) GetSubscriptionPeriods(ctx context.Context, req *models.SubscriptionPeriodsRequest) (*models.SubscriptionPeriodsResponse, error) {
+	//validate userId and service_name
+	//проверить UserID и service_name
+	if err := validations.ValidateUserID(req.UserID); err != nil {
+		return nil, err
+	}
+	serviceName, err := validations.ValidateServiceName(req.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+
+	subscriptions, err := s.repo.FindSubscriptionsByUserIDandServiceName(ctx, req.UserID, serviceName, false)
+	if err != nil {
+		return nil, err
+	}
+
+	periods := make([]models.CoveragePeriod, 0)
+	for _, period := range MergePeriods(subscriptions) {
+		coverage := models.CoveragePeriod{From: utils.FormatMonthYear(period.Start)}
+		if period.End != nil {
+			end := utils.FormatMonthYear(*period.End)
+			coverage.To = &end
+		}
+		periods = append(periods, coverage)
+	}
+
+	return &models.SubscriptionPeriodsResponse{
+		UserID:            req.UserID,
+		ServiceName:       serviceName,
+		SubscriptionCount: len(subscriptions),
+		Periods:           periods,
+	}, nil
+}
+
+// GetUserStats returns the number of distinct users with any subscription and with one active now.
+// GetUserStats возвращает количество уникальных пользователей с любой подпиской и с активной в данный момент.
+func (s *SubscriptionService) GetUserStats(ctx context.Context) (int64, int64, error) {
+	totalUsers, err := s.repo.CountDistinctUsers(ctx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	now := utils.Now()
+	activeUsers, err := s.repo.CountDistinctUsers(ctx, &now)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return totalUsers, activeUsers, nil
+}
 
+// normalizeDatesBatchSize is the number of rows rewritten per statement when normalizing dates.
+// normalizeDatesBatchSize — количество строк, переписываемых одним запросом при нормализации дат.
+const normalizeDatesBatchSize = 1000
+
+// NormalizeDates rewrites every start and end date to the first of its month, fixing rows written before dates
+// were normalized on input, and returns the number of subscriptions rewritten.
+// NormalizeDates переписывает все даты начала и окончания на первое число месяца, исправляя строки, записанные
+// до нормализации дат при вводе, и возвращает количество переписанных подписок.
+func (s *SubscriptionService) NormalizeDates(ctx context.Context) (int64, error) {
+	normalized, err := s.repo.NormalizeDates(ctx, normalizeDatesBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	s.Logger.WithFields(logrus.Fields{"audit": "dates_normalized", "normalized": normalized}).Info("subscription dates have been normalized")
+	return normalized, nil
+}
+
+// FindSubscriptionsByUserIDPrefix validates the prefix and returns a page of matching subscriptions for admin lookups.
+// FindSubscriptionsByUserIDPrefix проверяет префикс и возвращает страницу подходящих подписок для поиска администратором.
+func (s *SubscriptionService) FindSubscriptionsByUserIDPrefix(ctx context.Context, req *models.UserPrefixSearchRequest) (int64, []models.Subscription, error) {
+	prefix, err := validations.ValidateUserIDPrefix(req.UserPrefix)
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := s.validateOffset(req.Offset); err != nil {
+		return 0, nil, err
+	}
+	total, subs, err := s.repo.FindSubscriptionsByUserIDPrefix(ctx, prefix, req.Limit, req.Offset)
+	if err != nil {
+		return total, nil, err
+	}
+	return total, subs, s.exposeParentsOf(ctx, subs)
+}
+
+// MaxPrice returns the highest monthly price accepted, 0 when unlimited.
+// MaxPrice возвращает максимальную допустимую месячную цену, 0 — без ограничения.
+func (s *SubscriptionService) MaxPrice() int {
+	return s.config.MaxPrice
+}
+
+// MaxOffset returns the deepest offset accepted by paginated lists, 0 when unlimited.
+// MaxOffset возвращает максимальное смещение для списков с пагинацией, 0 — без ограничения.
+func (s *SubscriptionService) MaxOffset() int {
+	return s.config.MaxOffset
+}
+
+// validateOffset rejects offsets beyond MAX_OFFSET, deep offset scans being slow and mostly scrapers.
+// validateOffset отклоняет смещения больше MAX_OFFSET, так как глубокие сканирования медленные и чаще всего от скраперов.
+func (s *SubscriptionService) validateOffset(offset int) error {
+	if s.config.MaxOffset > 0 && offset > s.config.MaxOffset {
+		return validations.ErrOffsetTooLarge
+	}
 	return nil
 }
@@ -0,0 +1,411 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/config"
+	"github.com/cyb3rkh4l1d/subsapi/internal/models"
+	"github.com/cyb3rkh4l1d/subsapi/internal/utils"
+	"github.com/cyb3rkh4l1d/subsapi/internal/validations"
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestDefaultPeriodStart(t *testing.T) {
+	logger, _ := logtest.NewNullLogger()
+	periodEnd := time.Date(2025, time.June, 15, 0, 0, 0, 0, time.UTC)
+	subs := []models.Subscription{
+		{UserID: "60601fee-2bf1-4721-ae6f-7636e79a0cba", ServiceName: "Netflix", Price: 100, StartDate: time.Date(2015, time.January, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	tests := []struct {
+		name       string
+		months     int
+		wantStart  time.Time
+		wantMonths int
+	}{
+		{"all_time", 0, time.Time{}, 126},
+		{"last_12_months", 12, time.Date(2024, time.July, 1, 0, 0, 0, 0, time.UTC), 12},
+		{"window before the subscription", 240, time.Date(2005, time.July, 1, 0, 0, 0, 0, time.UTC), 126},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewSubscriptionService(nil, &config.Config{DefaultStatsMonths: tt.months}, logrus.NewEntry(logger))
+
+			periodStart := svc.defaultPeriodStart(periodEnd)
+			if !periodStart.Equal(tt.wantStart) {
+				t.Fatalf("defaultPeriodStart = %v, want %v", periodStart, tt.wantStart)
+			}
+			_, totalCost, months := CalculateSubscriptionMetrics(subs, periodStart, periodEnd)
+			if months != tt.wantMonths || totalCost != int64(100*tt.wantMonths) {
+				t.Errorf("months = %d, cost = %d, want %d and %d", months, totalCost, tt.wantMonths, 100*tt.wantMonths)
+			}
+		})
+	}
+}
+
+func TestOmittedRangeUsesDefaultStatsPeriod(t *testing.T) {
+	currentMonth := utils.StartOfMonth(utils.Now())
+	start := func(months int) *string {
+		return ptr(utils.FormatMonthYear(currentMonth.AddDate(0, -(months - 1), 0)))
+	}
+	tests := []struct {
+		name     string
+		period   string
+		months   int
+		from, to string
+		want     models.StatsPeriod
+	}{
+		{"both omitted", "last_12_months", 12, "", "", models.StatsPeriod{From: start(12), To: utils.FormatMonthYear(currentMonth), Default: "last_12_months"}},
+		{"from omitted", "last_3_months", 3, "", "12-2024", models.StatsPeriod{From: ptr("10-2024"), To: "12-2024", Default: "last_3_months"}},
+		{"all_time", config.StatsPeriodAllTime, 0, "", "", models.StatsPeriod{To: utils.FormatMonthYear(currentMonth), Default: config.StatsPeriodAllTime}},
+		{"both given", "last_12_months", 12, "01-2024", "06-2024", models.StatsPeriod{From: ptr("01-2024"), To: "06-2024"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, _ := logtest.NewNullLogger()
+			svc := NewSubscriptionService(nil, &config.Config{DefaultStatsPeriod: tt.period, DefaultStatsMonths: tt.months}, logrus.NewEntry(logger))
+
+			got := svc.StatsPeriod(tt.from, tt.to)
+			if (got.From == nil) != (tt.want.From == nil) || got.From != nil && *got.From != *tt.want.From ||
+				got.To != tt.want.To || got.Default != tt.want.Default {
+				t.Errorf("StatsPeriod(%q, %q) = %s, want %s", tt.from, tt.to, describePeriod(got), describePeriod(tt.want))
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T { return &v }
+
+// describePeriod formats a stats period with its optional start for test messages.
+// describePeriod форматирует период статистики с необязательным началом для сообщений тестов.
+func describePeriod(period models.StatsPeriod) string {
+	from := "null"
+	if period.From != nil {
+		from = *period.From
+	}
+	return from + ".." + period.To + " default " + period.Default
+}
+
+func TestGetUserStatsUsesConfiguredTimezone(t *testing.T) {
+	if err := utils.SetTimezone("Pacific/Kiritimati"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = utils.SetTimezone("UTC") })
+
+	repo := &fakeRepository{}
+	if _, _, err := newTestService(repo).GetUserStats(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if repo.activeAt == nil {
+		t.Fatal("active users counted without a month")
+	}
+	if got := repo.activeAt.Location().String(); got != "Pacific/Kiritimati" {
+		t.Errorf("active month evaluated in %s, want Pacific/Kiritimati", got)
+	}
+}
+
+func TestGetCostPerMonth(t *testing.T) {
+	end := month(2025, time.June)
+	repo := &fakeRepository{subs: []models.Subscription{
+		{ID: 1, UserID: ownerID, ServiceName: "Netflix", Price: 300, StartDate: month(2025, time.January), EndDate: &end},
+	}}
+	svc := newTestService(repo)
+
+	tests := []struct {
+		name         string
+		req          models.CostPerMonthRequest
+		activeMonths int
+		totalCost    int64
+		costPerMonth float64
+	}{
+		{"bounded period", models.CostPerMonthRequest{DateRange: models.DateRange{From: "03-2025", To: "08-2025"}}, 4, 1200, 300},
+		{"period before the start", models.CostPerMonthRequest{DateRange: models.DateRange{From: "01-2024", To: "12-2024"}}, 0, 0, 0},
+		{"period after the end", models.CostPerMonthRequest{DateRange: models.DateRange{From: "07-2025", To: "12-2025"}}, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := svc.GetCostPerMonth(context.Background(), 1, &tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.ActiveMonths != tt.activeMonths || got.TotalCost != tt.totalCost || got.CostPerMonth != tt.costPerMonth {
+				t.Errorf("got %d months, cost %d, %v per month, want %d, %d, %v", got.ActiveMonths, got.TotalCost, got.CostPerMonth, tt.activeMonths, tt.totalCost, tt.costPerMonth)
+			}
+			if got.From != tt.req.From || got.To != tt.req.To {
+				t.Errorf("period = %s..%s, want %s..%s", got.From, got.To, tt.req.From, tt.req.To)
+			}
+		})
+	}
+}
+
+func TestGetServiceStats(t *testing.T) {
+	march := month(2025, time.March)
+	repo := &fakeRepository{subs: []models.Subscription{
+		{ID: 1, UserID: ownerID, ServiceName: "Netflix", Price: 100, StartDate: month(2025, time.January), EndDate: &march},
+		{ID: 2, UserID: ownerID, ServiceName: "Spotify", Price: 500, StartDate: month(2025, time.February), EndDate: &march},
+		{ID: 3, UserID: ownerID, ServiceName: "Netflix", Price: 100, StartDate: month(2025, time.March)},
+		{ID: 4, UserID: memberID, ServiceName: "Netflix", Price: 100, StartDate: month(2025, time.January)},
+	}}
+
+	got, err := newTestService(repo).GetServiceStats(context.Background(), &models.ServiceStatsRequest{UserID: ownerID, DateRange: models.DateRange{From: "01-2025", To: "06-2025"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []models.ServiceSummary{
+		{ServiceName: "Spotify", TotalCost: 1000, Months: 2, SubscriptionCount: 1},
+		{ServiceName: "Netflix", TotalCost: 600, Months: 6, SubscriptionCount: 2},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("services = %+v, want %+v", got, want)
+	}
+}
+
+func TestGetTeamStats(t *testing.T) {
+	march := month(2025, time.March)
+	repo := &fakeRepository{subs: []models.Subscription{
+		{ID: 1, UserID: ownerID, ServiceName: "Netflix", Price: 100, StartDate: month(2025, time.January), EndDate: &march},
+		{ID: 2, UserID: ownerID, ServiceName: "Spotify", Price: 200, StartDate: month(2025, time.January), EndDate: &march},
+		{ID: 3, UserID: memberID, ServiceName: "Netflix", Price: 100, StartDate: month(2025, time.February), EndDate: &march},
+	}}
+
+	got, err := newTestService(repo).GetTeamStats(context.Background(), &models.TeamStatsRequest{
+		UserIDs: []string{ownerID, strings.ToUpper(memberID), memberID},
+		From:    "01-2025",
+		To:      "12-2025",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &models.TeamStatsResponse{TotalCost: 1100, Users: []models.UserCostSummary{
+		{UserID: ownerID, TotalCost: 900, TotalMonths: 3, SubscriptionCount: 2},
+		{UserID: memberID, TotalCost: 200, TotalMonths: 2, SubscriptionCount: 1},
+	}}
+	if got.TotalCost != want.TotalCost || !slices.Equal(got.Users, want.Users) {
+		t.Errorf("team stats = %+v, want %+v", got, want)
+	}
+}
+
+func TestCancelAndExtendSubscription(t *testing.T) {
+	currentMonth := utils.StartOfMonth(utils.Now())
+	tests := []struct {
+		name    string
+		call    func(*SubscriptionService) (*models.Subscription, []string, error)
+		wantEnd time.Time
+		wantErr error
+	}{
+		{"cancel at a month", func(s *SubscriptionService) (*models.Subscription, []string, error) {
+			return s.CancelSubscription(context.Background(), 1, &models.CancelSubscriptionRequest{EndDate: "06-2025"})
+		}, month(2025, time.June), nil},
+		{"cancel defaults to the current month", func(s *SubscriptionService) (*models.Subscription, []string, error) {
+			return s.CancelSubscription(context.Background(), 1, &models.CancelSubscriptionRequest{})
+		}, currentMonth, nil},
+		{"cancel before the start", func(s *SubscriptionService) (*models.Subscription, []string, error) {
+			return s.CancelSubscription(context.Background(), 1, &models.CancelSubscriptionRequest{EndDate: "12-2024"})
+		}, time.Time{}, validations.ErrEndDateBeforeStart},
+		{"extend a fixed-term subscription", func(s *SubscriptionService) (*models.Subscription, []string, error) {
+			return s.ExtendSubscription(context.Background(), 2, &models.ExtendSubscriptionRequest{Periods: 3})
+		}, month(2026, time.February), nil},
+		{"extend an open-ended subscription", func(s *SubscriptionService) (*models.Subscription, []string, error) {
+			return s.ExtendSubscription(context.Background(), 1, &models.ExtendSubscriptionRequest{Periods: 3})
+		}, time.Time{}, validations.ErrOpenEndedExtension},
+		{"extend a missing subscription", func(s *SubscriptionService) (*models.Subscription, []string, error) {
+			return s.ExtendSubscription(context.Background(), 3, &models.ExtendSubscriptionRequest{Periods: 3})
+		}, time.Time{}, validations.ErrSubscriptionNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			november := month(2025, time.November)
+			repo := &fakeRepository{subs: []models.Subscription{
+				{ID: 1, UserID: ownerID, ServiceName: "Netflix", Price: 100, StartDate: month(2025, time.January)},
+				{ID: 2, UserID: ownerID, ServiceName: "Spotify", Price: 100, StartDate: month(2025, time.January), EndDate: &november},
+			}}
+
+			sub, _, err := tt.call(newTestService(repo))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if sub.EndDate == nil || !sub.EndDate.Equal(tt.wantEnd) {
+				t.Errorf("end_date = %v, want %v", sub.EndDate, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestGetSubscriptionTimeline(t *testing.T) {
+	currentMonth := utils.StartOfMonth(utils.Now())
+	march := month(2025, time.March)
+	repo := &fakeRepository{subs: []models.Subscription{
+		{ID: 1, UserID: ownerID, ServiceName: "Netflix", Price: 100, StartDate: month(2025, time.January), EndDate: &march},
+		{ID: 2, UserID: ownerID, ServiceName: "Spotify", Price: 200, StartDate: currentMonth.AddDate(0, -2, 0)},
+	}}
+	svc := newTestService(repo)
+
+	tests := []struct {
+		name  string
+		id    uint
+		req   models.SubscriptionTimelineRequest
+		from  string
+		costs []int64
+	}{
+		{"fixed-term lifespan", 1, models.SubscriptionTimelineRequest{}, "01-2025", []int64{100, 100, 100}},
+		{"fixed-term within a wider period", 1, models.SubscriptionTimelineRequest{DateRange: models.DateRange{From: "12-2024", To: "04-2025"}}, "12-2024", []int64{0, 100, 100, 100, 0}},
+		{"open-ended up to the current month", 2, models.SubscriptionTimelineRequest{}, utils.FormatMonthYear(currentMonth.AddDate(0, -2, 0)), []int64{200, 200, 200}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := svc.GetSubscriptionTimeline(context.Background(), tt.id, &tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var costs []int64
+			var total int64
+			for _, point := range got.Points {
+				costs = append(costs, point.Cost)
+				total += point.Cost
+			}
+			if got.From != tt.from || !slices.Equal(costs, tt.costs) || got.TotalCost != total {
+				t.Errorf("timeline from %s costs %v totalling %d, want from %s costs %v", got.From, costs, got.TotalCost, tt.from, tt.costs)
+			}
+		})
+	}
+}
+
+func TestCompareStatsPeriods(t *testing.T) {
+	repo := &fakeRepository{subs: []models.Subscription{
+		{ID: 1, UserID: ownerID, ServiceName: "Netflix", Price: 100, StartDate: month(2025, time.January)},
+		{ID: 2, UserID: ownerID, ServiceName: "Spotify", Price: 200, StartDate: month(2025, time.April)},
+	}}
+	svc := newTestService(repo)
+	tripled := 200.0
+
+	tests := []struct {
+		name         string
+		aFrom, aTo   string
+		aCost, bCost int64
+		delta        int64
+		deltaPercent *float64
+	}{
+		{"growing spend", "01-2025", "03-2025", 300, 900, 600, &tripled},
+		{"zero base period", "01-2024", "03-2024", 0, 900, 900, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := svc.CompareStatsPeriods(context.Background(), &models.StatsCompareRequest{
+				UserID: ownerID, PeriodAFrom: tt.aFrom, PeriodATo: tt.aTo, PeriodBFrom: "04-2025", PeriodBTo: "06-2025",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.PeriodA.TotalCost != tt.aCost || got.PeriodB.TotalCost != tt.bCost || got.Delta != tt.delta {
+				t.Errorf("period A %d, period B %d, delta %d, want %d, %d, %d", got.PeriodA.TotalCost, got.PeriodB.TotalCost, got.Delta, tt.aCost, tt.bCost, tt.delta)
+			}
+			if (got.DeltaPercent == nil) != (tt.deltaPercent == nil) || got.DeltaPercent != nil && *got.DeltaPercent != *tt.deltaPercent {
+				t.Errorf("delta_percent = %v, want %v", got.DeltaPercent, tt.deltaPercent)
+			}
+		})
+	}
+}
+
+func TestGetQuarterlyStats(t *testing.T) {
+	august := month(2025, time.August)
+	repo := &fakeRepository{subs: []models.Subscription{
+		// February to August spans the first three quarters
+		// с февраля по август охватывает первые три квартала
+		{ID: 1, UserID: ownerID, ServiceName: "Netflix", Price: 100, StartDate: month(2025, time.February), EndDate: &august},
+		{ID: 2, UserID: ownerID, ServiceName: "Spotify", Price: 300, StartDate: month(2026, time.January)},
+		{ID: 3, UserID: memberID, ServiceName: "Netflix", Price: 100, StartDate: month(2025, time.January)},
+	}}
+	svc := newTestService(repo)
+
+	got, err := svc.GetQuarterlyStats(context.Background(), &models.QuarterlyStatsRequest{UserID: ownerID, Year: 2025})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []models.QuarterCost{
+		{Quarter: 1, From: "01-2025", To: "03-2025", TotalCost: 200, Months: 2},
+		{Quarter: 2, From: "04-2025", To: "06-2025", TotalCost: 300, Months: 3},
+		{Quarter: 3, From: "07-2025", To: "09-2025", TotalCost: 200, Months: 2},
+		{Quarter: 4, From: "10-2025", To: "12-2025", TotalCost: 0, Months: 0},
+	}
+	if !slices.Equal(got.Quarters, want) || got.TotalCost != 700 {
+		t.Errorf("quarters = %+v with total %d, want %+v with total 700", got.Quarters, got.TotalCost, want)
+	}
+
+	if _, err := svc.GetQuarterlyStats(context.Background(), &models.QuarterlyStatsRequest{UserID: ownerID, Year: 1969}); !errors.Is(err, validations.ErrInvalidYear) {
+		t.Errorf("year 1969: err = %v, want ErrInvalidYear", err)
+	}
+}
+
+func TestOrderPeriod(t *testing.T) {
+	tests := []struct {
+		name             string
+		strict           bool
+		from, to         string
+		wantFrom, wantTo string
+		wantErr          error
+	}{
+		{"ordered range", true, "01-2025", "03-2025", "01-2025", "03-2025", nil},
+		{"single month", true, "03-2025", "03-2025", "03-2025", "03-2025", nil},
+		{"reversed range rejected when strict", true, "03-2025", "01-2025", "", "", validations.ErrPeriodReversed},
+		{"reversed range swapped when lenient", false, "03-2025", "01-2025", "01-2025", "03-2025", nil},
+		{"open bound left as is", true, "03-2025", "", "03-2025", "", nil},
+		{"malformed bound left as is", true, "13-2025", "01-2025", "13-2025", "01-2025", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, _ := logtest.NewNullLogger()
+			svc := NewSubscriptionService(nil, &config.Config{StrictDateOrder: tt.strict}, logrus.NewEntry(logger))
+
+			from, to, err := svc.orderPeriod(tt.from, tt.to)
+			if !errors.Is(err, tt.wantErr) || from != tt.wantFrom || to != tt.wantTo {
+				t.Errorf("orderPeriod(%q, %q) = %q, %q, %v, want %q, %q, %v", tt.from, tt.to, from, to, err, tt.wantFrom, tt.wantTo, tt.wantErr)
+			}
+		})
+	}
+
+	// a lenient summary covers the swapped range
+	// нестрогая сводка охватывает переставленный диапазон
+	logger, _ := logtest.NewNullLogger()
+	svc := NewSubscriptionService(nil, &config.Config{}, logrus.NewEntry(logger))
+	start, end, err := svc.summaryPeriod("03-2025", "01-2025")
+	if err != nil || !start.Equal(month(2025, time.January)) || !end.Equal(month(2025, time.March)) {
+		t.Errorf("summaryPeriod of a reversed range = %v, %v, %v, want January to March 2025", start, end, err)
+	}
+}
+
+func TestListPeriodCostsMatchStandaloneCost(t *testing.T) {
+	june := month(2025, time.June)
+	repo := &fakeRepository{subs: []models.Subscription{
+		{ID: 1, UserID: ownerID, ServiceName: "Netflix", Price: 100, StartDate: month(2025, time.January), EndDate: &june},
+		{ID: 2, UserID: ownerID, ServiceName: "Spotify", Price: 250, StartDate: month(2025, time.April)},
+		{ID: 3, UserID: memberID, ServiceName: "Yandex Plus", Price: 300, StartDate: month(2026, time.January)},
+	}}
+	svc := newTestService(repo)
+	req := &models.ListSubscriptionRequest{WithCost: true, DateRange: models.DateRange{From: "03-2025", To: "08-2025"}}
+
+	costs, err := svc.ListPeriodCosts(req, repo.subs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, sub := range repo.subs {
+		standalone, err := svc.GetCostPerMonth(context.Background(), sub.ID, &models.CostPerMonthRequest{DateRange: req.DateRange})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if costs[i] != standalone.TotalCost {
+			t.Errorf("subscription %d: listed cost %d, standalone cost %d", sub.ID, costs[i], standalone.TotalCost)
+		}
+	}
+	if want := []int64{400, 1250, 0}; !slices.Equal(costs, want) {
+		t.Errorf("costs = %v, want %v", costs, want)
+	}
+}
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/models"
+	"github.com/cyb3rkh4l1d/subsapi/internal/repository"
+	"github.com/cyb3rkh4l1d/subsapi/internal/tenancy"
+	"github.com/cyb3rkh4l1d/subsapi/internal/utils"
+)
+
+// summaryCacheHorizonMonths is how many months after the current one the summary months are precomputed for,
+// open-ended subscriptions included. Periods ending later are computed live.
+// summaryCacheHorizonMonths — на сколько месяцев после текущего предварительно вычисляются месяцы сводки,
+// включая бессрочные подписки. Периоды, заканчивающиеся позже, вычисляются на лету.
+const summaryCacheHorizonMonths = 24
+
+// summaryCacheBatchSize is the number of stale users loaded per query of a refresh run.
+// summaryCacheBatchSize — количество устаревших пользователей, загружаемых одним запросом за запуск обновления.
+const summaryCacheBatchSize = 100
+
+/*.....................................................................
+
+					Functions/Methods Definations
+
+........................................................................*/
+
+// BuildSummaryMonths precomputes the cost of each service of a user per month, up to the month of horizon.
+// Like CalculateSubscriptionMetrics, a month of a service is charged once, at the price of the first
+// subscription covering it, so summing the months of a period gives the live summary cost.
+// BuildSummaryMonths предварительно вычисляет стоимость каждого сервиса пользователя по месяцам до месяца horizon.
+// Как и в CalculateSubscriptionMetrics, месяц сервиса оплачивается один раз по цене первой покрывающей его
+// подписки, поэтому сумма месяцев периода равна стоимости живой сводки.
+func BuildSummaryMonths(subscriptions []models.Subscription, horizon time.Time) []models.SummaryMonth {
+	months := make([]models.SummaryMonth, 0)
+	for key, group := range GroupByServiceName(subscriptions) {
+		charged := make(map[string]bool)
+		for _, sub := range group {
+			end := utils.StartOfMonth(horizon)
+			if sub.EndDate != nil && !sub.EndDate.IsZero() {
+				end = utils.MinTime(utils.StartOfMonth(*sub.EndDate), end)
+			}
+			for month := utils.StartOfMonth(sub.StartDate); !month.After(end); month = month.AddDate(0, 1, 0) {
+				if charged[monthKey(month)] {
+					continue
+				}
+				charged[monthKey(month)] = true
+				months = append(months, models.SummaryMonth{ServiceKey: key, Month: month, Cost: int64(sub.Price)})
+			}
+		}
+	}
+	return months
+}
+
+// BuildSummaryServices describes each service of a user the way CalculateServiceSummaries and
+// CalculateAllServicesMetrics do: its display name, the price of its first subscription and how many it has.
+// BuildSummaryServices описывает каждый сервис пользователя так же, как CalculateServiceSummaries и
+// CalculateAllServicesMetrics: отображаемое имя, цену первой подписки и их количество.
+func BuildSummaryServices(subscriptions []models.Subscription) []models.SummaryService {
+	groups := GroupByServiceName(subscriptions)
+	services := make([]models.SummaryService, 0, len(groups))
+	for key, group := range groups {
+		// the unit price is the first non-zero price, whatever the period, as in CalculateSubscriptionMetrics
+		// цена за единицу — первая ненулевая цена независимо от периода, как в CalculateSubscriptionMetrics
+		var unitPrice int
+		for _, sub := range group {
+			if unitPrice == 0 {
+				unitPrice = sub.Price
+			}
+		}
+		services = append(services, models.SummaryService{
+			ServiceKey:        key,
+			ServiceName:       group[0].ServiceName,
+			UnitPrice:         unitPrice,
+			SubscriptionCount: len(group),
+		})
+	}
+	return services
+}
+
+// StartSummaryCache refreshes the stale summary months in the background right away and then every interval until ctx is done.
+// StartSummaryCache обновляет устаревшие месяцы сводки в фоне сразу и затем каждые interval до завершения ctx.
+func (s *SubscriptionService) StartSummaryCache(ctx context.Context, interval time.Duration) {
+	go func() {
+		s.RefreshSummaryCache(ctx)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.RefreshSummaryCache(ctx)
+			}
+		}
+	}()
+}
+
+// RefreshSummaryCache recomputes the summary months of every user, of every tenant, that are missing,
+// invalidated or older than half of SUMMARY_CACHE_TTL_MINUTES, batch by batch, and logs how many were refreshed.
+// RefreshSummaryCache пересчитывает месяцы сводки каждого пользователя всех арендаторов, которые отсутствуют,
+// инвалидированы или старше половины SUMMARY_CACHE_TTL_MINUTES, пакет за пакетом, и логирует их количество.
+func (s *SubscriptionService) RefreshSummaryCache(ctx context.Context) {
+	// refreshing before the TTL expires keeps the reads on the cache
+	// обновление до истечения TTL сохраняет чтение из кеша
+	refreshedBefore := time.Now().UTC().Add(-s.summaryCacheTTL() / 2)
+	refreshed, failed := 0, 0
+	var after repository.SummaryCacheKey
+	for {
+		keys, err := s.repo.FindStaleSummaryUsers(ctx, refreshedBefore, after, summaryCacheBatchSize)
+		if err != nil {
+			s.Logger.WithError(err).Warn("summary cache: failed to find stale users")
+			break
+		}
+		for _, key := range keys {
+			if err := s.refreshSummaryUser(tenancy.WithTenant(ctx, key.TenantID), key.UserID); err != nil {
+				failed++
+				s.Logger.WithError(err).Warnf("summary cache: failed to refresh user %+v", key.UserID)
+				continue
+			}
+			refreshed++
+		}
+		if len(keys) < summaryCacheBatchSize {
+			break
+		}
+		after = keys[len(keys)-1]
+	}
+	if refreshed > 0 || failed > 0 {
+		s.Logger.Infof("summary cache: %+v users refreshed, %+v failed", refreshed, failed)
+	}
+}
+
+// refreshSummaryUser recomputes the summary services and months of a user from their subscriptions.
+// The refresh time is taken before loading them, so a change made meanwhile leaves the months stale.
+// refreshSummaryUser пересчитывает сервисы и месяцы сводки пользователя по его подпискам.
+// Время обновления берется до их загрузки, поэтому изменение за это время оставляет месяцы устаревшими.
+func (s *SubscriptionService) refreshSummaryUser(ctx context.Context, userID string) error {
+	refreshedAt := time.Now().UTC()
+	subscriptions, err := s.repo.FindSubscriptionsByUserIDandServiceName(ctx, userID, "", false)
+	if err != nil {
+		return err
+	}
+	horizon := utils.StartOfMonth(utils.Now()).AddDate(0, summaryCacheHorizonMonths, 0)
+	return s.repo.ReplaceSummaryCache(ctx, userID, BuildSummaryServices(subscriptions), BuildSummaryMonths(subscriptions, horizon), refreshedAt, horizon)
+}
+
+// invalidateSummaryCache marks the summary months of a user stale after one of their subscriptions changed.
+// A failure is only logged: the change is saved and the months expire with SUMMARY_CACHE_TTL_MINUTES anyway.
+// invalidateSummaryCache помечает месяцы сводки пользователя устаревшими после изменения его подписки.
+// Ошибка только логируется: изменение сохранено, а месяцы все равно устареют по SUMMARY_CACHE_TTL_MINUTES.
+func (s *SubscriptionService) invalidateSummaryCache(ctx context.Context, userID string) {
+	if !s.config.SummaryCacheEnabled {
+		return
+	}
+	if err := s.repo.InvalidateSummaryCache(ctx, userID, time.Now().UTC()); err != nil {
+		s.Logger.WithError(err).Warnf("summary cache: failed to invalidate user %+v", userID)
+	}
+}
+
+// cachedSummary returns the summary services of a user and their months over a period. It reports false,
+// for the caller to compute the stats live, when the cache is disabled, stale, not covering the period, or failing.
+// cachedSummary возвращает сервисы сводки пользователя и их месяцы за период. Возвращает false, чтобы вызывающий
+// вычислил статистику на лету, если кеш отключен, устарел, не покрывает период или недоступен.
+func (s *SubscriptionService) cachedSummary(
+	ctx context.Context,
+	userID string,
+	periodStart, periodEnd time.Time,
+) ([]models.SummaryService, []models.SummaryMonth, bool) {
+	if !s.config.SummaryCacheEnabled {
+		return nil, nil, false
+	}
+	state, err := s.repo.GetSummaryCacheState(ctx, userID)
+	if err != nil || state == nil || state.RefreshedAt == nil || state.Horizon == nil {
+		return nil, nil, false
+	}
+	if state.InvalidatedAt != nil && !state.InvalidatedAt.Before(*state.RefreshedAt) {
+		return nil, nil, false
+	}
+	if time.Since(*state.RefreshedAt) > s.summaryCacheTTL() || utils.StartOfMonth(periodEnd).After(*state.Horizon) {
+		return nil, nil, false
+	}
+
+	services, err := s.repo.FindSummaryServices(ctx, userID)
+	if err != nil {
+		return nil, nil, false
+	}
+	months, err := s.repo.FindSummaryMonths(ctx, userID, utils.StartOfMonth(periodStart), utils.StartOfMonth(periodEnd))
+	if err != nil {
+		return nil, nil, false
+	}
+	return services, months, true
+}
+
+// cachedPeriodStats returns the unit price, the total cost and the months covered by any subscription of
+// a user over a period, read from the summary cache: the same figures as CalculateAllServicesMetrics.
+// cachedPeriodStats возвращает цену за единицу, общую стоимость и число месяцев, покрытых любой подпиской
+// пользователя за период, из кеша сводки — те же значения, что и CalculateAllServicesMetrics.
+func (s *SubscriptionService) cachedPeriodStats(ctx context.Context, userID string, periodStart, periodEnd time.Time) (int, int64, int, bool) {
+	services, months, ok := s.cachedSummary(ctx, userID, periodStart, periodEnd)
+	if !ok {
+		return 0, 0, 0, false
+	}
+	var unitPrice int
+	if len(services) == 1 {
+		unitPrice = services[0].UnitPrice
+	}
+	totalCost, totalMonths := sumSummaryMonths(months, periodStart, periodEnd)
+	return unitPrice, totalCost, totalMonths, true
+}
+
+// sumSummaryMonths returns the total cost and the months covered by the summary months falling in a period,
+// so that a period read from the cache can be split into shorter ones.
+// sumSummaryMonths возвращает общую стоимость и число месяцев, покрытых месяцами сводки, попадающими в период,
+// чтобы прочитанный из кеша период можно было разбить на более короткие.
+func sumSummaryMonths(months []models.SummaryMonth, periodStart, periodEnd time.Time) (int64, int) {
+	from, to := utils.StartOfMonth(periodStart), utils.StartOfMonth(periodEnd)
+	var totalCost int64
+	covered := make(map[string]bool)
+	for _, month := range months {
+		if month.Month.Before(from) || month.Month.After(to) {
+			continue
+		}
+		totalCost = addCost(totalCost, month.Cost)
+		covered[monthKey(month.Month)] = true
+	}
+	return totalCost, len(covered)
+}
+
+// cachedServiceSummaries returns the metrics of each service of a user over a period, read from the
+// summary cache: the same summaries as CalculateServiceSummaries.
+// cachedServiceSummaries возвращает метрики каждого сервиса пользователя за период из кеша сводки —
+// те же сводки, что и CalculateServiceSummaries.
+func (s *SubscriptionService) cachedServiceSummaries(ctx context.Context, userID string, periodStart, periodEnd time.Time) ([]models.ServiceSummary, bool) {
+	services, months, ok := s.cachedSummary(ctx, userID, periodStart, periodEnd)
+	if !ok {
+		return nil, false
+	}
+	byKey := make(map[string]*models.ServiceSummary, len(services))
+	summaries := make([]models.ServiceSummary, len(services))
+	for i, service := range services {
+		summaries[i] = models.ServiceSummary{ServiceName: service.ServiceName, SubscriptionCount: service.SubscriptionCount}
+		byKey[service.ServiceKey] = &summaries[i]
+	}
+	for _, month := range months {
+		if summary := byKey[month.ServiceKey]; summary != nil {
+			summary.TotalCost = addCost(summary.TotalCost, month.Cost)
+			summary.Months++
+		}
+	}
+	sortServiceSummaries(summaries)
+	return summaries, true
+}
+
+// summaryCacheTTL returns how long refreshed summary months are trusted without invalidation.
+// summaryCacheTTL возвращает, сколько времени обновленным месяцам сводки доверяют без инвалидации.
+func (s *SubscriptionService) summaryCacheTTL() time.Duration {
+	return time.Duration(s.config.SummaryCacheTTL) * time.Minute
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/models"
+)
+
+func TestBuildSummaryMonthsMatchesLiveMetrics(t *testing.T) {
+	end := func(year int, m time.Month) *time.Time {
+		date := month(year, m)
+		return &date
+	}
+	subs := []models.Subscription{
+		{ID: 1, ServiceName: "Netflix", Price: 400, StartDate: month(2024, 1), EndDate: end(2024, 12)},
+		{ID: 2, ServiceName: "netflix ", Price: 600, StartDate: month(2024, 6)},
+		{ID: 3, ServiceName: "Spotify", Price: 200, StartDate: month(2023, 3), EndDate: end(2024, 8)},
+		{ID: 4, ServiceName: "Spotify", Price: 250, StartDate: month(2024, 10), EndDate: end(2025, 2)},
+		{ID: 5, ServiceName: "Yandex Plus", Price: 300, StartDate: month(2028, 1)},
+	}
+	horizon := month(2027, 12)
+	summaryMonths := BuildSummaryMonths(subs, horizon)
+	services := BuildSummaryServices(subs)
+
+	periods := []struct{ start, end time.Time }{
+		{time.Time{}, month(2025, 6)},
+		{month(2024, 1), month(2024, 12)},
+		{month(2024, 7), month(2024, 9)},
+		{month(2025, 3), month(2027, 12)},
+		{month(2020, 1), month(2022, 12)},
+	}
+	for _, period := range periods {
+		// sum the precomputed months the cache would read for the period
+		// сложить предварительно вычисленные месяцы, которые кеш прочитал бы за период
+		var cachedCost int64
+		covered := make(map[string]bool)
+		perService := make(map[string]models.ServiceSummary)
+		for _, m := range summaryMonths {
+			if m.Month.Before(period.start) || m.Month.After(period.end) {
+				continue
+			}
+			cachedCost += m.Cost
+			covered[monthKey(m.Month)] = true
+			summary := perService[m.ServiceKey]
+			summary.TotalCost += m.Cost
+			summary.Months++
+			perService[m.ServiceKey] = summary
+		}
+
+		name := period.start.Format("2006-01") + ".." + period.end.Format("2006-01")
+		t.Run(name, func(t *testing.T) {
+			_, liveCost, liveMonths := CalculateAllServicesMetrics(subs, period.start, period.end)
+			if cachedCost != liveCost || len(covered) != liveMonths {
+				t.Errorf("cached %d over %d months, live %d over %d", cachedCost, len(covered), liveCost, liveMonths)
+			}
+
+			cached := make([]models.ServiceSummary, 0, len(services))
+			for _, service := range services {
+				summary := perService[service.ServiceKey]
+				summary.ServiceName, summary.SubscriptionCount = service.ServiceName, service.SubscriptionCount
+				cached = append(cached, summary)
+			}
+			sortServiceSummaries(cached)
+			if live := CalculateServiceSummaries(subs, period.start, period.end); !slices.Equal(cached, live) {
+				t.Errorf("cached services %+v, live %+v", cached, live)
+			}
+		})
+	}
+}
+
+func TestBuildSummaryServices(t *testing.T) {
+	subs := []models.Subscription{
+		{ServiceName: "Netflix", Price: 400, StartDate: month(2024, 1)},
+		{ServiceName: "netflix", Price: 600, StartDate: month(2024, 6)},
+		{ServiceName: "Spotify", Price: 200, StartDate: month(2023, 3)},
+	}
+	services := BuildSummaryServices(subs)
+	slices.SortFunc(services, func(a, b models.SummaryService) int { return a.SubscriptionCount - b.SubscriptionCount })
+
+	want := []models.SummaryService{
+		{ServiceKey: "spotify", ServiceName: "Spotify", UnitPrice: 200, SubscriptionCount: 1},
+		{ServiceKey: "netflix", ServiceName: "Netflix", UnitPrice: 400, SubscriptionCount: 2},
+	}
+	if !slices.Equal(services, want) {
+		t.Errorf("services = %+v, want %+v", services, want)
+	}
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/models"
+	"github.com/cyb3rkh4l1d/subsapi/internal/utils"
+)
+
+const (
+	// priceOutlierFactor is how many times the service average a price must exceed to be reported.
+	// priceOutlierFactor — во сколько раз цена должна превышать среднюю по сервису, чтобы о ней сообщить.
+	priceOutlierFactor = 3
+	// priceOutlierMinSamples is the number of existing subscriptions needed for a meaningful average.
+	// priceOutlierMinSamples — количество существующих подписок, необходимое для осмысленного среднего.
+	priceOutlierMinSamples = 3
+)
+
+// WarningCheck inspects a valid subscription before it is saved and returns a non-blocking
+// warning, or an empty string when nothing is suspicious.
+// WarningCheck проверяет корректную подписку перед сохранением и возвращает неблокирующее
+// предупреждение или пустую строку, если ничего подозрительного нет.
+type WarningCheck func(ctx context.Context, sub *models.Subscription) (string, error)
+
+// RegisterWarningCheck adds a check run by CollectWarnings on every create, update and batch validation.
+// RegisterWarningCheck добавляет проверку, выполняемую CollectWarnings при каждом создании, обновлении и пакетной проверке.
+func (s *SubscriptionService) RegisterWarningCheck(check WarningCheck) {
+	s.warningChecks = append(s.warningChecks, check)
+}
+
+// CollectWarnings runs every registered check against the subscription. Checks never block
+// the request: a failing check is logged and skipped.
+// CollectWarnings выполняет все зарегистрированные проверки для подписки. Проверки никогда не блокируют
+// запрос: ошибка проверки записывается в журнал и пропускается.
+func (s *SubscriptionService) CollectWarnings(ctx context.Context, sub *models.Subscription) []string {
+	var warnings []string
+	for _, check := range s.warningChecks {
+		warning, err := check(ctx, sub)
+		if err != nil {
+			s.Logger.WithError(err).Warn("warning check failed, skipping")
+			continue
+		}
+		if warning != "" {
+			warnings = append(warnings, warning)
+		}
+	}
+	return warnings
+}
+
+// priceOutlierCheck warns when the price is far above the average price other subscriptions pay for the same service.
+// priceOutlierCheck предупреждает, если цена намного выше средней цены других подписок на тот же сервис.
+func (s *SubscriptionService) priceOutlierCheck(ctx context.Context, sub *models.Subscription) (string, error) {
+	average, count, err := s.repo.AveragePriceByServiceName(ctx, sub.ServiceName, sub.ID)
+	if err != nil {
+		return "", err
+	}
+	if count < priceOutlierMinSamples || average <= 0 {
+		return "", nil
+	}
+	if float64(sub.Price) > average*priceOutlierFactor {
+		return fmt.Sprintf("price %d is more than %d times the average price %.2f for %s", sub.Price, priceOutlierFactor, average, sub.ServiceName), nil
+	}
+	return "", nil
+}
+
+// duplicateCheck warns when the user already has a subscription to the same service, in any case, starting the same month.
+// duplicateCheck предупреждает, если у пользователя уже есть подписка на тот же сервис, в любом регистре, начинающаяся в том же месяце.
+func (s *SubscriptionService) duplicateCheck(ctx context.Context, sub *models.Subscription) (string, error) {
+	existing, err := s.repo.FindSubscriptionsByUserIDandServiceName(ctx, sub.UserID, sub.ServiceName, false)
+	if err != nil {
+		return "", err
+	}
+	for _, other := range existing {
+		if other.ID != sub.ID && other.StartDate.Equal(sub.StartDate) {
+			return fmt.Sprintf("duplicates subscription %s to %s starting %s", other.ExposedID(), other.ServiceName, utils.FormatMonthYear(other.StartDate)), nil
+		}
+	}
+	return "", nil
+}
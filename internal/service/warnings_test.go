@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cyb3rkh4l1d/subsapi/internal/config"
+	"github.com/cyb3rkh4l1d/subsapi/internal/models"
+	"github.com/cyb3rkh4l1d/subsapi/internal/repository"
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+)
+
+// averagePriceRepository answers AveragePriceByServiceName with a fixed average and count.
+// averagePriceRepository отвечает на AveragePriceByServiceName фиксированными средним и количеством.
+type averagePriceRepository struct {
+	repository.Repository
+	average float64
+	count   int64
+	err     error
+}
+
+func (r *averagePriceRepository) AveragePriceByServiceName(context.Context, string, uint) (float64, int64, error) {
+	return r.average, r.count, r.err
+}
+
+// FindSubscriptionsByUserIDandServiceName finds no other subscription, so the duplicate check stays silent.
+// FindSubscriptionsByUserIDandServiceName не находит других подписок, поэтому проверка дубликатов молчит.
+func (r *averagePriceRepository) FindSubscriptionsByUserIDandServiceName(context.Context, string, string, bool) ([]models.Subscription, error) {
+	return nil, nil
+}
+
+func TestPriceOutlierWarning(t *testing.T) {
+	tests := []struct {
+		name  string
+		repo  *averagePriceRepository
+		price int
+		warn  bool
+	}{
+		{"far above the average", &averagePriceRepository{average: 100, count: 5}, 301, true},
+		{"at three times the average", &averagePriceRepository{average: 100, count: 5}, 300, false},
+		{"too few samples", &averagePriceRepository{average: 100, count: 2}, 1000, false},
+		{"no other subscription", &averagePriceRepository{}, 1000, false},
+		{"failing check is skipped", &averagePriceRepository{err: errors.New("boom")}, 1000, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, _ := logtest.NewNullLogger()
+			svc := NewSubscriptionService(tt.repo, &config.Config{}, logrus.NewEntry(logger))
+
+			warnings := svc.CollectWarnings(context.Background(), &models.Subscription{ServiceName: "Netflix", Price: tt.price})
+			if got := len(warnings) == 1; got != tt.warn {
+				t.Fatalf("warnings = %q, want a warning: %v", warnings, tt.warn)
+			}
+			if tt.warn && !strings.Contains(warnings[0], "Netflix") {
+				t.Errorf("warning %q does not name the service", warnings[0])
+			}
+		})
+	}
+}
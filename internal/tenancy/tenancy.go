@@ -0,0 +1,28 @@
+package tenancy
+
+import "context"
+
+// contextKey is the unexported type of the tenant context key, so no other package can collide with it.
+// contextKey — неэкспортируемый тип ключа контекста арендатора, чтобы другие пакеты не могли с ним пересечься.
+type contextKey struct{}
+
+/*.....................................................................
+
+					Functions/Methods Definations
+
+........................................................................*/
+
+// WithTenant returns a copy of ctx carrying the tenant every repository query is scoped to.
+// WithTenant возвращает копию ctx с арендатором, которым ограничивается каждый запрос репозитория.
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, tenantID)
+}
+
+// FromContext returns the tenant carried by ctx, if any. Without a tenant queries are not scoped,
+// as for admin endpoints and background jobs.
+// FromContext возвращает арендатора из ctx, если он есть. Без арендатора запросы не ограничиваются,
+// как для эндпоинтов администратора и фоновых задач.
+func FromContext(ctx context.Context) (string, bool) {
+	tenantID, ok := ctx.Value(contextKey{}).(string)
+	return tenantID, ok
+}
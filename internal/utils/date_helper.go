@@ -1,24 +1,84 @@
 package utils
 
 import (
+	"errors"
 	"time"
 )
 
+// MonthYearLayout is the MM-YYYY layout accepted for all date inputs and used for outputs by default.
+// MonthYearLayout — формат MM-YYYY, принимаемый для всех входных дат и используемый для вывода по умолчанию.
+const MonthYearLayout = "01-2006"
+
+// ErrInvalidDateLayout is returned when an output layout cannot represent both month and year.
+// ErrInvalidDateLayout возвращается, если формат вывода не может представить месяц и год.
+var ErrInvalidDateLayout = errors.New("invalid date output layout, it must contain month and year")
+
+// location is the timezone used to determine "now" for date based computations.
+// location — часовой пояс, используемый для определения текущего момента в вычислениях с датами.
+var location = time.UTC
+
+// outputLayout is the layout used by FormatMonthYear for every date emitted by the API.
+// outputLayout — формат, используемый FormatMonthYear для всех дат, возвращаемых API.
+var outputLayout = MonthYearLayout
+
+// SetDateOutputLayout configures the layout used for all date outputs.
+// The layout must round-trip both the month and the year of a date.
+// SetDateOutputLayout настраивает формат, используемый для вывода всех дат.
+// Формат должен сохранять месяц и год даты при обратном преобразовании.
+func SetDateOutputLayout(layout string) error {
+	reference := time.Date(2025, time.November, 1, 0, 0, 0, 0, time.UTC)
+	parsed, err := time.Parse(layout, reference.Format(layout))
+	if err != nil || parsed.Year() != reference.Year() || parsed.Month() != reference.Month() {
+		return ErrInvalidDateLayout
+	}
+	outputLayout = layout
+	return nil
+}
+
+// DateOutputLayout returns the layout currently used for date outputs.
+// DateOutputLayout возвращает формат, используемый в данный момент для вывода дат.
+func DateOutputLayout() string {
+	return outputLayout
+}
+
+// SetTimezone configures the timezone used by Now, e.g. "Europe/Moscow".
+// SetTimezone настраивает часовой пояс, используемый Now, например "Europe/Moscow".
+func SetTimezone(name string) error {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return err
+	}
+	location = loc
+	return nil
+}
+
+// Now returns the current time in the configured timezone.
+// Now возвращает текущее время в настроенном часовом поясе.
+func Now() time.Time {
+	return time.Now().In(location)
+}
+
+// StartOfMonth returns the first day of the month of t at midnight UTC, matching how dates are stored.
+// StartOfMonth возвращает первый день месяца t в полночь UTC, как хранятся даты.
+func StartOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
 // ParseMonthYear parses strings like "07-2025" into time.Time
 // with day set to the first day of the month.
 // ParseMonthYear преобразует строки типа "07-2025" в time.Time
 // где day устанавливается на первый день месяца.
 func ParseMonthYear(value string) (time.Time, error) {
-	return time.Parse("01-2006", value)
+	return time.Parse(MonthYearLayout, value)
 }
 
-// FormatMonthYear, convert time to mm-yyyy format
-// FormatMonthYear, преобразование времени в формат мм-гггг
+// FormatMonthYear, convert time to the configured output format (mm-yyyy by default)
+// FormatMonthYear, преобразование времени в настроенный формат вывода (по умолчанию мм-гггг)
 func FormatMonthYear(t time.Time) string {
 	if t.IsZero() {
 		return ""
 	}
-	return t.Format("01-2006")
+	return t.Format(outputLayout)
 }
 
 // MaxTime returns the later of two time values (maximum)
@@ -38,3 +98,9 @@ func MinTime(a, b time.Time) time.Time {
 	}
 	return b
 }
+
+// MonthSpan returns the number of months from the month of start to the month of end, both included.
+// MonthSpan возвращает количество месяцев от месяца start до месяца end включительно.
+func MonthSpan(start, end time.Time) int {
+	return (end.Year()-start.Year())*12 + int(end.Month()-start.Month()) + 1
+}
@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseFormatMonthYearRoundTrip(t *testing.T) {
+	for _, value := range []string{"01-1970", "07-2025", "12-2099"} {
+		parsed, err := ParseMonthYear(value)
+		if err != nil {
+			t.Fatalf("ParseMonthYear(%q): %v", value, err)
+		}
+		if parsed.Day() != 1 || parsed.Location() != time.UTC {
+			t.Errorf("ParseMonthYear(%q) = %v, want the first of the month in UTC", value, parsed)
+		}
+		if got := FormatMonthYear(parsed); got != value {
+			t.Errorf("FormatMonthYear(ParseMonthYear(%q)) = %q", value, got)
+		}
+	}
+}
+
+func TestParseMonthYearRejectsInvalid(t *testing.T) {
+	for _, value := range []string{"", "13-2025", "00-2025", "7-2025", "2025-07", "07/2025"} {
+		if _, err := ParseMonthYear(value); err == nil {
+			t.Errorf("ParseMonthYear(%q) succeeded, want an error", value)
+		}
+	}
+}
+
+func TestFormatMonthYearZeroTime(t *testing.T) {
+	if got := FormatMonthYear(time.Time{}); got != "" {
+		t.Errorf("FormatMonthYear(zero) = %q, want empty", got)
+	}
+}
+
+func TestFormatMonthYearOutputLayout(t *testing.T) {
+	t.Cleanup(func() { outputLayout = MonthYearLayout })
+
+	if err := SetDateOutputLayout("2006-01"); err != nil {
+		t.Fatal(err)
+	}
+	if got := FormatMonthYear(time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)); got != "2025-07" {
+		t.Errorf("FormatMonthYear = %q, want 2025-07", got)
+	}
+	if err := SetDateOutputLayout("2006"); err != ErrInvalidDateLayout {
+		t.Errorf("SetDateOutputLayout without month = %v, want ErrInvalidDateLayout", err)
+	}
+}
@@ -0,0 +1,67 @@
+package validations
+
+import "errors"
+
+// errorCodes maps the errors returned to clients to stable machine-readable codes, independent of the response language.
+// errorCodes сопоставляет ошибки, возвращаемые клиентам, стабильным машиночитаемым кодам, не зависящим от языка ответа.
+var errorCodes = map[error]string{
+	ErrInvalidServiceName:    "invalid_service_name",
+	ErrInvalidSubscriptionID: "invalid_subscription_id",
+	ErrInvalidPrice:          "invalid_price",
+	ErrPriceTooHigh:          "price_too_high",
+	ErrDescriptionTooLong:    "description_too_long",
+	ErrInvalidDateFormat:     "invalid_date_format",
+	ErrEndDateBeforeStart:    "end_date_before_start",
+	ErrInvalidUserID:         "invalid_user_id",
+	ErrEmptyUserID:           "empty_user_id",
+	ErrSubscriptionExists:    "subscription_exists",
+	ErrSubscriptionNotFound:  "subscription_not_found",
+	ErrServiceNotFound:       "service_not_found",
+	ErrInvalidStartDate:      "invalid_start_date",
+	ErrInvalidEndDate:        "invalid_end_date",
+	ErrInvalidYear:           "invalid_year",
+	ErrInvalidRequestInput:   "invalid_request_input",
+	ErrEmptyBody:             "empty_body",
+	ErrInvalidUserIDPrefix:   "invalid_user_id_prefix",
+	ErrParentNotFound:        "parent_not_found",
+	ErrParentIsSelf:          "parent_is_self",
+	ErrParentCycle:           "parent_cycle",
+	ErrOpenEndedExtension:    "open_ended_extension",
+	ErrTimelineTooLong:       "timeline_too_long",
+	ErrPeriodReversed:        "period_reversed",
+	ErrQueryTooLong:          "query_too_long",
+	ErrUnknownQueryParam:     "unknown_query_param",
+	ErrResultTooLarge:        "result_too_large",
+	ErrTooManyInFlight:       "too_many_in_flight",
+	ErrOffsetTooLarge:        "offset_too_large",
+	ErrInvalidCursor:         "invalid_cursor",
+	ErrAdminUnauthorized:     "admin_unauthorized",
+	ErrAdminAPIDisabled:      "admin_api_disabled",
+	ErrTenantUnauthorized:    "tenant_unauthorized",
+	ErrInvalidTenantID:       "invalid_tenant_id",
+	ErrInternalServer:        "internal_error",
+	ErrRouteNotFound:         "route_not_found",
+	ErrServiceUnavailable:    "service_unavailable",
+}
+
+/*.....................................................................
+
+					Functions/Methods Definations
+
+........................................................................*/
+
+// Code returns the stable code of an error returned to clients, or of the first such error it wraps,
+// and an empty string for any other error.
+// Code возвращает стабильный код ошибки, возвращаемой клиентам, или первой такой обернутой ею ошибки,
+// и пустую строку для любой другой ошибки.
+func Code(err error) string {
+	if code, ok := errorCodes[err]; ok {
+		return code
+	}
+	for target, code := range errorCodes {
+		if errors.Is(err, target) {
+			return code
+		}
+	}
+	return ""
+}
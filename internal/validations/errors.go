@@ -6,32 +6,76 @@ var (
 	ErrInvalidServiceName    = errors.New("service name must be provided")
 	ErrInvalidSubscriptionID = errors.New("invalid subscription ID")
 	ErrInvalidPrice          = errors.New("price must be positive integer")
+	ErrPriceTooHigh          = errors.New("price exceeds the maximum monthly price")
+	ErrDescriptionTooLong    = errors.New("description exceeds 500 characters")
 	ErrInvalidDateFormat     = errors.New("invalid date format, expected MM-YYYY")
 	ErrEndDateBeforeStart    = errors.New("end date must not be lessthan start date")
 	ErrInvalidUserID         = errors.New("invalid user ID")
 	ErrEmptyUserID           = errors.New("user ID is empty")
 	ErrSubscriptionExists    = errors.New("subscription already exists")
 	ErrSubscriptionNotFound  = errors.New("subscription not found")
+	ErrServiceNotFound       = errors.New("no subscriptions found for service")
 	ErrInvalidStartDate      = errors.New("invalid start_date format, expected MM-YYYY")
 	ErrInvalidEndDate        = errors.New("invalid end_date format, expected MM-YYYY")
+	ErrInvalidYear           = errors.New("year must be between 1970 and 2100")
 	ErrInvalidRequestInput   = errors.New("invalid request input")
+	ErrEmptyBody             = errors.New("request body is required")
+	ErrInvalidUserIDPrefix   = errors.New("invalid user ID prefix, expected at least 8 hexadecimal characters of a UUID")
+	ErrParentNotFound        = errors.New("parent subscription not found")
+	ErrParentIsSelf          = errors.New("subscription can't be its own parent")
+	ErrParentCycle           = errors.New("parent subscription would create a cycle")
+	ErrOpenEndedExtension    = errors.New("subscription has no end date to extend")
+	ErrTimelineTooLong       = errors.New("timeline period exceeds 1200 months, narrow from and to")
+	ErrPeriodReversed        = errors.New("from must not be later than to")
 	ErrInvalid               = errors.New("invalid query parameters")
+	ErrQueryTooLong          = errors.New("query string is too long")
+	ErrUnknownQueryParam     = errors.New("unknown query parameter")
+	ErrResultTooLarge        = errors.New("result exceeds the maximum number of rows, narrow the query")
+	ErrTooManyInFlight       = errors.New("too many requests in flight, retry later")
+	ErrOffsetTooLarge        = errors.New("offset exceeds the maximum page depth, narrow the filters instead of paging deeper")
+	ErrInvalidCursor         = errors.New("invalid pagination cursor")
+	ErrInternalServer        = errors.New("Internal server error")
+	ErrRouteNotFound         = errors.New("route not found")
+	ErrServiceUnavailable    = errors.New("Service unavailable")
+	//Admin Error
+	ErrAdminUnauthorized = errors.New("admin authorization required")
+	ErrAdminAPIDisabled  = errors.New("admin api is disabled")
+	//Tenant Error
+	ErrTenantUnauthorized = errors.New("tenant API key required")
+	ErrInvalidTenantID    = errors.New("invalid tenant ID, expected 1 to 64 letters, digits, '_' or '-'")
 	//Repo Error
 	ErrCreateSubscriptionFailed       = errors.New("failed to create subscription")
+	ErrUpsertSubscriptionFailed       = errors.New("failed to upsert subscription")
 	ErrListSubscriptionFailed         = errors.New("failed to list subscription")
 	ErrGetSubscriptionByIDFailed      = errors.New("failed to get subscription by ID")
 	ErrUpdateSubscriptionFailed       = errors.New("failed to get update subscription")
 	ErrDeleteSubscriptionFailed       = errors.New("failed to delete subscription")
 	ErrCalculateTotalCostFailed       = errors.New("failed to calculate totalcost")
 	ErrFindSubscriptionByPeriodFailed = errors.New("failed to find subscription by userId or servicename")
+	ErrCountUsersFailed               = errors.New("failed to count users")
+	ErrSumPricesFailed                = errors.New("failed to sum subscription prices")
+	ErrFindSubscriptionByPrefixFailed = errors.New("failed to find subscription by user ID prefix")
+	ErrAveragePriceFailed             = errors.New("failed to compute average price")
+	ErrPriceStatsFailed               = errors.New("failed to compute price statistics")
+	ErrNormalizeDatesFailed           = errors.New("failed to normalize subscription dates")
+	ErrFindSubscriptionByParentFailed = errors.New("failed to find subscription by parent")
+	ErrExplainFailed                  = errors.New("failed to explain query")
+	ErrFindExpiringFailed             = errors.New("failed to find expiring subscriptions")
+	ErrReminderUpdateFailed           = errors.New("failed to update subscription reminder")
+	ErrRevenueByMonthFailed           = errors.New("failed to compute revenue by month")
+	ErrSummaryCacheFailed             = errors.New("failed to access the summary cache")
+	//Notification Error
+	ErrNotificationFailed = errors.New("failed to send notification")
 	//Database Error
 	ErrDbInitializationFailed  = errors.New("failed to initialize db")
 	ErrDbMigrationFailed       = errors.New("migration failed")
 	ErrDbConnectionFailed      = errors.New("failed to connect to database")
 	ErrDbPingFailed            = errors.New("failed to ping db")
 	ErrDbCloseConnectionFailed = errors.New("failed to close database connections")
+	ErrDbSSLConfigInvalid      = errors.New("invalid database TLS configuration")
 	//Config Error
-	ErrConfiLoadFailed = errors.New("failed to load config from environment, config set to default value")
+	ErrConfiLoadFailed    = errors.New("failed to load config from environment, config set to default value")
+	ErrInvalidConfigValue = errors.New("invalid config value")
 
 	//router error
 	ErrServerStartFailed = errors.New("failed to start the server.")
@@ -1,7 +1,10 @@
 package validations
 
 import (
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/cyb3rkh4l1d/subsapi/internal/utils"
 	"github.com/google/uuid"
@@ -19,21 +22,94 @@ func ValidateUserID(userID string) error {
 	return nil
 }
 
-// ValidateServiceName ensures service name is not empty
-// ValidateServiceName гарантирует, что имя сервиса не пустое
-func ValidateServiceName(name string) error {
+// MinUserIDPrefixLength is the shortest user ID prefix accepted for prefix lookups, to avoid broad scans.
+// MinUserIDPrefixLength — минимальная длина префикса ID пользователя для поиска, чтобы избежать широкого сканирования.
+const MinUserIDPrefixLength = 8
+
+// ValidateUserIDPrefix ensures the prefix is long enough and only contains characters of a UUID text form.
+// It returns the prefix lowercased, as Postgres renders UUIDs.
+// ValidateUserIDPrefix гарантирует, что префикс достаточно длинный и содержит только символы текстовой формы UUID.
+// Возвращает префикс в нижнем регистре, как Postgres отображает UUID.
+func ValidateUserIDPrefix(prefix string) (string, error) {
+	prefix = strings.ToLower(prefix)
+	if len(prefix) < MinUserIDPrefixLength || len(prefix) > 36 {
+		return "", ErrInvalidUserIDPrefix
+	}
+	for _, r := range prefix {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'f' || r == '-') {
+			return "", ErrInvalidUserIDPrefix
+		}
+	}
+	return prefix, nil
+}
+
+// ValidateSubscriptionID parses a subscription ID path parameter, which must be a positive integer
+// Функция ValidateSubscriptionID разбирает параметр пути ID подписки, который должен быть положительным целым числом
+func ValidateSubscriptionID(idStr string) (uint, error) {
+	id, err := strconv.ParseUint(idStr, 10, strconv.IntSize)
+	if err != nil || id == 0 {
+		return 0, ErrInvalidSubscriptionID
+	}
+	return uint(id), nil
+}
+
+// ValidatePublicSubscriptionID parses a subscription ID path parameter when subscriptions are exposed by UUID.
+// It returns the UUID in its canonical lowercase form, as Postgres renders it.
+// Функция ValidatePublicSubscriptionID разбирает параметр пути ID подписки, когда подписки доступны по UUID.
+// Возвращает UUID в каноническом виде в нижнем регистре, как его отображает Postgres.
+func ValidatePublicSubscriptionID(idStr string) (string, error) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return "", ErrInvalidSubscriptionID
+	}
+	return id.String(), nil
+}
+
+// ValidateServiceName normalizes a service name by trimming it and collapsing inner whitespace,
+// and ensures the result is not empty. The case is kept as entered for display.
+// ValidateServiceName нормализует имя сервиса, удаляя пробелы по краям и схлопывая внутренние пробелы,
+// и гарантирует, что результат не пустой. Регистр сохраняется в введенном виде для отображения.
+func ValidateServiceName(name string) (string, error) {
+	name = strings.Join(strings.Fields(name), " ")
 	if name == "" {
-		return ErrInvalidServiceName
+		return "", ErrInvalidServiceName
 	}
-	return nil
+	return name, nil
 }
 
-// ValidatePrice ensures the price is positive
-// Функция ValidatePrice гарантирует, что цена положительная
-func ValidatePrice(price int) error {
+// ServiceNameKey returns the case-insensitive form under which service names are compared,
+// so that "Netflix" and "netflix" are treated as the same service.
+// ServiceNameKey возвращает регистронезависимую форму, в которой сравниваются имена сервисов,
+// чтобы "Netflix" и "netflix" считались одним сервисом.
+func ServiceNameKey(name string) string {
+	return strings.ToLower(name)
+}
+
+// MaxDescriptionLength is the longest subscription description accepted, in characters.
+// MaxDescriptionLength — максимальная длина описания подписки в символах.
+const MaxDescriptionLength = 500
+
+// ValidateDescription trims a subscription description and ensures it is not longer than MaxDescriptionLength.
+// An empty description is valid, the field is optional.
+// ValidateDescription удаляет пробелы по краям описания подписки и гарантирует, что оно не длиннее MaxDescriptionLength.
+// Пустое описание допустимо, поле необязательное.
+func ValidateDescription(description string) (string, error) {
+	description = strings.TrimSpace(description)
+	if utf8.RuneCountInString(description) > MaxDescriptionLength {
+		return "", ErrDescriptionTooLong
+	}
+	return description, nil
+}
+
+// ValidatePrice ensures the price is positive and, when maxPrice is set, not above it
+// Функция ValidatePrice гарантирует, что цена положительная и, если задан maxPrice, не превышает его
+func ValidatePrice(price, maxPrice int) error {
 	if price <= 0 {
 		return ErrInvalidPrice
 	}
+	if maxPrice > 0 && price > maxPrice {
+		return ErrPriceTooHigh
+	}
 	return nil
 }
 
@@ -47,6 +123,22 @@ func ValidateStartDate(dateStr string) (time.Time, error) {
 	return startDate, nil
 }
 
+// MinYear and MaxYear bound the years accepted by the yearly stats.
+// MinYear и MaxYear ограничивают годы, принимаемые годовой статистикой.
+const (
+	MinYear = 1970
+	MaxYear = 2100
+)
+
+// ValidateYear ensures a year is between MinYear and MaxYear.
+// ValidateYear гарантирует, что год находится между MinYear и MaxYear.
+func ValidateYear(year int) error {
+	if year < MinYear || year > MaxYear {
+		return ErrInvalidYear
+	}
+	return nil
+}
+
 // ValidateEndDate parses and validates end date, ensures end >= start if provided
 // Функция ValidateEndate анализирует и проверяет дату окончания, обеспечивая, чтобы дата окончания была >= даты начала, если она указана.
 func ValidateEndDate(startDate time.Time, endStr string) (*time.Time, error) {
@@ -0,0 +1,74 @@
+package validations
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidatePrice(t *testing.T) {
+	tests := []struct {
+		name     string
+		price    int
+		maxPrice int
+		want     error
+	}{
+		{"zero", 0, 1000, ErrInvalidPrice},
+		{"negative", -1, 1000, ErrInvalidPrice},
+		{"below the cap", 999, 1000, nil},
+		{"at the cap", 1000, 1000, nil},
+		{"above the cap", 1001, 1000, ErrPriceTooHigh},
+		{"cap disabled", 1 << 40, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidatePrice(tt.price, tt.maxPrice); !errors.Is(err, tt.want) {
+				t.Errorf("ValidatePrice(%d, %d) = %v, want %v", tt.price, tt.maxPrice, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateDescription(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		want        string
+		err         error
+	}{
+		{"empty", "", "", nil},
+		{"trimmed", "  family plan \n", "family plan", nil},
+		{"at the limit in characters", strings.Repeat("я", MaxDescriptionLength), strings.Repeat("я", MaxDescriptionLength), nil},
+		{"over the limit", strings.Repeat("a", MaxDescriptionLength+1), "", ErrDescriptionTooLong},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ValidateDescription(tt.description)
+			if got != tt.want || !errors.Is(err, tt.err) {
+				t.Errorf("ValidateDescription = %q, %v, want %q, %v", got, err, tt.want, tt.err)
+			}
+		})
+	}
+}
+
+func TestValidatePublicSubscriptionID(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+		want string
+		err  error
+	}{
+		{"canonical", "0b6e2a4c-5d1f-4e8a-9c3b-7f2d1e0a9b8c", "0b6e2a4c-5d1f-4e8a-9c3b-7f2d1e0a9b8c", nil},
+		{"upper case", "0B6E2A4C-5D1F-4E8A-9C3B-7F2D1E0A9B8C", "0b6e2a4c-5d1f-4e8a-9c3b-7f2d1e0a9b8c", nil},
+		{"integer", "42", "", ErrInvalidSubscriptionID},
+		{"empty", "", "", ErrInvalidSubscriptionID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ValidatePublicSubscriptionID(tt.id)
+			if got != tt.want || !errors.Is(err, tt.err) {
+				t.Errorf("ValidatePublicSubscriptionID(%q) = %q, %v, want %q, %v", tt.id, got, err, tt.want, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddSubscriptionParent, downAddSubscriptionParent)
+}
+
+// upAddSubscriptionParent links family/group plan members to their primary subscription.
+// Statements are idempotent because 00001 creates the table from the current model.
+// upAddSubscriptionParent связывает участников семейного/группового плана с основной подпиской.
+// Операторы идемпотентны, так как 00001 создает таблицу по текущей модели.
+func upAddSubscriptionParent(ctx context.Context, tx *sql.Tx) error {
+	statements := []string{
+		`ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS parent_id bigint`,
+		`CREATE INDEX IF NOT EXISTS idx_subscriptions_parent_id ON subscriptions (parent_id)`,
+		`DO $$
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_subscriptions_parent') THEN
+				ALTER TABLE subscriptions ADD CONSTRAINT fk_subscriptions_parent
+					FOREIGN KEY (parent_id) REFERENCES subscriptions (id) ON DELETE SET NULL;
+			END IF;
+		END $$`,
+	}
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func downAddSubscriptionParent(ctx context.Context, tx *sql.Tx) error {
+	statements := []string{
+		`ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS fk_subscriptions_parent`,
+		`DROP INDEX IF EXISTS idx_subscriptions_parent_id`,
+		`ALTER TABLE subscriptions DROP COLUMN IF EXISTS parent_id`,
+	}
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
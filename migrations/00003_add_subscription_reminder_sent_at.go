@@ -0,0 +1,26 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddSubscriptionReminderSentAt, downAddSubscriptionReminderSentAt)
+}
+
+// upAddSubscriptionReminderSentAt records when the expiry reminder of a subscription was sent.
+// The statement is idempotent because 00001 creates the table from the current model.
+// upAddSubscriptionReminderSentAt сохраняет время отправки напоминания об окончании подписки.
+// Оператор идемпотентен, так как 00001 создает таблицу по текущей модели.
+func upAddSubscriptionReminderSentAt(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS reminder_sent_at timestamptz`)
+	return err
+}
+
+func downAddSubscriptionReminderSentAt(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `ALTER TABLE subscriptions DROP COLUMN IF EXISTS reminder_sent_at`)
+	return err
+}
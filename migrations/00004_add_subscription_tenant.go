@@ -0,0 +1,42 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddSubscriptionTenant, downAddSubscriptionTenant)
+}
+
+// upAddSubscriptionTenant scopes subscriptions to a tenant, existing rows belong to the default empty tenant.
+// Statements are idempotent because 00001 creates the table from the current model.
+// upAddSubscriptionTenant привязывает подписки к арендатору, существующие строки относятся к пустому арендатору по умолчанию.
+// Операторы идемпотентны, так как 00001 создает таблицу по текущей модели.
+func upAddSubscriptionTenant(ctx context.Context, tx *sql.Tx) error {
+	statements := []string{
+		`ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS tenant_id varchar(64) NOT NULL DEFAULT ''`,
+		`CREATE INDEX IF NOT EXISTS idx_subscriptions_tenant_id ON subscriptions (tenant_id)`,
+	}
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func downAddSubscriptionTenant(ctx context.Context, tx *sql.Tx) error {
+	statements := []string{
+		`DROP INDEX IF EXISTS idx_subscriptions_tenant_id`,
+		`ALTER TABLE subscriptions DROP COLUMN IF EXISTS tenant_id`,
+	}
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
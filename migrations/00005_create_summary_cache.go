@@ -0,0 +1,66 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upCreateSummaryCache, downCreateSummaryCache)
+}
+
+// upCreateSummaryCache creates the precomputed per-service monthly costs and service descriptions read by
+// the stats endpoints and the per-user state telling whether they are fresh.
+// upCreateSummaryCache создает предварительно вычисленные помесячные стоимости и описания сервисов, читаемые
+// эндпоинтами статистики, и состояние пользователя, показывающее их актуальность.
+func upCreateSummaryCache(ctx context.Context, tx *sql.Tx) error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS summary_months (
+			tenant_id varchar(64) NOT NULL DEFAULT '',
+			user_id uuid NOT NULL,
+			service_key varchar(100) NOT NULL,
+			month date NOT NULL,
+			cost bigint NOT NULL,
+			PRIMARY KEY (tenant_id, user_id, service_key, month)
+		)`,
+		`CREATE TABLE IF NOT EXISTS summary_services (
+			tenant_id varchar(64) NOT NULL DEFAULT '',
+			user_id uuid NOT NULL,
+			service_key varchar(100) NOT NULL,
+			service_name varchar(100) NOT NULL,
+			unit_price integer NOT NULL,
+			subscription_count integer NOT NULL,
+			PRIMARY KEY (tenant_id, user_id, service_key)
+		)`,
+		`CREATE TABLE IF NOT EXISTS summary_cache_states (
+			tenant_id varchar(64) NOT NULL DEFAULT '',
+			user_id uuid NOT NULL,
+			refreshed_at timestamptz,
+			invalidated_at timestamptz,
+			horizon date,
+			PRIMARY KEY (tenant_id, user_id)
+		)`,
+	}
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func downCreateSummaryCache(ctx context.Context, tx *sql.Tx) error {
+	statements := []string{
+		`DROP TABLE IF EXISTS summary_cache_states`,
+		`DROP TABLE IF EXISTS summary_services`,
+		`DROP TABLE IF EXISTS summary_months`,
+	}
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddSubscriptionDescription, downAddSubscriptionDescription)
+}
+
+// upAddSubscriptionDescription adds the optional free-text description, existing rows get an empty one.
+// The statement is idempotent because 00001 creates the table from the current model.
+// upAddSubscriptionDescription добавляет необязательное текстовое описание, существующие строки получают пустое.
+// Оператор идемпотентен, так как 00001 создает таблицу по текущей модели.
+func upAddSubscriptionDescription(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS description varchar(500) NOT NULL DEFAULT ''`)
+	return err
+}
+
+func downAddSubscriptionDescription(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `ALTER TABLE subscriptions DROP COLUMN IF EXISTS description`)
+	return err
+}
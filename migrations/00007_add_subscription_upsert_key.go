@@ -0,0 +1,45 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddSubscriptionUpsertKey, downAddSubscriptionUpsertKey)
+}
+
+// upAddSubscriptionUpsertKey adds the upserted flag and the partial unique index ON CONFLICT targets on upsert:
+// one upserted subscription per user, service in any case and start month, while plain creates may still duplicate.
+// The column statement is idempotent because 00001 creates the table from the current model.
+// upAddSubscriptionUpsertKey добавляет флаг upserted и частичный уникальный индекс, на который ссылается ON CONFLICT
+// при upsert: одна подписка upsert на пользователя, сервис в любом регистре и месяц начала, а обычное создание
+// по-прежнему допускает дубликаты. Оператор столбца идемпотентен, так как 00001 создает таблицу по текущей модели.
+func upAddSubscriptionUpsertKey(ctx context.Context, tx *sql.Tx) error {
+	statements := []string{
+		`ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS upserted boolean NOT NULL DEFAULT false`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_subscriptions_upsert_key
+			ON subscriptions (tenant_id, user_id, LOWER(service_name), start_date) WHERE upserted`,
+	}
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func downAddSubscriptionUpsertKey(ctx context.Context, tx *sql.Tx) error {
+	statements := []string{
+		`DROP INDEX IF EXISTS idx_subscriptions_upsert_key`,
+		`ALTER TABLE subscriptions DROP COLUMN IF EXISTS upserted`,
+	}
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
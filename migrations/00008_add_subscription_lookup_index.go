@@ -0,0 +1,28 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddSubscriptionLookupIndex, downAddSubscriptionLookupIndex)
+}
+
+// upAddSubscriptionLookupIndex indexes the case-insensitive lookups by user, service and start month used by the
+// summaries and the duplicate warning. idx_summary_service can't serve them past user_id, as they
+// compare LOWER(service_name).
+// upAddSubscriptionLookupIndex индексирует регистронезависимые поиски по пользователю, сервису и месяцу начала,
+// используемые сводками и предупреждением о дубликатах. idx_summary_service не обслуживает их дальше
+// user_id, так как они сравнивают LOWER(service_name).
+func upAddSubscriptionLookupIndex(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `CREATE INDEX IF NOT EXISTS idx_subscriptions_user_service_start ON subscriptions (user_id, LOWER(service_name), start_date)`)
+	return err
+}
+
+func downAddSubscriptionLookupIndex(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `DROP INDEX IF EXISTS idx_subscriptions_user_service_start`)
+	return err
+}
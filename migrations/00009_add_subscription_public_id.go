@@ -0,0 +1,44 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddSubscriptionPublicID, downAddSubscriptionPublicID)
+}
+
+// upAddSubscriptionPublicID adds the UUID every subscription is exposed under when UUID_SUBSCRIPTION_IDS is set.
+// Existing rows are backfilled by the column default, so a deployment can switch to UUIDs at any time after it.
+// The column statement is idempotent because 00001 creates the table from the current model.
+// upAddSubscriptionPublicID добавляет UUID, под которым доступна каждая подписка при включенном UUID_SUBSCRIPTION_IDS.
+// Существующие строки заполняются значением по умолчанию столбца, поэтому развертывание может перейти на UUID
+// в любой момент после нее. Оператор столбца идемпотентен, так как 00001 создает таблицу по текущей модели.
+func upAddSubscriptionPublicID(ctx context.Context, tx *sql.Tx) error {
+	statements := []string{
+		`ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS public_id uuid NOT NULL DEFAULT gen_random_uuid()`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_subscriptions_public_id ON subscriptions (public_id)`,
+	}
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func downAddSubscriptionPublicID(ctx context.Context, tx *sql.Tx) error {
+	statements := []string{
+		`DROP INDEX IF EXISTS idx_subscriptions_public_id`,
+		`ALTER TABLE subscriptions DROP COLUMN IF EXISTS public_id`,
+	}
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAddSubscriptionTimestamps, downAddSubscriptionTimestamps)
+}
+
+// upAddSubscriptionTimestamps adds the created_at and updated_at auditing timestamps, existing rows get the migration time.
+// The statement is idempotent because 00001 creates the table from the current model.
+// upAddSubscriptionTimestamps добавляет метки времени аудита created_at и updated_at, существующие строки получают время миграции.
+// Оператор идемпотентен, так как 00001 создает таблицу по текущей модели.
+func upAddSubscriptionTimestamps(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `ALTER TABLE subscriptions
+		ADD COLUMN IF NOT EXISTS created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		ADD COLUMN IF NOT EXISTS updated_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP`)
+	return err
+}
+
+func downAddSubscriptionTimestamps(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `ALTER TABLE subscriptions DROP COLUMN IF EXISTS created_at, DROP COLUMN IF EXISTS updated_at`)
+	return err
+}
@@ -1,26 +1,53 @@
 package migrations
 
 import (
+	"database/sql"
+	"errors"
+
 	"github.com/cyb3rkh4l1d/subsapi/internal/database"
 	"github.com/cyb3rkh4l1d/subsapi/internal/validations"
 	"github.com/pressly/goose/v3"
 	"github.com/sirupsen/logrus"
 )
 
+// migrationsDir is the directory goose reads migration files from.
+// migrationsDir — каталог, из которого goose читает файлы миграций.
+const migrationsDir = "migrations"
+
 // MigrateSubscriptions performs automatic database migration for the Subscription model.
 // Uses goose to create or update the 'subscriptions' table schema based on the model.
-// Returns an error if migration fails.
+// Migrations are skipped when skip is set, any failure other than an empty migrations directory is fatal.
 // MigrateSubscriptions выполняет автоматическую миграцию базы данных для модели Subscription.
 // Использует goose для создания или обновления схемы таблицы 'subscriptions' на основе модели.
-// Возвращает ошибку, если миграция не удалась.
-func PostgreSQLMigrateSubscriptions(dbLogger *logrus.Entry) {
-	if err := goose.SetDialect("postgres"); err != nil {
+// Миграции пропускаются, если задан skip; фатальна любая ошибка, кроме пустого каталога миграций.
+func PostgreSQLMigrateSubscriptions(dbLogger *logrus.Entry, skip bool) {
+	if err := migrate(database.PgDriverInstance.Sql_DB, migrationsDir, skip, dbLogger); err != nil {
 		dbLogger.WithError(err).Fatal(validations.ErrDbMigrationFailed)
+	}
+}
 
+// migrate applies the goose migrations of dir to db. A directory without migration files is logged
+// and skipped, as the migrations are then applied externally; a missing directory is an error.
+// migrate применяет миграции goose из dir к db. Каталог без файлов миграций журналируется
+// и пропускается, так как миграции применяются извне; отсутствующий каталог является ошибкой.
+func migrate(db *sql.DB, dir string, skip bool, dbLogger *logrus.Entry) error {
+	if skip {
+		dbLogger.Info("SKIP_MIGRATIONS is set, database migrations are skipped")
+		return nil
 	}
-	if err := goose.Up(database.PgDriverInstance.Sql_DB, "migrations"); err != nil {
-		dbLogger.WithError(err).Fatal(validations.ErrDbMigrationFailed)
+	if err := goose.SetDialect("postgres"); err != nil {
+		return err
+	}
+
+	err := goose.Up(db, dir)
+	if errors.Is(err, goose.ErrNoMigrationFiles) {
+		dbLogger.Infof("no migration files found in %q, skipping database migration", dir)
+		return nil
+	}
+	if err != nil {
+		return err
 	}
 
 	dbLogger.Info("database migration successful.")
+	return nil
 }
@@ -0,0 +1,46 @@
+package migrations
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pressly/goose/v3"
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestMigrate(t *testing.T) {
+	// the Go migrations of this package register themselves on init, an empty directory only
+	// collects nothing without them; no other test of the package runs migrations
+	// Go-миграции этого пакета регистрируются при init, пустой каталог не дает миграций
+	// только без них; другие тесты пакета миграции не выполняют
+	goose.ResetGlobalMigrations()
+
+	tests := []struct {
+		name    string
+		dir     string
+		skip    bool
+		wantErr bool
+		wantLog string
+	}{
+		{"skipped", filepath.Join(t.TempDir(), "missing"), true, false, "SKIP_MIGRATIONS is set"},
+		{"empty directory", t.TempDir(), false, false, "no migration files found"},
+		{"missing directory", filepath.Join(t.TempDir(), "missing"), false, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, hook := logtest.NewNullLogger()
+
+			// a nil database fails any attempt to migrate, so passing means nothing was applied
+			// nil база данных приводит к ошибке любой попытки миграции, поэтому успех означает, что ничего не применялось
+			err := migrate(nil, tt.dir, tt.skip, logrus.NewEntry(logger))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want an error: %v", err, tt.wantErr)
+			}
+			if tt.wantLog != "" && (hook.LastEntry() == nil || !strings.Contains(hook.LastEntry().Message, tt.wantLog)) {
+				t.Errorf("logged %v, want %q", hook.AllEntries(), tt.wantLog)
+			}
+		})
+	}
+}
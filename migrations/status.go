@@ -0,0 +1,27 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/pressly/goose/v3"
+)
+
+// PendingMigrations returns the number of known migrations that have not been applied to the database yet.
+// PendingMigrations возвращает количество известных миграций, которые еще не применены к базе данных.
+func PendingMigrations(ctx context.Context, db *sql.DB) (int, error) {
+	current, err := goose.GetDBVersionContext(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+
+	pending, err := goose.CollectMigrations(migrationsDir, current, goose.MaxVersion)
+	if errors.Is(err, goose.ErrNoMigrationFiles) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(pending), nil
+}